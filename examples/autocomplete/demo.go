@@ -76,7 +76,7 @@ func (m *AutoCompleteModel) Update(msg any) (render.Model, render.Cmd) {
 
 	switch msg := msg.(type) {
 	case render.KeyMsg:
-		switch msg.Key {
+		switch msg.String() {
 		case "q", "ctrl+c":
 			// Quit
 			return m, render.Command(func() error {
@@ -125,8 +125,8 @@ func (m *AutoCompleteModel) Update(msg any) (render.Model, render.Cmd) {
 			}
 		default:
 			// Typing - add to query
-			if len(msg.Key) == 1 && msg.Key[0] >= 32 && msg.Key[0] < 127 {
-				m.currentQuery += msg.Key
+			if len(msg.Text) == 1 && msg.Text[0] >= 32 && msg.Text[0] < 127 {
+				m.currentQuery += msg.Text
 				if !m.autocomplete.IsOpen() {
 					m.autocomplete.Open()
 				}
@@ -42,7 +42,7 @@ func (m *DualEngineModel) Update(msg any) (render.Model, render.Cmd) {
 
 	switch msg := msg.(type) {
 	case render.KeyMsg:
-		switch msg.Key {
+		switch msg.String() {
 		case "q", "ctrl+c":
 			// Quit
 			return m, render.Quit()
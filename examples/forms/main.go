@@ -92,66 +92,13 @@ func (m model) View() string {
 		fmt.Fprintf(&b, "Status: Invalid (%s)\n", err.Error())
 	}
 
-	return b.String()
+	// Draw the text inputs' cursor, which no engine positions here
+	return render.DrawCursor(b.String())
 }
 
 // adaptCmd converts a render.Cmd (engine-agnostic) to a tea.Cmd (Bubbletea specific).
-// It recursively handles Batches and wrapping of function types.
 func adaptCmd(cmd render.Cmd) tea.Cmd {
-	if cmd == nil {
-		return nil
-	}
-
-	switch c := cmd.(type) {
-	case render.BatchCmd:
-		var cmds []tea.Cmd
-		for _, nested := range c {
-			if ac := adaptCmd(nested); ac != nil {
-				cmds = append(cmds, ac)
-			}
-		}
-		return tea.Batch(cmds...)
-	
-	case []render.Cmd: // Just in case it's a raw slice
-		var cmds []tea.Cmd
-		for _, nested := range c {
-			if ac := adaptCmd(nested); ac != nil {
-				cmds = append(cmds, ac)
-			}
-		}
-		return tea.Batch(cmds...)
-
-	case func() render.Msg:
-		return func() tea.Msg {
-			return c()
-		}
-
-	case func() error:
-		return func() tea.Msg {
-			if err := c(); err != nil {
-				return render.ErrorMsg{Error: err}
-			}
-			return nil
-		}
-	
-	case render.Command: // Alias for func() error
-		return func() tea.Msg {
-			if err := c(); err != nil {
-				return render.ErrorMsg{Error: err}
-			}
-			return nil
-		}
-
-	case render.Cmd:
-		// Check for Quit command interface
-		if render.IsQuit(c) {
-			return tea.Quit
-		}
-		// If it's a type we don't know but implements the empty interface (everything does),
-		// check if it's a batch implicitly? No, Go types are strict.
-	}
-
-	return nil
+	return render.TeaCmd(cmd)
 }
 
 func main() {
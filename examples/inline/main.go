@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+// replies are streamed word by word, like a chat transcript.
+var replies = []string{
+	"Inline mode keeps the terminal's normal screen.",
+	"Finished messages are printed above the live region and stay in the scrollback.",
+	"Only the lines below them are redrawn while a reply streams in.",
+}
+
+type wordMsg struct{}
+
+// InlineModel streams replies and commits each one to the scrollback once
+// it is complete.
+type InlineModel struct {
+	reply int
+	words int
+}
+
+// Init starts streaming the first reply
+func (m *InlineModel) Init() render.Cmd {
+	return m.next()
+}
+
+func (m *InlineModel) next() render.Cmd {
+	return render.Tick(80*time.Millisecond, func(time.Time) render.Msg { return wordMsg{} })
+}
+
+// Update handles incoming messages
+func (m *InlineModel) Update(msg any) (render.Model, render.Cmd) {
+	switch msg := msg.(type) {
+	case render.KeyMsg:
+		if msg.String() == "q" || msg.String() == "ctrl+c" {
+			return m, render.Quit()
+		}
+	case wordMsg:
+		if m.reply >= len(replies) {
+			return m, nil
+		}
+		m.words++
+		if m.words < len(strings.Fields(replies[m.reply])) {
+			return m, m.next()
+		}
+
+		// The reply is complete: print it and start the next one
+		done := fmt.Sprintf("assistant: %s", replies[m.reply])
+		m.reply++
+		m.words = 0
+		if m.reply == len(replies) {
+			return m, render.Sequence(render.Println(done), render.Quit())
+		}
+		return m, render.Sequence(render.Println(done), m.next())
+	}
+	return m, nil
+}
+
+// View renders the live region: the partial reply and a status line
+func (m *InlineModel) View() string {
+	if m.reply >= len(replies) {
+		return ""
+	}
+	words := strings.Fields(replies[m.reply])
+	return fmt.Sprintf("assistant: %s▌\n(%d/%d) q to quit",
+		strings.Join(words[:m.words], " "), m.reply+1, len(replies))
+}
+
+func main() {
+	engineName := flag.String("engine", "ultraviolet", "engine to use (bubbletea or ultraviolet)")
+	flag.Parse()
+
+	engineType := render.EngineUltraviolet
+	if *engineName == "bubbletea" {
+		engineType = render.EngineBubbletea
+	}
+
+	engine, err := render.CreateEngine(engineType, render.InlineConfig(2))
+	if err != nil {
+		fmt.Printf("Failed to create engine: %v\n", err)
+		return
+	}
+
+	fmt.Println("user: how does inline rendering work?")
+	if err := engine.Start(&InlineModel{}); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
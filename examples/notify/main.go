@@ -93,15 +93,7 @@ func (m model) View() string {
 }
 
 func adaptCmd(cmd render.Cmd) tea.Cmd {
-	if cmd == nil {
-		return nil
-	}
-	if fn, ok := cmd.(func() render.Msg); ok {
-		return func() tea.Msg {
-			return fn()
-		}
-	}
-	return nil
+	return render.TeaCmd(cmd)
 }
 
 func main() {
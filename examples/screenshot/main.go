@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/rendertest"
+	"github.com/wwsheng009/taproot/ui/render/screenshot"
+)
+
+var (
+	frameStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7c3aed")).
+			Padding(0, 1)
+
+	titleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#7c3aed")).Bold(true)
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#f59e0b")).Bold(true)
+	doneStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#6b7280")).Strikethrough(true)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#6b7280")).Italic(true)
+	progressStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#10b981"))
+)
+
+// TaskModel is a small checklist to take screenshots of.
+type TaskModel struct {
+	tasks  []string
+	done   map[int]bool
+	cursor int
+}
+
+// Init starts the model
+func (m *TaskModel) Init() render.Cmd {
+	return nil
+}
+
+// Update handles incoming messages
+func (m *TaskModel) Update(msg any) (render.Model, render.Cmd) {
+	if msg, ok := msg.(render.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j":
+			m.cursor = min(m.cursor+1, len(m.tasks)-1)
+		case " ", "enter":
+			m.done[m.cursor] = !m.done[m.cursor]
+		case "q":
+			return m, render.Quit()
+		}
+	}
+	return m, nil
+}
+
+// View renders the checklist
+func (m *TaskModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Release checklist") + "\n\n")
+	for i, task := range m.tasks {
+		cursor, box := "  ", "[ ]"
+		if i == m.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		if m.done[i] {
+			box = "[x]"
+			task = doneStyle.Render(task)
+		}
+		b.WriteString(cursor + box + " " + task + "\n")
+	}
+
+	width := 20
+	filled := width * len(m.done) / len(m.tasks)
+	bar := progressStyle.Render(strings.Repeat("█", filled)) + strings.Repeat("░", width-filled)
+	b.WriteString("\n" + bar + fmt.Sprintf(" %d/%d\n\n", len(m.done), len(m.tasks)))
+	b.WriteString(helpStyle.Render("↑/↓ move • space toggle • q quit"))
+	return frameStyle.Render(b.String())
+}
+
+func main() {
+	out := flag.String("out", ".", "directory to write the screenshots to")
+	flag.Parse()
+
+	// Screenshots are taken without a terminal, so lipgloss must not drop
+	// the colors for one
+	lipgloss.SetColorProfile(termenv.TrueColor)
+
+	model := &TaskModel{
+		tasks: []string{"Run the tests", "Update the changelog", "Tag the release", "Publish the docs"},
+		done:  map[int]bool{},
+	}
+	d := rendertest.New(model, 60, 16)
+	defer d.Close()
+	d.Press("space", "down", "space", "down")
+
+	buf := screenshot.FromView(d.View())
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range []string{"checklist.html", "checklist.svg", "checklist.png"} {
+		path := filepath.Join(*out, name)
+		if err := screenshot.Save(path, buf, screenshot.DefaultOptions()); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("wrote", path)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+// CounterModel is the model each connected client gets its own copy of.
+type CounterModel struct {
+	id            int64
+	count         int
+	width, height int
+}
+
+// Init starts the session
+func (m *CounterModel) Init() render.Cmd {
+	return nil
+}
+
+// Update handles incoming messages
+func (m *CounterModel) Update(msg any) (render.Model, render.Cmd) {
+	switch msg := msg.(type) {
+	case render.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case render.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, render.Quit()
+		case "+", "up":
+			m.count++
+		case "-", "down":
+			m.count--
+		}
+	}
+	return m, nil
+}
+
+// View renders the session
+func (m *CounterModel) View() string {
+	return fmt.Sprintf("Session %d (%dx%d)\n\nCount: %d\n\n+/- to change, q to disconnect",
+		m.id, m.width, m.height, m.count)
+}
+
+func main() {
+	path := flag.String("socket", "/tmp/taproot.sock", "unix socket to listen on")
+	width := flag.Int("width", 80, "terminal width of clients")
+	height := flag.Int("height", 24, "terminal height of clients")
+	flag.Parse()
+
+	os.Remove(*path)
+	ln, err := net.Listen("unix", *path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ln.Close()
+	log.Printf("listening on %s; connect with: socat -,raw,echo=0 UNIX-CONNECT:%s", *path, *path)
+
+	var sessions atomic.Int64
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(conn, sessions.Add(1), *width, *height)
+	}
+}
+
+// serve runs an independent engine and model over one connection.
+func serve(conn net.Conn, id int64, width, height int) {
+	defer conn.Close()
+
+	config := render.DefaultConfig()
+	config.Input = conn
+	config.Output = conn
+	config.Width, config.Height = width, height
+	config.Term = "xterm-256color"
+
+	engine, err := render.CreateEngine(render.EngineUltraviolet, config)
+	if err != nil {
+		log.Printf("session %d: %v", id, err)
+		return
+	}
+	log.Printf("session %d connected", id)
+	if err := engine.Start(&CounterModel{id: id}); err != nil {
+		log.Printf("session %d: %v", id, err)
+	}
+	log.Printf("session %d disconnected", id)
+}
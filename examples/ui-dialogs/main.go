@@ -8,6 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/wwsheng009/taproot/ui/dialog"
+	"github.com/wwsheng009/taproot/ui/render"
 	"github.com/wwsheng009/taproot/ui/styles"
 )
 
@@ -77,13 +78,8 @@ func (m *DialogsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *DialogsModel) updateDialog(msg tea.Msg) (tea.Model, tea.Cmd) {
 	activeDialog := m.overlay.ActiveDialog()
 
-	// Convert tea.KeyMsg to render.KeyMsg for dialog compatibility
-	var renderMsg any
-	if keyMsg, ok := msg.(tea.KeyMsg); ok {
-		renderMsg = dialog.ConvertKeyMsg(keyMsg)
-	} else {
-		renderMsg = msg
-	}
+	// Convert tea.KeyMsg to render.KeyMsg (or PasteMsg) for dialog compatibility
+	renderMsg := dialog.ConvertMsg(msg)
 
 	// Update the active dialog
 	updated, _ := activeDialog.Update(renderMsg)
@@ -248,7 +244,8 @@ func (m *DialogsModel) View() string {
 
 	// If dialog is active, overlay it on top of background
 	if m.overlay.HasDialogs() {
-		return m.overlay.Render(backgroundView)
+		// Draw the input dialog's cursor, which no engine positions here
+		return render.DrawCursor(m.overlay.Render(backgroundView))
 	}
 
 	return backgroundView
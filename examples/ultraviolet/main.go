@@ -34,7 +34,7 @@ func (m *UVCounterModel) Update(msg any) (render.Model, render.Cmd) {
 
 	switch msg := msg.(type) {
 	case render.KeyMsg:
-		switch msg.Key {
+		switch msg.String() {
 		case "q", "ctrl+c":
 			// Quit
 			return m, render.Quit()
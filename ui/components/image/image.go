@@ -318,8 +318,8 @@ func (img *Image) renderKitty(width, height int) string {
 	sampledW, sampledH := img.ScaledSize()
 	img.kitty.SetSampledSize(sampledW, sampledH)
 
-	// Set cell size (default)
-	img.kitty.SetCellSize(10, 20)
+	// Set cell size (as reported by the terminal, if probed)
+	img.kitty.SetCellSize(cellSize())
 
 	output := img.kitty.Render(width, height)
 	return output
@@ -335,7 +335,7 @@ func (img *Image) renderITerm2(width, height int) string {
 	sampledW, sampledH := img.ScaledSize()
 	img.iterm.SetSampledSize(sampledW, sampledH)
 
-	img.iterm.SetCellSize(10, 20)
+	img.iterm.SetCellSize(cellSize())
 	return img.iterm.Render(width, height)
 }
 
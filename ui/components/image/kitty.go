@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/wwsheng009/taproot/ui/components/image/decoder"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 // KittyRenderer implements the Kitty graphics protocol
@@ -130,6 +131,11 @@ func (k *KittyRenderer) SupportsAnimation() bool {
 
 // DetectKitty checks if the terminal supports Kitty graphics protocol
 func DetectKitty() bool {
+	// Trust the terminal's own answer once it has been probed
+	if caps := termcap.Current(); caps.Probed {
+		return caps.KittyGraphics
+	}
+
 	// Check TERM environment variable
 	term := termEnv()
 	if strings.Contains(term, "kitty") {
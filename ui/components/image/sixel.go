@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/wwsheng009/taproot/ui/components/image/decoder"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 // SixelRenderer implements the Sixel graphics protocol
@@ -211,6 +212,11 @@ func (s *SixelRenderer) SupportsAnimation() bool {
 
 // DetectSixel checks if the terminal supports Sixel
 func DetectSixel() bool {
+	// Trust the terminal's own answer once it has been probed
+	if caps := termcap.Current(); caps.Probed {
+		return caps.Sixel()
+	}
+
 	// Check TERM variable
 	term := termEnv()
 
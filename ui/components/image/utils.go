@@ -1,6 +1,10 @@
 package image
 
-import "os"
+import (
+	"os"
+
+	"github.com/wwsheng009/taproot/ui/render/termcap"
+)
 
 // env returns the value of an environment variable
 func env(key string) string {
@@ -90,6 +94,15 @@ func detectColorDepth() int {
 	return 1
 }
 
+// cellSize returns the terminal cell size in pixels: the size reported by
+// the terminal once it has been probed, or a typical 10x20 otherwise.
+func cellSize() (int, int) {
+	if w, h, ok := termcap.Current().CellSize(0, 0); ok {
+		return w, h
+	}
+	return 10, 20
+}
+
 // detectWindows detects if running on Windows
 func detectWindows() bool {
 	return env("OS") == "Windows_NT" || env("TERM") == "cygwin" || env("TERM") == "msys"
@@ -261,6 +261,47 @@ func TestSpinner_Update_RenderTickMsg(t *testing.T) {
 	}
 }
 
+func TestSpinner_NeedsRender(t *testing.T) {
+	sp := NewSpinner()
+	sp.Init()
+	if !sp.NeedsRender() || sp.NeedsRender() {
+		t.Fatal("A new spinner should need one render")
+	}
+
+	sp.Update(&TickMsg{id: sp.id + 1})
+	if sp.NeedsRender() {
+		t.Error("A tick for another spinner should not need a render")
+	}
+	sp.Update(&TickMsg{id: sp.id})
+	if !sp.NeedsRender() {
+		t.Error("Advancing a frame should need a render")
+	}
+	sp.SetLabel("Loading")
+	if !sp.NeedsRender() {
+		t.Error("Changing the label should need a render")
+	}
+}
+
+func TestSpinner_PausedWhileUnfocused(t *testing.T) {
+	sp := NewSpinner()
+	sp.Init()
+
+	sp.Update(render.FocusMsg{Focused: false})
+	if !sp.Paused() {
+		t.Fatal("Spinner should pause when the window loses focus")
+	}
+	sp.Update(&TickMsg{id: sp.id})
+	if sp.state != 0 {
+		t.Errorf("Paused spinner should hold its frame, got %d", sp.state)
+	}
+
+	sp.Update(render.FocusMsg{Focused: true})
+	sp.Update(&TickMsg{id: sp.id})
+	if sp.Paused() || sp.state != 1 {
+		t.Errorf("Spinner should resume on focus, got paused=%v state=%d", sp.Paused(), sp.state)
+	}
+}
+
 func TestSpinner_View(t *testing.T) {
 	sp := NewSpinner()
 	sp.Init()
@@ -50,12 +50,17 @@ func DefaultSpinnerStyle() *SpinnerStyle {
 }
 
 // Spinner is an animated spinner component implementing render.Model.
+// It tracks its own changes, so the engine only redraws it when it moved
+// to another frame or was restyled.
 type Spinner struct {
+	render.Dirty
+
 	id           int
 	state        int
 	style        *SpinnerStyle
 	started      bool
 	stopped      bool
+	paused       bool // the terminal window is unfocused
 	initialized  bool
 	tickInterval time.Duration
 }
@@ -89,18 +94,17 @@ func (s *Spinner) Update(msg any) (render.Model, render.Cmd) {
 	}
 
 	switch m := msg.(type) {
+	case render.FocusMsg:
+		// Hold the animation while the terminal window is in the background
+		s.paused = !m.Focused
 	case *TickMsg:
-		if m.id == s.id {
-			s.state++
-			if s.state >= len(s.frames()) {
-				s.state = 0
-			}
+		if m.id == s.id && !s.paused {
+			s.advance()
 		}
 	case *render.TickMsg:
 		// Handle generic tick messages from the render engine
-		s.state++
-		if s.state >= len(s.frames()) {
-			s.state = 0
+		if !s.paused {
+			s.advance()
 		}
 	}
 
@@ -126,6 +130,15 @@ func (s *Spinner) View() string {
 	return rendered
 }
 
+// advance moves to the next frame.
+func (s *Spinner) advance() {
+	s.state++
+	if s.state >= len(s.frames()) {
+		s.state = 0
+	}
+	s.MarkDirty()
+}
+
 // start starts the spinner animation.
 func (s *Spinner) start() {
 	if s.started {
@@ -143,6 +156,13 @@ func (s *Spinner) Stop() {
 func (s *Spinner) Reset() {
 	s.state = 0
 	s.stopped = false
+	s.MarkDirty()
+}
+
+// Paused returns whether the spinner holds its frame because the terminal
+// window is unfocused.
+func (s *Spinner) Paused() bool {
+	return s.paused
 }
 
 // Running returns whether the spinner is running.
@@ -150,7 +170,8 @@ func (s *Spinner) Running() bool {
 	return s.started && !s.stopped
 }
 
-// Style returns the spinner's style configuration.
+// Style returns the spinner's style configuration. Call SetStyle after
+// changing it so the spinner is redrawn.
 func (s *Spinner) Style() *SpinnerStyle {
 	return s.style
 }
@@ -159,6 +180,7 @@ func (s *Spinner) Style() *SpinnerStyle {
 func (s *Spinner) SetStyle(style *SpinnerStyle) {
 	s.style = style
 	s.tickInterval = time.Second / time.Duration(style.FPS)
+	s.MarkDirty()
 }
 
 // Type returns the spinner type.
@@ -169,6 +191,7 @@ func (s *Spinner) Type() SpinnerType {
 // SetType sets the spinner type.
 func (s *Spinner) SetType(spinnerType SpinnerType) {
 	s.style.Type = spinnerType
+	s.MarkDirty()
 }
 
 // Color returns the spinner color.
@@ -179,6 +202,7 @@ func (s *Spinner) Color() lipgloss.Color {
 // SetColor sets the spinner color.
 func (s *Spinner) SetColor(color lipgloss.Color) {
 	s.style.Color = color
+	s.MarkDirty()
 }
 
 // Label returns the spinner's label.
@@ -189,6 +213,7 @@ func (s *Spinner) Label() string {
 // SetLabel sets the spinner's label.
 func (s *Spinner) SetLabel(label string) {
 	s.style.Label = label
+	s.MarkDirty()
 }
 
 // FPS returns the frames per second for the animation.
@@ -5,6 +5,7 @@ import (
 
 	"github.com/wwsheng009/taproot/ui/list"
 	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/rendertest"
 )
 
 func TestButton(t *testing.T) {
@@ -164,6 +165,23 @@ func TestInputField(t *testing.T) {
 			t.Error("expected hidden to be true")
 		}
 	})
+
+	t.Run("Graphemes", func(t *testing.T) {
+		i := NewInputField("")
+		i.SetValue("né🇯🇵")
+		if i.Cursor() != 3 {
+			t.Errorf("expected cursor at 3, got %d", i.Cursor())
+		}
+		i.MoveCursorLeft()
+		i.Delete()
+		if i.Value() != "n🇯🇵" {
+			t.Errorf("expected accented letter deleted, got %q", i.Value())
+		}
+		i.DeleteForward()
+		if i.Value() != "n" {
+			t.Errorf("expected flag deleted, got %q", i.Value())
+		}
+	})
 }
 
 func TestSelectList(t *testing.T) {
@@ -462,3 +480,40 @@ func TestConfirmDialog(t *testing.T) {
 		_ = m
 	})
 }
+
+func TestInputDialogSnapshot(t *testing.T) {
+	var submitted string
+	dlg := NewInputDialog("Rename", "New name", func(s string) { submitted = s })
+	dlg.SetHint("Letters and digits only")
+
+	d := rendertest.New(dlg, 80, 24)
+	defer d.Close()
+
+	d.Type("report-2")
+	d.Press("backspace", "backspace")
+	d.AssertGolden(t, "input_dialog")
+	if c := d.Cursor(); c == nil || c.X != 11 || c.Y != 4 {
+		t.Errorf("expected the cursor after the value at (11, 4), got %+v", c)
+	}
+
+	d.Press("enter")
+	if submitted != "report" {
+		t.Errorf("expected submitted value 'report', got %q", submitted)
+	}
+}
+
+func TestInputDialogPaste(t *testing.T) {
+	var submitted string
+	dlg := NewInputDialog("Rename", "New name", func(s string) { submitted = s })
+	dlg.SetMaxLength(12)
+
+	d := rendertest.New(dlg, 80, 24)
+	defer d.Close()
+
+	d.Type("a")
+	d.Paste("line one\r\nline two\n")
+	d.Press("enter")
+	if submitted != "aline one li" {
+		t.Errorf("expected paste joined and truncated, got %q", submitted)
+	}
+}
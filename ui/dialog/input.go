@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/rivo/uniseg"
 	"github.com/wwsheng009/taproot/ui/list"
 	"github.com/wwsheng009/taproot/ui/render"
 )
@@ -89,6 +90,10 @@ func (d *InputDialog) Update(msg any) (render.Model, render.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case render.PasteMsg:
+		if d.input.Focused() {
+			d.input.InsertText(msg.SingleLine())
+		}
 	case render.KeyMsg:
 		if !d.input.Focused() {
 			switch msg.String() {
@@ -131,7 +136,9 @@ func (d *InputDialog) Update(msg any) (render.Model, render.Cmd) {
 			d.input.MoveCursorToEnd()
 		default:
 			// Regular character input
-			if len(msg.String()) == 1 {
+			if msg.Text != "" && !msg.Ctrl && !msg.Alt {
+				d.input.InsertText(msg.Text)
+			} else if len(msg.String()) == 1 {
 				d.input.Insert([]rune(msg.String())[0])
 			}
 		}
@@ -164,14 +171,19 @@ func (d *InputDialog) View() string {
 	// Input field
 	inputValue := d.input.Value()
 	if d.input.Hidden() {
-		inputValue = strings.Repeat("•", len(inputValue))
+		inputValue = strings.Repeat("•", uniseg.GraphemeClusterCount(inputValue))
 	}
 	if d.input.Focused() && d.input.Value() == "" && d.input.Placeholder() != "" {
 		inputValue = d.input.Placeholder()
 	}
 	
-	inputLine := fmt.Sprintf("  %s%-56s  ", ">", inputValue)
-	b.WriteString("║ " + inputLine + " ║\n")
+	inputLine := "║ " + fmt.Sprintf("  %s%-56s  ", ">", inputValue) + " ║"
+	if d.input.Focused() {
+		// The value starts after the border and the prompt
+		cursor := &render.Cursor{X: 5 + d.input.cursorWidth(), Blink: true}
+		inputLine = render.PlaceCursor(inputLine, cursor)
+	}
+	b.WriteString(inputLine + "\n")
 
 	// Hint
 	if d.hint != "" {
@@ -190,6 +202,12 @@ func (d *InputDialog) View() string {
 	return b.String()
 }
 
+// TerminalCursor implements render.CursorProvider.
+func (d *InputDialog) TerminalCursor() *render.Cursor {
+	_, cursor := render.FindCursor(d.View())
+	return cursor
+}
+
 // ID returns the dialog ID.
 func (d *InputDialog) ID() DialogID {
 	return d.id
@@ -20,6 +20,19 @@ func ConvertKeyMsg(msg tea.KeyMsg) render.KeyMsg {
 	}
 }
 
+// ConvertMsg converts Bubbletea input for dialogs: keys become
+// render.KeyMsg and pastes render.PasteMsg. Other messages are returned
+// unchanged.
+func ConvertMsg(msg tea.Msg) any {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		if key.Paste {
+			return render.PasteMsg{Text: string(key.Runes)}
+		}
+		return ConvertKeyMsg(key)
+	}
+	return msg
+}
+
 // OpenDialogMsg is sent to open a new dialog.
 type OpenDialogMsg struct {
 	Dialog Dialog
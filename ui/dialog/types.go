@@ -1,6 +1,7 @@
 package dialog
 
 import (
+	"github.com/rivo/uniseg"
 	"github.com/wwsheng009/taproot/ui/list"
 	"github.com/wwsheng009/taproot/ui/render"
 )
@@ -185,9 +186,9 @@ type InputField struct {
 	placeholder string
 	focused     bool
 	hidden      bool // For passwords
-	cursor      int
+	cursor      int  // Grapheme cluster index into value
 	width       int
-	maxLength   int
+	maxLength   int  // In grapheme clusters
 }
 
 // NewInputField creates a new input field.
@@ -210,7 +211,7 @@ func (i *InputField) Value() string {
 // SetValue sets the input value.
 func (i *InputField) SetValue(value string) {
 	i.value = value
-	i.setCursor(len(value))
+	i.setCursor(uniseg.GraphemeClusterCount(value))
 }
 
 // Placeholder returns the placeholder text.
@@ -248,6 +249,15 @@ func (i *InputField) Cursor() int {
 	return i.cursor
 }
 
+// cursorWidth returns the display width of the value before the cursor,
+// as shown: hidden values show one bullet per grapheme cluster.
+func (i *InputField) cursorWidth() int {
+	if i.hidden {
+		return i.cursor
+	}
+	return uniseg.StringWidth(i.value[:graphemeOffset(i.value, i.cursor)])
+}
+
 // Width returns the input width.
 func (i *InputField) Width() int {
 	return i.width
@@ -270,32 +280,42 @@ func (i *InputField) SetMaxLength(max int) {
 
 // Insert inserts a rune at the cursor position.
 func (i *InputField) Insert(r rune) {
-	if i.maxLength > 0 && len(i.value) >= i.maxLength {
-		return
+	i.InsertText(string(r))
+}
+
+// InsertText inserts text at the cursor position, truncated to the room
+// left by the max length.
+func (i *InputField) InsertText(text string) {
+	if i.maxLength > 0 {
+		room := i.maxLength - uniseg.GraphemeClusterCount(i.value)
+		if room <= 0 {
+			return
+		}
+		text = text[:graphemeOffset(text, room)]
 	}
-	
-	before := i.value[:i.cursor]
-	after := i.value[i.cursor:]
-	i.value = before + string(r) + after
-	i.cursor++
+
+	offset := graphemeOffset(i.value, i.cursor)
+	before := i.value[:offset] + text
+	i.value = before + i.value[offset:]
+	i.cursor = uniseg.GraphemeClusterCount(before)
 }
 
-// Delete deletes the rune before the cursor.
+// Delete deletes the grapheme cluster before the cursor.
 func (i *InputField) Delete() {
 	if i.cursor > 0 {
-		before := i.value[:i.cursor-1]
-		after := i.value[i.cursor:]
-		i.value = before + after
+		start := graphemeOffset(i.value, i.cursor-1)
+		end := graphemeOffset(i.value, i.cursor)
+		i.value = i.value[:start] + i.value[end:]
 		i.cursor--
 	}
 }
 
-// DeleteForward deletes the rune at the cursor.
+// DeleteForward deletes the grapheme cluster at the cursor.
 func (i *InputField) DeleteForward() {
-	if i.cursor < len(i.value) {
-		before := i.value[:i.cursor]
-		after := i.value[i.cursor+1:]
-		i.value = before + after
+	if i.cursor < uniseg.GraphemeClusterCount(i.value) {
+		start := graphemeOffset(i.value, i.cursor)
+		end := graphemeOffset(i.value, i.cursor+1)
+		i.value = i.value[:start] + i.value[end:]
 	}
 }
 
@@ -308,7 +328,7 @@ func (i *InputField) MoveCursorLeft() {
 
 // MoveCursorRight moves the cursor right.
 func (i *InputField) MoveCursorRight() {
-	if i.cursor < len(i.value) {
+	if i.cursor < uniseg.GraphemeClusterCount(i.value) {
 		i.cursor++
 	}
 }
@@ -320,7 +340,7 @@ func (i *InputField) MoveCursorToStart() {
 
 // MoveCursorToEnd moves cursor to end.
 func (i *InputField) MoveCursorToEnd() {
-	i.cursor = len(i.value)
+	i.cursor = uniseg.GraphemeClusterCount(i.value)
 }
 
 // Clear clears the input value.
@@ -331,14 +351,28 @@ func (i *InputField) Clear() {
 
 // setCursor sets the cursor position safely.
 func (i *InputField) setCursor(pos int) {
+	if n := uniseg.GraphemeClusterCount(i.value); pos > n {
+		pos = n
+	}
 	if pos < 0 {
 		pos = 0
-	} else if pos > len(i.value) {
-		pos = len(i.value)
 	}
 	i.cursor = pos
 }
 
+// graphemeOffset returns the byte offset of the n-th grapheme cluster in
+// s, or len(s) if s has fewer clusters.
+func graphemeOffset(s string, n int) int {
+	offset := 0
+	state := -1
+	for k := 0; k < n && offset < len(s); k++ {
+		var cluster string
+		cluster, _, _, state = uniseg.FirstGraphemeClusterInString(s[offset:], state)
+		offset += len(cluster)
+	}
+	return offset
+}
+
 // SelectList is a dropdown selection list.
 type SelectList struct {
 	items      []list.Item
@@ -11,20 +11,28 @@ import (
 var blinkCounter int64
 
 // NextBlinkID returns a globally unique blink ID.
+//
+// Deprecated: text inputs show the terminal cursor, see
+// render.CursorProvider.
 func NextBlinkID() int {
 	return int(atomic.AddInt64(&blinkCounter, 1))
 }
 
 // BlinkMsg is sent when the cursor should blink.
+//
+// Deprecated: text inputs show the terminal cursor, which the terminal
+// blinks itself.
 type BlinkMsg struct {
 	id int
 }
 
 // BlinkCmd returns a command that waits and sends a BlinkMsg.
 // Duration is set to 500ms for standard cursor blink rate.
+//
+// Deprecated: text inputs show the terminal cursor, which the terminal
+// blinks itself.
 func BlinkCmd(id int) render.Cmd {
-	return func() render.Msg {
-		time.Sleep(500 * time.Millisecond)
+	return render.Tick(500*time.Millisecond, func(time.Time) render.Msg {
 		return BlinkMsg{id: id}
-	}
+	})
 }
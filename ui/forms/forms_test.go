@@ -3,6 +3,9 @@ package forms
 import (
 	"errors"
 	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wwsheng009/taproot/ui/render"
 )
 
 func TestNewTextInput(t *testing.T) {
@@ -72,6 +75,30 @@ func TestTextInput_MaxLength(t *testing.T) {
 	}
 }
 
+func TestTextInput_Paste(t *testing.T) {
+	input := NewTextInput("test")
+	input.SetMaxLength(10)
+	input.Focus()
+	input.insertText("[]")
+	input.moveLeft()
+
+	input.Update(render.PasteMsg{Text: "one\ttwo\r\nthree"})
+	if input.Value() != "[one    t]" {
+		t.Errorf("expected paste truncated to max length, got %q", input.Value())
+	}
+	if input.cursor != 9 {
+		t.Errorf("expected cursor after the paste, got %d", input.cursor)
+	}
+
+	// Plain Bubbletea programs deliver pastes as a key message
+	input = NewTextInput("test")
+	input.Focus()
+	input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a b\n"), Paste: true})
+	if input.Value() != "a b" {
+		t.Errorf("expected 'a b', got %q", input.Value())
+	}
+}
+
 func TestTextInput_Validation(t *testing.T) {
 	input := NewTextInput("email")
 
@@ -329,6 +356,45 @@ func TestTextArea_MultiLineDelete(t *testing.T) {
 	}
 }
 
+func TestTextArea_Graphemes(t *testing.T) {
+	area := NewTextArea("bio")
+	area.SetWidth(4)
+	area.SetValue("ab👍🏽👍🏽🇯🇵")
+
+	// Wide clusters wrap whole, never split across lines
+	lines, vRow, vCol := area.getDisplayInfo()
+	if len(lines) != 2 || lines[0] != "ab👍🏽" || lines[1] != "👍🏽🇯🇵" {
+		t.Fatalf("unexpected wrapped lines %q", lines)
+	}
+	if vRow != 1 || vCol != 2 {
+		t.Errorf("expected cursor at (1, 2), got (%d, %d)", vRow, vCol)
+	}
+
+	area.MoveLeft()
+	area.Delete()
+	if area.Value() != "ab👍🏽🇯🇵" {
+		t.Errorf("expected one emoji deleted, got %q", area.Value())
+	}
+	if area.cursorCol != 3 {
+		t.Errorf("expected cursor at 3, got %d", area.cursorCol)
+	}
+}
+
+func TestTextArea_Paste(t *testing.T) {
+	area := NewTextArea("bio")
+	area.Focus()
+	area.SetValue("<>")
+	area.MoveLeft()
+
+	area.Update(render.PasteMsg{Text: "héllo\r\n\tworld 👍🏽\rend\x07"})
+	if area.Value() != "<héllo\n    world 👍🏽\nend>" {
+		t.Errorf("unexpected value %q", area.Value())
+	}
+	if area.cursorRow != 2 || area.cursorCol != 3 {
+		t.Errorf("expected cursor at (2, 3), got (%d, %d)", area.cursorRow, area.cursorCol)
+	}
+}
+
 func TestTextArea_SetValue(t *testing.T) {
 	area := NewTextArea("bio")
 
@@ -407,6 +473,48 @@ func TestTextArea_Focus(t *testing.T) {
 	}
 }
 
+func TestTextInput_TerminalCursor(t *testing.T) {
+	input := NewTextInput("name")
+	input.SetPrompt("Name:")
+	input.SetValue("héllo")
+	if c := input.TerminalCursor(); c != nil {
+		t.Errorf("expected no cursor while blurred, got %+v", c)
+	}
+
+	input.Focus()
+	input.cursor = 2
+	if c := input.TerminalCursor(); c == nil || *c != (render.Cursor{X: 8, Y: 0, Blink: true}) {
+		t.Errorf("expected a blinking block cursor at (8, 0), got %+v", c)
+	}
+
+	// The border and padding move the cursor
+	input.SetShowBorder(true)
+	input.SetCursorShape(render.CursorBar, false)
+	if c := input.TerminalCursor(); c == nil || *c != (render.Cursor{X: 10, Y: 1, Shape: render.CursorBar}) {
+		t.Errorf("expected a bar cursor at (10, 1), got %+v", c)
+	}
+}
+
+func TestTextArea_TerminalCursor(t *testing.T) {
+	area := NewTextArea("bio")
+	area.SetLabel("Bio")
+	area.SetValue("ab\ncd")
+	if c := area.TerminalCursor(); c != nil {
+		t.Errorf("expected no cursor while blurred, got %+v", c)
+	}
+
+	area.Focus()
+	if c := area.TerminalCursor(); c == nil || c.X != 2 || c.Y != 2 {
+		t.Errorf("expected the cursor at (2, 2), got %+v", c)
+	}
+
+	area.SetShowBorder(true)
+	area.MoveUp()
+	if c := area.TerminalCursor(); c == nil || c.X != 4 || c.Y != 2 {
+		t.Errorf("expected the cursor at (4, 2), got %+v", c)
+	}
+}
+
 // Test custom validators
 func Test_CustomValidator(t *testing.T) {
 	input := NewTextInput("username")
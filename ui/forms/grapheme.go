@@ -0,0 +1,92 @@
+package forms
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+// The text inputs keep their cursors as grapheme cluster indexes rather
+// than rune or byte offsets, so moving or deleting never splits an emoji
+// sequence, a flag or a letter from its combining marks.
+
+// graphemes splits s into grapheme clusters.
+func graphemes(s string) []string {
+	var gs []string
+	state := -1
+	for len(s) > 0 {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		gs = append(gs, cluster)
+	}
+	return gs
+}
+
+// graphemeCount returns the number of grapheme clusters in s.
+func graphemeCount(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// graphemeOffset returns the byte offset of the n-th grapheme cluster in
+// s, or len(s) if s has fewer clusters.
+func graphemeOffset(s string, n int) int {
+	offset := 0
+	state := -1
+	for i := 0; i < n && offset < len(s); i++ {
+		var cluster string
+		cluster, _, _, state = uniseg.FirstGraphemeClusterInString(s[offset:], state)
+		offset += len(cluster)
+	}
+	return offset
+}
+
+// graphemeWidth returns the display width of a grapheme cluster or string.
+func graphemeWidth(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// keyText returns the printable text typed by a key message, or "" for
+// keys that don't insert text.
+func keyText(msg any) string {
+	var text string
+	switch k := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case k.Alt:
+			return ""
+		case k.Type == tea.KeyRunes:
+			text = string(k.Runes)
+		case k.Type == tea.KeySpace:
+			text = " "
+		}
+	case render.KeyMsg:
+		if k.Alt || k.Ctrl || k.Type == render.KeyRelease {
+			return ""
+		}
+		text = k.Text
+		if text == "" && len(k.Key) == 1 {
+			text = k.Key
+		}
+	}
+
+	for _, r := range text {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ""
+		}
+	}
+	return text
+}
+
+// pasteMsg returns the paste carried by msg. Engines send render.PasteMsg;
+// plain Bubbletea programs deliver pastes as a tea.KeyMsg with Paste set.
+func pasteMsg(msg any) (render.PasteMsg, bool) {
+	switch m := msg.(type) {
+	case render.PasteMsg:
+		return m, true
+	case tea.KeyMsg:
+		if m.Paste {
+			return render.PasteMsg{Text: string(m.Runes)}, true
+		}
+	}
+	return render.PasteMsg{}, false
+}
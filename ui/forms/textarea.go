@@ -2,7 +2,6 @@ package forms
 
 import (
 	"strings"
-	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,7 +16,7 @@ type TextArea struct {
 	placeholder string
 	focused     bool
 	cursorRow   int
-	cursorCol   int
+	cursorCol   int // Grapheme cluster index into the cursor's line
 	width       int
 	height      int
 	offset      int // Vertical scroll offset (first visible line)
@@ -25,10 +24,12 @@ type TextArea struct {
 
 	validators []Validator
 	err        error
-	blink      bool
-	blinkCtx   int
 	styles     styles.Styles
 
+	// Cursor
+	cursorShape render.CursorShape
+	cursorBlink bool
+
 	// Border
 	showBorder   bool
 	focusedStyle lipgloss.Style
@@ -45,7 +46,8 @@ func NewTextArea(placeholder string) *TextArea {
 		width:       40,
 		height:      5,
 		wrap:        true, // Enable word wrap by default
-		blink:       true,
+		cursorShape: render.CursorBlock,
+		cursorBlink: true,
 		styles:      s,
 		focusedStyle: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
@@ -72,7 +74,10 @@ func (t *TextArea) Update(msg any) (render.Model, render.Cmd) {
 		return t, nil
 	}
 
-	var cmd render.Cmd
+	if paste, ok := pasteMsg(msg); ok {
+		t.Paste(paste)
+		return t, nil
+	}
 
 	// Handle both tea.KeyMsg and render.KeyMsg
 	var keyStr string
@@ -89,72 +94,42 @@ func (t *TextArea) Update(msg any) (render.Model, render.Cmd) {
 			return t, nil
 		case "enter":
 			t.InsertNewline()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "backspace", "ctrl+h":
 			t.Delete()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "up":
 			t.MoveUp()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "down":
 			t.MoveDown()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "left":
 			t.MoveLeft()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "right":
 			t.MoveRight()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "home", "ctrl+a":
 			// Move to start of current line
 			t.cursorCol = 0
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "end", "ctrl+e":
 			// Move to end of current line
 			if t.cursorRow < len(t.lines) {
-				t.cursorCol = len(t.lines[t.cursorRow])
+				t.cursorCol = graphemeCount(t.lines[t.cursorRow])
 			}
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		default:
-			// Insert single printable characters only
-			if len(keyStr) == 1 {
-				r := []rune(keyStr)[0]
-				// Only accept printable characters (space and above)
-				if r >= 32 && r <= 126 {
-					t.Insert(r)
-					t.blink = true
-					t.blinkCtx = NextBlinkID()
-					return t, BlinkCmd(t.blinkCtx)
-				}
+			// Insert printable text only
+			if text := keyText(msg); text != "" {
+				t.InsertText(text)
+				return t, nil
 			}
 		}
 	}
 
-	// Handle BlinkMsg
-	if msg, ok := msg.(BlinkMsg); ok {
-		if t.focused && msg.id == t.blinkCtx {
-			t.blink = !t.blink
-			cmd = BlinkCmd(t.blinkCtx)
-		}
-	}
-
-	return t, cmd
+	return t, nil
 }
 
 // View implements render.Model.
@@ -170,11 +145,10 @@ func (t *TextArea) View() string {
 	// Handle empty state with placeholder
 	if len(t.lines) == 1 && t.lines[0] == "" && t.placeholder != "" && !t.focused {
 		// Render placeholder
-		ph := t.placeholder
-		ph = ph[:min(len(ph), t.width)]
+		ph := truncateToWidth(t.placeholder, t.width)
 		// Pad to width
-		if len(ph) < t.width {
-			ph += strings.Repeat(" ", t.width-utf8.RuneCountInString(ph))
+		if w := graphemeWidth(ph); w < t.width {
+			ph += strings.Repeat(" ", t.width-w)
 		}
 		
 		// Use a gray color for placeholder to match TextInput
@@ -212,29 +186,22 @@ func (t *TextArea) View() string {
 			line := displayLines[i]
 
 			// Pad line to full width for consistent rendering
-			if utf8.RuneCountInString(line) < t.width {
-				line += strings.Repeat(" ", t.width-utf8.RuneCountInString(line))
+			if w := graphemeWidth(line); w < t.width {
+				line += strings.Repeat(" ", t.width-w)
 			}
 
 			// Render cursor if focused and on cursor row
 			if t.focused && i == vRow {
-				cursorStyle := t.styles.Base
-				if t.blink {
-					cursorStyle = cursorStyle.Reverse(true)
-				}
-
 				// Render line with cursor
-				if vCol >= utf8.RuneCountInString(line) {
+				marker := render.Cursor{Shape: t.cursorShape, Blink: t.cursorBlink}.Marker()
+				gs := graphemes(line)
+				if vCol >= len(gs) {
 					b.WriteString(line)
-					b.WriteString(cursorStyle.Render(" "))
+					b.WriteString(marker + " ")
 				} else {
-					lineRunes := []rune(line)
-					before := string(lineRunes[:vCol])
-					cursorChar := string(lineRunes[vCol])
-					after := string(lineRunes[vCol+1:])
-					b.WriteString(before)
-					b.WriteString(cursorStyle.Render(cursorChar))
-					b.WriteString(after)
+					b.WriteString(strings.Join(gs[:vCol], ""))
+					b.WriteString(marker)
+					b.WriteString(strings.Join(gs[vCol:], ""))
 				}
 			} else {
 				b.WriteString(line)
@@ -280,6 +247,23 @@ func (t *TextArea) View() string {
 	return result
 }
 
+// TerminalCursor implements render.CursorProvider. It returns the cursor
+// relative to the text area's view while the text area is focused.
+func (t *TextArea) TerminalCursor() *render.Cursor {
+	if !t.focused {
+		return nil
+	}
+	_, cursor := render.FindCursor(t.View())
+	return cursor
+}
+
+// SetCursorShape sets the shape of the cursor shown while the text area
+// is focused and whether it blinks.
+func (t *TextArea) SetCursorShape(shape render.CursorShape, blink bool) {
+	t.cursorShape = shape
+	t.cursorBlink = blink
+}
+
 // getDisplayInfo returns lines for display and the visual cursor coordinates
 func (t *TextArea) getDisplayInfo() ([]string, int, int) {
 	if !t.wrap {
@@ -290,25 +274,19 @@ func (t *TextArea) getDisplayInfo() ([]string, int, int) {
 	vRow, vCol := -1, -1
 
 	for r, line := range t.lines {
-		runes := []rune(line)
-
-		// Handle empty line
-		if len(runes) == 0 {
-			if r == t.cursorRow {
-				vRow = len(wrapped)
-				vCol = 0
+		gs := graphemes(line)
+
+		// Break line into chunks of width; an empty line is one empty chunk
+		starts := wrapGraphemes(gs, t.width)
+		for c, i := range starts {
+			end := len(gs)
+			if c+1 < len(starts) {
+				end = starts[c+1]
 			}
-			wrapped = append(wrapped, "")
-			continue
-		}
-
-		// Break line into chunks of width
-		for i := 0; i < len(runes); i += t.width {
-			end := min(i+t.width, len(runes))
 
 			// Check if cursor falls in this chunk
 			if r == t.cursorRow {
-				isLastChunk := (end == len(runes))
+				isLastChunk := (end == len(gs))
 				// Cursor belongs to this chunk if it's within [i, end)
 				// OR if it's at 'end' and this is the last chunk
 				if t.cursorCol >= i && t.cursorCol < end {
@@ -320,13 +298,30 @@ func (t *TextArea) getDisplayInfo() ([]string, int, int) {
 				}
 			}
 
-			wrapped = append(wrapped, string(runes[i:end]))
+			wrapped = append(wrapped, strings.Join(gs[i:end], ""))
 		}
 	}
 
 	return wrapped, vRow, vCol
 }
 
+// wrapGraphemes breaks a line's grapheme clusters into chunks of at most
+// width columns and returns the index each chunk starts at. A cluster wider
+// than width gets a chunk of its own.
+func wrapGraphemes(gs []string, width int) []int {
+	starts := []int{0}
+	used := 0
+	for i, g := range gs {
+		w := graphemeWidth(g)
+		if used > 0 && used+w > width {
+			starts = append(starts, i)
+			used = 0
+		}
+		used += w
+	}
+	return starts
+}
+
 // Value returns the full text.
 func (t *TextArea) Value() string {
 	return strings.Join(t.lines, "\n")
@@ -340,22 +335,19 @@ func (t *TextArea) SetValue(val string) {
 	}
 	t.cursorRow = len(t.lines) - 1
 	if t.cursorRow >= 0 {
-		t.cursorCol = len(t.lines[t.cursorRow])
+		t.cursorCol = graphemeCount(t.lines[t.cursorRow])
 	}
 }
 
 // Focus focuses the text area.
 func (t *TextArea) Focus() render.Cmd {
 	t.focused = true
-	t.blink = true
-	t.blinkCtx = NextBlinkID()
-	return BlinkCmd(t.blinkCtx)
+	return nil
 }
 
 // Blur blurs the text area.
 func (t *TextArea) Blur() {
 	t.focused = false
-	t.blink = false
 }
 
 // Focused returns true if focused.
@@ -392,15 +384,30 @@ func (t *TextArea) SetValidators(v ...Validator) {
 
 // Insert inserts a rune.
 func (t *TextArea) Insert(r rune) {
+	t.InsertText(string(r))
+}
+
+// InsertText inserts text at the cursor and moves the cursor past it.
+func (t *TextArea) InsertText(text string) {
 	if len(t.lines) == 0 {
 		t.lines = []string{""}
 	}
 	line := t.lines[t.cursorRow]
-	runes := []rune(line)
-	before := string(runes[:t.cursorCol])
-	after := string(runes[t.cursorCol:])
-	t.lines[t.cursorRow] = before + string(r) + after
-	t.cursorCol++
+	offset := graphemeOffset(line, t.cursorCol)
+	before := line[:offset] + text
+	t.lines[t.cursorRow] = before + line[offset:]
+	t.cursorCol = graphemeCount(before)
+}
+
+// Paste inserts pasted text at the cursor in one step, starting a new
+// line at each line break.
+func (t *TextArea) Paste(msg render.PasteMsg) {
+	for i, line := range msg.Lines() {
+		if i > 0 {
+			t.InsertNewline()
+		}
+		t.InsertText(line)
+	}
 }
 
 // InsertNewline inserts a newline.
@@ -409,9 +416,9 @@ func (t *TextArea) InsertNewline() {
 		t.lines = []string{""}
 	}
 	line := t.lines[t.cursorRow]
-	runes := []rune(line)
-	before := string(runes[:t.cursorCol])
-	after := string(runes[t.cursorCol:])
+	offset := graphemeOffset(line, t.cursorCol)
+	before := line[:offset]
+	after := line[offset:]
 
 	t.lines[t.cursorRow] = before
 	// Insert new line after current
@@ -428,17 +435,16 @@ func (t *TextArea) Delete() {
 	}
 	if t.cursorCol > 0 {
 		line := t.lines[t.cursorRow]
-		runes := []rune(line)
-		before := string(runes[:t.cursorCol-1])
-		after := string(runes[t.cursorCol:])
-		t.lines[t.cursorRow] = before + after
+		start := graphemeOffset(line, t.cursorCol-1)
+		end := graphemeOffset(line, t.cursorCol)
+		t.lines[t.cursorRow] = line[:start] + line[end:]
 		t.cursorCol--
 	} else if t.cursorRow > 0 {
 		// Merge with previous line
 		prevLine := t.lines[t.cursorRow-1]
 		currLine := t.lines[t.cursorRow]
 
-		newCol := utf8.RuneCountInString(prevLine)
+		newCol := graphemeCount(prevLine)
 		t.lines[t.cursorRow-1] = prevLine + currLine
 
 		// Remove current line
@@ -471,14 +477,14 @@ func (t *TextArea) MoveLeft() {
 		t.cursorCol--
 	} else if t.cursorRow > 0 {
 		t.cursorRow--
-		t.cursorCol = utf8.RuneCountInString(t.lines[t.cursorRow])
+		t.cursorCol = graphemeCount(t.lines[t.cursorRow])
 	}
 }
 
 // MoveRight moves cursor right.
 func (t *TextArea) MoveRight() {
 	if t.cursorRow < len(t.lines) {
-		lineLen := utf8.RuneCountInString(t.lines[t.cursorRow])
+		lineLen := graphemeCount(t.lines[t.cursorRow])
 		if t.cursorCol < lineLen {
 			t.cursorCol++
 		} else if t.cursorRow < len(t.lines)-1 {
@@ -499,7 +505,7 @@ func (t *TextArea) resolveVisualPos(targetVRow, targetVCol int) (int, int) {
 			targetVRow = len(t.lines) - 1
 		}
 		// Clamp column
-		lineLen := utf8.RuneCountInString(t.lines[targetVRow])
+		lineLen := graphemeCount(t.lines[targetVRow])
 		targetVCol = min(targetVCol, lineLen)
 		return targetVRow, targetVCol
 	}
@@ -513,20 +519,15 @@ func (t *TextArea) resolveVisualPos(targetVRow, targetVCol int) (int, int) {
 	}
 
 	for lRow, line := range t.lines {
-		runes := []rune(line)
-
-		// Handle empty line
-		if len(runes) == 0 {
-			if currentVRow == targetVRow {
-				return lRow, 0
-			}
-			currentVRow++
-			continue
-		}
+		gs := graphemes(line)
 
 		// Iterate chunks
-		for i := 0; i < len(runes); i += t.width {
-			end := min(i+t.width, len(runes))
+		starts := wrapGraphemes(gs, t.width)
+		for c, i := range starts {
+			end := len(gs)
+			if c+1 < len(starts) {
+				end = starts[c+1]
+			}
 
 			if currentVRow == targetVRow {
 				// Found the visual row
@@ -548,7 +549,7 @@ func (t *TextArea) resolveVisualPos(targetVRow, targetVCol int) (int, int) {
 	if lastRow < 0 {
 		return 0, 0
 	}
-	lastCol := utf8.RuneCountInString(t.lines[lastRow])
+	lastCol := graphemeCount(t.lines[lastRow])
 	return lastRow, lastCol
 }
 
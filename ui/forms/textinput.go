@@ -2,7 +2,6 @@ package forms
 
 import (
 	"strings"
-	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 	tea "github.com/charmbracelet/bubbletea"
@@ -18,7 +17,7 @@ type TextInput struct {
 	placeholder string
 	focused     bool
 	hidden      bool // For passwords
-	cursor      int
+	cursor      int  // Grapheme cluster index into value
 	width       int
 	maxLength   int
 
@@ -26,10 +25,9 @@ type TextInput struct {
 	validators []Validator
 	err        error
 
-	// Cursor blinking
-	blink            bool
-	blinkCtx         int // Context ID for blink command
-	cursorStyle      lipgloss.Style
+	// Cursor
+	cursorShape      render.CursorShape
+	cursorBlink      bool
 	placeHolderStyle lipgloss.Style
 	textStyle        lipgloss.Style
 	errorStyle       lipgloss.Style
@@ -48,8 +46,8 @@ func NewTextInput(placeholder string) *TextInput {
 		placeholder:      placeholder,
 		width:            40,
 		maxLength:        0, // 0 means no limit
-		blink:            true,
-		cursorStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Reverse(true),
+		cursorShape:      render.CursorBlock,
+		cursorBlink:      true,
 		placeHolderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
 		textStyle:        lipgloss.NewStyle(),
 		errorStyle:       lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
@@ -75,7 +73,11 @@ func (t *TextInput) Update(msg any) (render.Model, render.Cmd) {
 		return t, nil
 	}
 
-	var cmd render.Cmd
+	// Pasted text is inserted at once, on a single line
+	if paste, ok := pasteMsg(msg); ok {
+		t.insertText(paste.SingleLine())
+		return t, nil
+	}
 
 	// Handle both tea.KeyMsg and render.KeyMsg
 	var keyStr string
@@ -91,69 +93,38 @@ func (t *TextInput) Update(msg any) (render.Model, render.Cmd) {
 			return t, nil
 		case "backspace", "ctrl+h":
 			t.deleteBefore()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "delete", "ctrl+d":
 			t.deleteAfter()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "left", "ctrl+b":
 			t.moveLeft()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "right", "ctrl+f":
 			t.moveRight()
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "home", "ctrl+a":
 			t.cursor = 0
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		case "end", "ctrl+e":
-			t.cursor = utf8.RuneCountInString(t.value)
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			t.cursor = graphemeCount(t.value)
+			return t, nil
 		case "ctrl+k":
-			t.value = t.value[:t.cursor]
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			t.value = t.value[:graphemeOffset(t.value, t.cursor)]
+			return t, nil
 		case "ctrl+u":
-			t.value = t.value[t.cursor:]
+			t.value = t.value[graphemeOffset(t.value, t.cursor):]
 			t.cursor = 0
-			t.blink = true
-			t.blinkCtx = NextBlinkID()
-			return t, BlinkCmd(t.blinkCtx)
+			return t, nil
 		default:
-			// Insert single printable characters only
-			// Check: single character string AND not a control character
-			if len(keyStr) == 1 {
-				r := []rune(keyStr)[0]
-				// Only accept printable characters (space and above)
-				if r >= 32 && r <= 126 {
-					t.insert(r)
-					t.blink = true
-					t.blinkCtx = NextBlinkID()
-					return t, BlinkCmd(t.blinkCtx)
-				}
+			// Insert printable text only
+			if text := keyText(msg); text != "" {
+				t.insertText(text)
+				return t, nil
 			}
 		}
 
-	// Handle BlinkMsg
-	if msg, ok := msg.(BlinkMsg); ok {
-		if t.focused && msg.id == t.blinkCtx {
-			t.blink = !t.blink
-			return t, BlinkCmd(t.blinkCtx)
-		}
-	}
-
-	return t, cmd
+	return t, nil
 }
 
 // View implements render.Model.
@@ -166,8 +137,8 @@ func (t *TextInput) View() string {
 		b.WriteString(" ")
 	}
 
-	// Get rune count for accurate measurement
-	valLength := utf8.RuneCountInString(t.value)
+	// Get grapheme cluster count for accurate measurement
+	valLength := graphemeCount(t.value)
 	var content string
 
 	// Handle empty state with placeholder
@@ -189,18 +160,18 @@ func (t *TextInput) View() string {
 	if valLength == 0 && t.placeholder != "" && !t.focused {
 		ph := truncateToWidth(t.placeholder, t.width)
 		content = t.placeHolderStyle.Render(ph)
-		currentVisualLen = utf8.RuneCountInString(ph)
+		currentVisualLen = graphemeWidth(ph)
 	} else {
-		// Generate display content using runes (not bytes)
-		var displayRunes []rune
+		// Generate display content using grapheme clusters (not bytes)
+		var display []string
 		if t.hidden {
-			// Use rune count for bullets
-			displayRunes = make([]rune, valLength)
-			for i := range displayRunes {
-				displayRunes[i] = '•'
+			// One bullet per grapheme cluster
+			display = make([]string, valLength)
+			for i := range display {
+				display[i] = "•"
 			}
 		} else {
-			displayRunes = []rune(t.value)
+			display = graphemes(t.value)
 		}
 
 		// Ensure cursor stays within bounds
@@ -208,35 +179,35 @@ func (t *TextInput) View() string {
 			t.cursor = valLength
 		}
 
-		// Simple scrolling based on rune count
-		displayRunes, scrollStart := scrollRunes(displayRunes, t.cursor, t.width)
+		// Scroll by display width, keeping the cursor visible
+		display, scrollStart := scrollGraphemes(display, t.cursor, t.width)
 
 		// Calculate cursor offset relative to scrolled view
 		cursorOffset := t.cursor - scrollStart
 
 		// Render with cursor
-		currentVisualLen = len(displayRunes)
+		currentVisualLen = graphemeWidth(strings.Join(display, ""))
 		var sb strings.Builder
-		if cursorOffset >= len(displayRunes) {
+		if cursorOffset >= len(display) {
 			// Cursor at end
-			sb.WriteString(t.textStyle.Render(string(displayRunes)))
-			if t.focused && t.blink {
-				sb.WriteString(t.cursorStyle.Render(" "))
+			sb.WriteString(t.textStyle.Render(strings.Join(display, "")))
+			if t.focused {
+				// Leave room for the cursor after the text
+				sb.WriteString(t.cursorMarker() + " ")
 				currentVisualLen++
 			}
 		} else {
 			// Cursor in middle
-			before := displayRunes[:cursorOffset]
-			cursorChar := displayRunes[cursorOffset]
-			after := displayRunes[cursorOffset+1:]
-
-			sb.WriteString(t.textStyle.Render(string(before)))
-			if t.focused && t.blink {
-				sb.WriteString(t.cursorStyle.Render(string(cursorChar)))
-			} else {
-				sb.WriteString(t.textStyle.Render(string(cursorChar)))
+			before := strings.Join(display[:cursorOffset], "")
+			cursorChar := display[cursorOffset]
+			after := strings.Join(display[cursorOffset+1:], "")
+
+			sb.WriteString(t.textStyle.Render(before))
+			if t.focused {
+				sb.WriteString(t.cursorMarker())
 			}
-			sb.WriteString(t.textStyle.Render(string(after)))
+			sb.WriteString(t.textStyle.Render(cursorChar))
+			sb.WriteString(t.textStyle.Render(after))
 		}
 		content = sb.String()
 	}
@@ -269,50 +240,80 @@ func (t *TextInput) View() string {
 	return result
 }
 
-// scrollRunes scrolls rune array to keep cursor visible
-// Returns the scrolled runes and the start position in the original array
+// TerminalCursor implements render.CursorProvider. It returns the cursor
+// relative to the input's view while the input is focused.
+func (t *TextInput) TerminalCursor() *render.Cursor {
+	if !t.focused {
+		return nil
+	}
+	_, cursor := render.FindCursor(t.View())
+	return cursor
+}
+
+// SetCursorShape sets the shape of the cursor shown while the input is
+// focused and whether it blinks.
+func (t *TextInput) SetCursorShape(shape render.CursorShape, blink bool) {
+	t.cursorShape = shape
+	t.cursorBlink = blink
+}
+
+// cursorMarker returns the marker that puts the terminal cursor on the
+// next cell.
+func (t *TextInput) cursorMarker() string {
+	return render.Cursor{Shape: t.cursorShape, Blink: t.cursorBlink}.Marker()
+}
+
+// scrollGraphemes scrolls grapheme clusters to keep cursor visible within
+// width columns.
+// Returns the visible clusters and the start position in the original slice
 // Strategy: Keep cursor as close to the right as possible, only scroll when needed
-func scrollRunes(runes []rune, cursor, width int) ([]rune, int) {
+func scrollGraphemes(gs []string, cursor, width int) ([]string, int) {
 	// If content fits, no scrolling needed
-	if len(runes) <= width {
-		return runes, 0
+	total := 0
+	for _, g := range gs {
+		total += graphemeWidth(g)
+	}
+	if total <= width {
+		return gs, 0
 	}
-
-	// Calculate scroll start position
-	// Keep cursor visible, preferably near the right edge for natural typing feel
-	start := 0
 
 	// Only scroll left when cursor is near or beyond the right edge
 	// Leave some padding on the right for better UX
 	paddingRight := 2
-	if cursor >= width-paddingRight {
-		start = cursor - width + paddingRight
+	start := cursor
+	for used := 0; start > 0 && used+graphemeWidth(gs[start-1]) <= width-paddingRight; start-- {
+		used += graphemeWidth(gs[start-1])
 	}
 
-	// Ensure start is within bounds
-	if start < 0 {
-		start = 0
+	// Calculate end position
+	end, used := start, 0
+	for end < len(gs) && used+graphemeWidth(gs[end]) <= width {
+		used += graphemeWidth(gs[end])
+		end++
 	}
 
-	// Calculate end position
-	end := start + width
-	if end > len(runes) {
-		end = len(runes)
-		// Adjust start if we're at the end of the text
-		start = max(0, end-width)
+	// Adjust start if we're at the end of the text
+	if end == len(gs) {
+		for start > 0 && used+graphemeWidth(gs[start-1]) <= width {
+			start--
+			used += graphemeWidth(gs[start])
+		}
 	}
 
-	return runes[start:end], start
+	return gs[start:end], start
 }
 
 // truncateToWidth truncates a string to fit within maxWidth in visual width
+// without splitting a grapheme cluster
 func truncateToWidth(s string, maxWidth int) string {
-	// For simplicity, assume 1 byte = 1 visual width for ASCII
-	// This works for most use cases
-	if len(s) <= maxWidth {
-		return s
+	width := 0
+	for i, g := range graphemes(s) {
+		width += graphemeWidth(g)
+		if width > maxWidth {
+			return s[:graphemeOffset(s, i)]
+		}
 	}
-	return s[:maxWidth]
+	return s
 }
 
 // Value returns the current value.
@@ -323,7 +324,7 @@ func (t *TextInput) Value() string {
 // SetValue sets the value.
 func (t *TextInput) SetValue(v string) {
 	t.value = v
-	cursorLen := utf8.RuneCountInString(v)
+	cursorLen := graphemeCount(v)
 	if t.cursor > cursorLen {
 		t.cursor = cursorLen
 	}
@@ -332,9 +333,7 @@ func (t *TextInput) SetValue(v string) {
 // Focus focuses the input.
 func (t *TextInput) Focus() render.Cmd {
 	t.focused = true
-	t.blink = true
-	t.blinkCtx = NextBlinkID()
-	return BlinkCmd(t.blinkCtx)
+	return nil
 }
 
 // Blur blurs the input.
@@ -402,32 +401,40 @@ func (t *TextInput) SetShowBorder(show bool) {
 // Helpers
 
 func (t *TextInput) insert(r rune) {
-	if t.maxLength > 0 && utf8.RuneCountInString(t.value) >= t.maxLength {
-		return
+	t.insertText(string(r))
+}
+
+// insertText inserts text at the cursor, truncated to the room left by
+// maxLength, and moves the cursor past it.
+func (t *TextInput) insertText(text string) {
+	if t.maxLength > 0 {
+		room := t.maxLength - graphemeCount(t.value)
+		if room <= 0 {
+			return
+		}
+		text = text[:graphemeOffset(text, room)]
 	}
 
-	left := t.value[:t.cursor]
-	right := t.value[t.cursor:]
-	t.value = left + string(r) + right
-	t.cursor++
+	offset := graphemeOffset(t.value, t.cursor)
+	left := t.value[:offset] + text
+	t.value = left + t.value[offset:]
+	t.cursor = graphemeCount(left)
 }
 
 func (t *TextInput) deleteBefore() {
 	if t.cursor > 0 {
-		runes := []rune(t.value)
-		left := string(runes[:t.cursor-1])
-		right := string(runes[t.cursor:])
-		t.value = left + right
+		start := graphemeOffset(t.value, t.cursor-1)
+		end := graphemeOffset(t.value, t.cursor)
+		t.value = t.value[:start] + t.value[end:]
 		t.cursor--
 	}
 }
 
 func (t *TextInput) deleteAfter() {
-	if t.cursor < utf8.RuneCountInString(t.value) {
-		runes := []rune(t.value)
-		left := string(runes[:t.cursor])
-		right := string(runes[t.cursor+1:])
-		t.value = left + right
+	if t.cursor < graphemeCount(t.value) {
+		start := graphemeOffset(t.value, t.cursor)
+		end := graphemeOffset(t.value, t.cursor+1)
+		t.value = t.value[:start] + t.value[end:]
 	}
 }
 
@@ -438,7 +445,7 @@ func (t *TextInput) moveLeft() {
 }
 
 func (t *TextInput) moveRight() {
-	if t.cursor < utf8.RuneCountInString(t.value) {
+	if t.cursor < graphemeCount(t.value) {
 		t.cursor++
 	}
 }
@@ -1,8 +1,11 @@
 package forms
 
 import (
+	"strings"
 	"testing"
 	"unicode/utf8"
+
+	"github.com/wwsheng009/taproot/ui/render"
 )
 
 func TestTextInput_PasswordMasking(t *testing.T) {
@@ -52,3 +55,58 @@ func TestTextInput_RuneCount(t *testing.T) {
 		t.Errorf("Expected 7 runes, got %d", utf8.RuneCountInString(input.Value()))
 	}
 }
+
+func TestTextInput_Graphemes(t *testing.T) {
+	input := NewTextInput("test")
+	input.Focus()
+	input.SetValue("a👍🏽🇯🇵é")
+	input.cursor = 4
+
+	// Cursor moves over whole clusters
+	input.moveLeft()
+	if input.cursor != 3 {
+		t.Errorf("expected cursor at 3, got %d", input.cursor)
+	}
+
+	// Backspace removes the whole flag, not one regional indicator
+	input.deleteBefore()
+	if input.Value() != "a👍🏽é" {
+		t.Errorf("expected flag deleted, got %q", input.Value())
+	}
+
+	// Delete removes the letter with its combining accent
+	input.deleteAfter()
+	if input.Value() != "a👍🏽" {
+		t.Errorf("expected accented letter deleted, got %q", input.Value())
+	}
+
+	// Typed text may be several runes forming one cluster
+	input.Update(render.KeyMsg{Key: "👨‍👩‍👧", Text: "👨‍👩‍👧"})
+	if input.Value() != "a👍🏽👨‍👩‍👧" || input.cursor != 3 {
+		t.Errorf("expected family emoji inserted, got %q cursor %d", input.Value(), input.cursor)
+	}
+}
+
+func TestTextInput_MaxLengthGraphemes(t *testing.T) {
+	input := NewTextInput("test")
+	input.SetMaxLength(2)
+	input.insertText("👍🏽🇯🇵x")
+
+	if input.Value() != "👍🏽🇯🇵" {
+		t.Errorf("expected two clusters, got %q", input.Value())
+	}
+}
+
+func TestScrollGraphemes(t *testing.T) {
+	gs := graphemes("中文中文中文")
+
+	visible, start := scrollGraphemes(gs, len(gs), 6)
+	if got := strings.Join(visible, ""); got != "文中文" || start != 3 {
+		t.Errorf("scrollGraphemes() = %q, %d; want %q, 3", got, start, "文中文")
+	}
+
+	visible, start = scrollGraphemes(gs, 0, 6)
+	if got := strings.Join(visible, ""); got != "中文中" || start != 0 {
+		t.Errorf("scrollGraphemes() = %q, %d; want %q, 0", got, start, "中文中")
+	}
+}
@@ -4,12 +4,29 @@ import "image"
 
 // FlexChild represents a child in a flex layout with its constraint.
 type FlexChild struct {
-	// Constraint defines the size constraint for this child
+	// Constraint defines the preferred size for this child
 	Constraint Constraint
 	// Grow indicates if this child should grow to fill remaining space
 	Grow bool
-	// Shrink indicates if this child can shrink below its constraint size
+	// Shrink indicates if this child is the first to shrink below its
+	// constraint size when the children don't fit
 	Shrink bool
+	// Min is the smallest size the child gets while there is room for it
+	Min int
+	// Max is the largest size the child gets, 0 for no limit
+	Max int
+	// Weight is the child's share of the remaining space relative to the
+	// other growing children. A growing child without one has weight 1.
+	Weight int
+
+	// The fields below are only used by FlexboxRow and FlexboxColumn.
+
+	// AlignSelf overrides the container's AlignItems for this child
+	AlignSelf Align
+	// Order places the child among its siblings, lowest first
+	Order int
+	// CrossSize is the size across the main axis; nil fills the line
+	CrossSize Constraint
 }
 
 // NewFlexChild creates a new FlexChild with the given constraint.
@@ -33,15 +50,38 @@ func (c FlexChild) WithShrink() FlexChild {
 	return c
 }
 
+// WithMin sets the minimum size of the FlexChild.
+func (c FlexChild) WithMin(size int) FlexChild {
+	c.Min = size
+	return c
+}
+
+// WithMax sets the maximum size of the FlexChild.
+func (c FlexChild) WithMax(size int) FlexChild {
+	c.Max = size
+	return c
+}
+
+// WithWeight enables grow behavior with the given share of the remaining
+// space.
+func (c FlexChild) WithWeight(weight int) FlexChild {
+	c.Grow = true
+	c.Weight = weight
+	return c
+}
+
 // RowLayout divides the area horizontally into child areas.
 // Children are arranged from left to right.
 //
 // The layout algorithm:
-// 1. Calculate fixed sizes from non-growing children
-// 2. Calculate remaining space
-// 3. Distribute remaining space to growing children equally
+// 1. Size each child as its constraint prefers, within its Min and Max
+// 2. Distribute remaining space to growing children by weight
+// 3. Shrink the children toward their minimums if they don't fit
 // 4. Assign areas from left to right
 //
+// The widths add up to the area's width whenever a child grows; see
+// solve for the priorities when constraints conflict.
+//
 // Example:
 //
 //	children := []layout.FlexChild{
@@ -50,6 +90,13 @@ func (c FlexChild) WithShrink() FlexChild {
 //	    layout.NewFlexChild(layout.Fixed(10)),        // 10 columns fixed
 //	}
 //	areas := RowLayout(area, children)
+//
+//	// 30% but at least 20 and at most 60 columns; the rest is split 2:1
+//	children = []layout.FlexChild{
+//	    layout.NewFlexChild(layout.Percent(30)).WithMin(20).WithMax(60),
+//	    layout.NewFlexChild(layout.Grow{}).WithWeight(2),
+//	    layout.NewFlexChild(layout.Grow{}).WithWeight(1),
+//	}
 func RowLayout(area Area, children []FlexChild) []Area {
 	return flexLayout(area, children, true)
 }
@@ -77,14 +124,10 @@ func flexLayout(area Area, children []FlexChild, horizontal bool) []Area {
 		return []Area{}
 	}
 
-	if len(children) == 1 && children[0].Grow {
-		return []Area{area}
-	}
-
 	available := getAvailableSize(area, horizontal)
 
 	// Calculate sizes for all children
-	sizes := calculateChildSizes(available, children, horizontal)
+	sizes := solve(available, children)
 
 	// Create child areas
 	areas := make([]Area, len(children))
@@ -119,40 +162,6 @@ func getStartPos(area Area, horizontal bool) image.Point {
 	return area.Rect().Min
 }
 
-// calculateChildSizes calculates the size for each child based on constraints.
-func calculateChildSizes(available int, children []FlexChild, horizontal bool) []int {
-	sizes := make([]int, len(children))
-
-	// First pass: calculate fixed sizes
-	var fixedTotal int
-	growCount := 0
-
-	for i, child := range children {
-		if !child.Grow {
-			sizes[i] = child.Constraint.Apply(available)
-			fixedTotal += sizes[i]
-		} else {
-			sizes[i] = 0
-			growCount++
-		}
-	}
-
-	// Second pass: distribute remaining space to growing children
-	if growCount > 0 {
-		remaining := available - fixedTotal
-		if remaining > 0 {
-			growSize := remaining / growCount
-			for i, child := range children {
-				if child.Grow {
-					sizes[i] = growSize
-				}
-			}
-		}
-	}
-
-	return sizes
-}
-
 // calculateHorizontalArea creates a horizontal child area.
 func calculateHorizontalArea(parent Area, startX, width int) Area {
 	return Area{
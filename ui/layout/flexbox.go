@@ -0,0 +1,318 @@
+package layout
+
+import (
+	"image"
+	"slices"
+)
+
+// Justify defines how the space left on a flex line is spread along the
+// main axis.
+type Justify int
+
+const (
+	// JustifyStart packs children at the start of the line.
+	JustifyStart Justify = iota
+	// JustifyCenter packs children in the middle of the line.
+	JustifyCenter
+	// JustifyEnd packs children at the end of the line.
+	JustifyEnd
+	// JustifySpaceBetween puts the space between children, none at the
+	// edges.
+	JustifySpaceBetween
+	// JustifySpaceAround gives every child the same space on both sides,
+	// so the edges get half the space between two children.
+	JustifySpaceAround
+	// JustifySpaceEvenly makes the space at the edges and between
+	// children the same.
+	JustifySpaceEvenly
+)
+
+// Align defines where children sit on the cross axis of their line.
+type Align int
+
+const (
+	// AlignAuto stretches children when used for AlignItems and defers to
+	// AlignItems when used for a child's AlignSelf.
+	AlignAuto Align = iota
+	// AlignStretch makes children as tall (or wide) as the line.
+	AlignStretch
+	// AlignStart puts children at the top (or left) of the line.
+	AlignStart
+	// AlignCenter centers children on the line.
+	AlignCenter
+	// AlignEnd puts children at the bottom (or right) of the line.
+	AlignEnd
+)
+
+// FlexConfig defines the configuration for a flexbox layout.
+type FlexConfig struct {
+	// JustifyContent spreads the space left on each line.
+	JustifyContent Justify
+	// AlignItems places children on the cross axis of their line, unless
+	// a child sets AlignSelf.
+	AlignItems Align
+	// Gap is the number of empty cells between children on a line.
+	Gap int
+	// LineGap is the number of empty cells between wrapped lines.
+	LineGap int
+	// Wrap starts a new line when the next child doesn't fit on the
+	// current one. Without it all children share one line and shrink.
+	Wrap bool
+}
+
+// NewFlexConfig creates a new FlexConfig that packs children at the start
+// of a single line and stretches them across it.
+func NewFlexConfig() FlexConfig {
+	return FlexConfig{
+		JustifyContent: JustifyStart,
+		AlignItems:     AlignStretch,
+	}
+}
+
+// WithJustifyContent sets how the space left on a line is spread and
+// returns the config.
+func (c FlexConfig) WithJustifyContent(justify Justify) FlexConfig {
+	c.JustifyContent = justify
+	return c
+}
+
+// WithAlignItems sets the cross axis alignment of children and returns
+// the config.
+func (c FlexConfig) WithAlignItems(align Align) FlexConfig {
+	c.AlignItems = align
+	return c
+}
+
+// WithGap sets the gap between children and returns the config.
+func (c FlexConfig) WithGap(gap int) FlexConfig {
+	c.Gap = gap
+	return c
+}
+
+// WithLineGap sets the gap between wrapped lines and returns the config.
+func (c FlexConfig) WithLineGap(gap int) FlexConfig {
+	c.LineGap = gap
+	return c
+}
+
+// WithWrap enables wrapping onto multiple lines and returns the config.
+func (c FlexConfig) WithWrap() FlexConfig {
+	c.Wrap = true
+	return c
+}
+
+// WithAlignSelf overrides the container's AlignItems for the FlexChild.
+func (c FlexChild) WithAlignSelf(align Align) FlexChild {
+	c.AlignSelf = align
+	return c
+}
+
+// WithOrder sets the position of the FlexChild among its siblings.
+func (c FlexChild) WithOrder(order int) FlexChild {
+	c.Order = order
+	return c
+}
+
+// WithCrossSize sets the size of the FlexChild across the main axis,
+// which is needed to align it anywhere but stretched.
+func (c FlexChild) WithCrossSize(size Constraint) FlexChild {
+	c.CrossSize = size
+	return c
+}
+
+// FlexboxRow lays children out from left to right like a CSS flex
+// container with flex-direction: row. The areas are returned in the order
+// of children, whatever their Order.
+//
+// Children are placed by ascending Order, keeping their given order on
+// ties. Each line is sized like RowLayout, in the width left after the
+// gaps. Space the children don't take is spread by JustifyContent. With
+// Wrap, a child that doesn't fit starts a new line; lines are as tall as
+// their tallest CrossSize and share the rest of the height equally.
+//
+// Example:
+//
+//	config := layout.NewFlexConfig().
+//	    WithJustifyContent(layout.JustifySpaceBetween).
+//	    WithAlignItems(layout.AlignCenter).
+//	    WithGap(1)
+//	areas := layout.FlexboxRow(toolbar, config, []layout.FlexChild{
+//	    layout.NewFlexChild(layout.Fixed(8)).WithCrossSize(layout.Fixed(1)),
+//	    layout.NewFlexChild(layout.Fixed(8)).WithCrossSize(layout.Fixed(1)),
+//	    layout.NewFlexChild(layout.Fixed(5)).WithOrder(-1), // placed first
+//	})
+func FlexboxRow(area Area, config FlexConfig, children []FlexChild) []Area {
+	return flexbox(area, config, children, true)
+}
+
+// FlexboxColumn lays children out from top to bottom like a CSS flex
+// container with flex-direction: column. See FlexboxRow for details.
+func FlexboxColumn(area Area, config FlexConfig, children []FlexChild) []Area {
+	return flexbox(area, config, children, false)
+}
+
+// flexbox is the common implementation of FlexboxRow and FlexboxColumn. It
+// works in main and cross axis coordinates relative to the area.
+func flexbox(area Area, config FlexConfig, children []FlexChild, horizontal bool) []Area {
+	areas := make([]Area, len(children))
+	if len(children) == 0 {
+		return areas
+	}
+
+	main, cross := area.Dx(), area.Dy()
+	if !horizontal {
+		main, cross = cross, main
+	}
+	gap, lineGap := max(config.Gap, 0), max(config.LineGap, 0)
+
+	order := make([]int, len(children))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return children[a].Order - children[b].Order
+	})
+	lines := breakLines(order, children, main, gap, config.Wrap)
+
+	// Cross sizes are relative to the container, not to the line
+	crossSizes := make([]int, len(children))
+	for i, child := range children {
+		if child.CrossSize != nil {
+			crossSizes[i] = min(max(child.CrossSize.Apply(cross), 0), cross)
+		}
+	}
+
+	// Lines are as thick as their thickest child and share what's left
+	thickness := make([]int, len(lines))
+	used := lineGap * (len(lines) - 1)
+	for l, line := range lines {
+		for _, i := range line {
+			thickness[l] = max(thickness[l], crossSizes[i])
+		}
+		used += thickness[l]
+	}
+	if left := cross - used; left > 0 {
+		for l, share := range split(left, slices.Repeat([]int{1}, len(lines))) {
+			thickness[l] += share
+		}
+	}
+
+	crossPos := 0
+	for l, line := range lines {
+		items := make([]FlexChild, len(line))
+		for k, i := range line {
+			items[k] = children[i]
+		}
+		space := max(main-gap*(len(line)-1), 0)
+		sizes := solve(space, items)
+		taken := 0
+		for _, size := range sizes {
+			taken += size
+		}
+		slots := justify(config.JustifyContent, max(space-taken, 0), len(line))
+
+		mainPos := slots[0]
+		for k, i := range line {
+			offset, size := alignCross(children[i], config.AlignItems, crossSizes[i], thickness[l])
+			r := image.Rect(mainPos, crossPos+offset, mainPos+sizes[k], crossPos+offset+size)
+			if !horizontal {
+				r = image.Rect(r.Min.Y, r.Min.X, r.Max.Y, r.Max.X)
+			}
+			areas[i] = Area(r.Add(area.Rect().Min)).Intersect(area)
+			mainPos += sizes[k] + gap + slots[k+1]
+		}
+		crossPos += thickness[l] + lineGap
+	}
+	return areas
+}
+
+// breakLines splits the children, in the order given, into lines whose
+// base sizes and gaps fit in main. Every line holds at least one child.
+// Without wrap all children are on one line.
+func breakLines(order []int, children []FlexChild, main, gap int, wrap bool) [][]int {
+	var lines [][]int
+	var line []int
+	used := 0
+	for _, i := range order {
+		size := children[i].basis(main)
+		if len(line) > 0 {
+			size += gap
+		}
+		if wrap && len(line) > 0 && used+size > main {
+			lines = append(lines, line)
+			line, used = nil, 0
+			size -= gap
+		}
+		line = append(line, i)
+		used += size
+	}
+	return append(lines, line)
+}
+
+// basis returns the size the child takes before growing or shrinking.
+func (c FlexChild) basis(available int) int {
+	size := 0
+	switch constraint := c.Constraint.(type) {
+	case nil, Grow, Fr:
+	default:
+		size = constraint.Apply(available)
+	}
+	lo, hi := c.bounds(available)
+	return min(max(size, lo), hi)
+}
+
+// justify returns the space before each of n children on a line and, as
+// the last element, after the last one, for the given space left.
+func justify(mode Justify, left, n int) []int {
+	slots := make([]int, n+1)
+	weights := make([]int, n+1)
+	switch mode {
+	case JustifyCenter:
+		weights[0], weights[n] = 1, 1
+	case JustifyEnd:
+		weights[0] = 1
+	case JustifySpaceBetween:
+		if n == 1 {
+			weights[n] = 1
+		}
+		for k := 1; k < n; k++ {
+			weights[k] = 1
+		}
+	case JustifySpaceAround:
+		for k := range weights {
+			weights[k] = 2
+		}
+		weights[0], weights[n] = 1, 1
+	case JustifySpaceEvenly:
+		for k := range weights {
+			weights[k] = 1
+		}
+	default:
+		weights[n] = 1
+	}
+	copy(slots, split(left, weights))
+	return slots
+}
+
+// alignCross returns the offset and size of the child across a line of
+// the given thickness, for its resolved cross size. A child without a
+// CrossSize always fills the line.
+func alignCross(child FlexChild, items Align, size, thickness int) (int, int) {
+	if child.CrossSize == nil {
+		return 0, thickness
+	}
+	size = min(size, thickness)
+
+	align := child.AlignSelf
+	if align == AlignAuto {
+		align = items
+	}
+	switch align {
+	case AlignCenter:
+		return (thickness - size) / 2, size
+	case AlignEnd:
+		return thickness - size, size
+	}
+	// Start, and stretch since an explicit cross size wins over it
+	return 0, size
+}
@@ -1,7 +1,15 @@
 package layout
 
 import (
+	"maps"
+	"slices"
 	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/buffer"
 )
 
 func TestNewArea(t *testing.T) {
@@ -371,6 +379,282 @@ func TestFlexColumn(t *testing.T) {
 	}
 }
 
+// widths returns the widths of areas laid out in a row.
+func widths(areas []Area) []int {
+	sizes := make([]int, len(areas))
+	for i, a := range areas {
+		sizes[i] = a.Dx()
+	}
+	return sizes
+}
+
+func TestRowLayoutSolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    int
+		children []FlexChild
+		want     []int
+	}{
+		{
+			name:  "percent within min and max",
+			width: 100,
+			children: []FlexChild{
+				NewFlexChild(Percent(30)).WithMin(20).WithMax(60),
+				NewFlexChild(Grow{}).WithGrow(),
+			},
+			want: []int{30, 70},
+		},
+		{
+			name:  "percent raised to min",
+			width: 40,
+			children: []FlexChild{
+				NewFlexChild(Percent(30)).WithMin(20).WithMax(60),
+				NewFlexChild(Grow{}).WithGrow(),
+			},
+			want: []int{20, 20},
+		},
+		{
+			name:  "percent capped at max",
+			width: 300,
+			children: []FlexChild{
+				NewFlexChild(Percent(30)).WithMin(20).WithMax(60),
+				NewFlexChild(Grow{}).WithGrow(),
+			},
+			want: []int{60, 240},
+		},
+		{
+			name:  "weights",
+			width: 100,
+			children: []FlexChild{
+				NewFlexChild(Fixed(10)),
+				NewFlexChild(Grow{}).WithWeight(2),
+				NewFlexChild(Grow{}).WithWeight(1),
+			},
+			want: []int{10, 60, 30},
+		},
+		{
+			name:  "equal growth fills odd widths",
+			width: 10,
+			children: []FlexChild{
+				NewFlexChild(Grow{}).WithGrow(),
+				NewFlexChild(Grow{}).WithGrow(),
+				NewFlexChild(Grow{}).WithGrow(),
+			},
+			want: []int{4, 3, 3},
+		},
+		{
+			name:  "capped growth goes to the others",
+			width: 100,
+			children: []FlexChild{
+				NewFlexChild(Grow{}).WithGrow().WithMax(10),
+				NewFlexChild(Grow{}).WithGrow(),
+			},
+			want: []int{10, 90},
+		},
+		{
+			name:     "percentages round without drift",
+			width:    10,
+			children: []FlexChild{NewFlexChild(Percent(33)), NewFlexChild(Percent(33)), NewFlexChild(Percent(34))},
+			want:     []int{3, 4, 3},
+		},
+		{
+			name:     "halves of an odd width",
+			width:    11,
+			children: []FlexChild{NewFlexChild(Ratio(1, 2)), NewFlexChild(Ratio(1, 2))},
+			want:     []int{6, 5},
+		},
+		{
+			name:     "min size constraint grows",
+			width:    50,
+			children: []FlexChild{NewFlexChild(MinSize{Min: 10}).WithGrow(), NewFlexChild(MaxSize{Max: 15}).WithGrow()},
+			want:     []int{35, 15},
+		},
+		{
+			name:     "overflow shrinks proportionally",
+			width:    100,
+			children: []FlexChild{NewFlexChild(Fixed(60)), NewFlexChild(Fixed(60))},
+			want:     []int{50, 50},
+		},
+		{
+			name:  "shrinkable children shrink first",
+			width: 100,
+			children: []FlexChild{
+				NewFlexChild(Fixed(60)),
+				NewFlexChild(Fixed(60)).WithShrink().WithMin(30),
+			},
+			want: []int{60, 40},
+		},
+		{
+			name:  "shrinking stops at min",
+			width: 50,
+			children: []FlexChild{
+				NewFlexChild(Fixed(40)).WithMin(30),
+				NewFlexChild(Fixed(40)).WithMin(10),
+			},
+			want: []int{32, 18},
+		},
+		{
+			name:     "minimums that don't fit are cut from the end",
+			width:    25,
+			children: []FlexChild{NewFlexChild(Fixed(20)).WithMin(20), NewFlexChild(Fixed(20)).WithMin(20)},
+			want:     []int{20, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			areas := RowLayout(NewArea(5, 0, 5+tt.width, 3), tt.children)
+			got := widths(areas)
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected widths %v, got %v", tt.want, got)
+				}
+			}
+			// Children are contiguous and, here, fill the area exactly
+			if areas[0].Min.X != 5 || areas[len(areas)-1].Max.X != 5+tt.width {
+				t.Errorf("expected the children to fill [5, %d), got %v", 5+tt.width, areas)
+			}
+		})
+	}
+}
+
+func TestFlexbox(t *testing.T) {
+	area := NewArea(0, 0, 40, 5)
+	three := []FlexChild{NewFlexChild(Fixed(6)), NewFlexChild(Fixed(6)), NewFlexChild(Fixed(6))}
+	starts := func(areas []Area) []int {
+		xs := make([]int, len(areas))
+		for i, a := range areas {
+			xs[i] = a.Min.X
+		}
+		return xs
+	}
+
+	t.Run("JustifyContent", func(t *testing.T) {
+		tests := []struct {
+			justify Justify
+			want    []int
+		}{
+			{JustifyStart, []int{0, 6, 12}},
+			{JustifyCenter, []int{11, 17, 23}},
+			{JustifyEnd, []int{22, 28, 34}},
+			{JustifySpaceBetween, []int{0, 17, 34}},
+			{JustifySpaceAround, []int{4, 17, 30}},
+			{JustifySpaceEvenly, []int{6, 18, 29}},
+		}
+		for _, tt := range tests {
+			areas := FlexboxRow(area, NewFlexConfig().WithJustifyContent(tt.justify), three)
+			if got := starts(areas); !slices.Equal(got, tt.want) {
+				t.Errorf("justify %d: expected starts %v, got %v", tt.justify, tt.want, got)
+			}
+			if w := widths(areas); !slices.Equal(w, []int{6, 6, 6}) {
+				t.Errorf("justify %d: expected widths to be kept, got %v", tt.justify, w)
+			}
+		}
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		config := NewFlexConfig().WithGap(2)
+		areas := FlexboxRow(area, config, append(three[:2:2], NewFlexChild(Grow{}).WithGrow()))
+		if got := starts(areas); !slices.Equal(got, []int{0, 8, 16}) {
+			t.Errorf("expected starts [0 8 16], got %v", got)
+		}
+		if areas[2].Max.X != 40 {
+			t.Errorf("expected the growing child to fill the rest, got %v", areas[2])
+		}
+	})
+
+	t.Run("Align", func(t *testing.T) {
+		config := NewFlexConfig().WithAlignItems(AlignCenter)
+		areas := FlexboxRow(area, config, []FlexChild{
+			NewFlexChild(Fixed(6)),
+			NewFlexChild(Fixed(6)).WithCrossSize(Fixed(1)),
+			NewFlexChild(Fixed(6)).WithCrossSize(Fixed(2)).WithAlignSelf(AlignEnd),
+			NewFlexChild(Fixed(6)).WithCrossSize(Fixed(2)).WithAlignSelf(AlignStart),
+		})
+		want := []Area{
+			NewArea(0, 0, 6, 5),
+			NewArea(6, 2, 12, 3),
+			NewArea(12, 3, 18, 5),
+			NewArea(18, 0, 24, 2),
+		}
+		if !slices.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Wrap", func(t *testing.T) {
+		config := NewFlexConfig().WithWrap().WithGap(1).WithLineGap(1)
+		children := []FlexChild{
+			NewFlexChild(Fixed(15)).WithCrossSize(Fixed(1)),
+			NewFlexChild(Fixed(15)).WithCrossSize(Fixed(2)),
+			NewFlexChild(Fixed(15)).WithCrossSize(Fixed(1)),
+		}
+		areas := FlexboxRow(NewArea(0, 0, 32, 6), config, children)
+		// Two lines of 2 and 1 rows share the 2 rows left after the gap
+		want := []Area{
+			NewArea(0, 0, 15, 1),
+			NewArea(16, 0, 31, 2),
+			NewArea(0, 4, 15, 5),
+		}
+		if !slices.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+
+		// Without wrap the children shrink to share one line
+		config.Wrap = false
+		areas = FlexboxRow(NewArea(0, 0, 32, 6), config, children)
+		if got := widths(areas); !slices.Equal(got, []int{10, 10, 10}) {
+			t.Errorf("expected one shrunk line, got widths %v", got)
+		}
+
+		// Percent cross sizes are taken of the container, once
+		config = NewFlexConfig().WithWrap()
+		children = []FlexChild{
+			NewFlexChild(Fixed(6)).WithCrossSize(Percent(50)),
+			NewFlexChild(Fixed(6)).WithCrossSize(Percent(50)),
+		}
+		areas = FlexboxRow(NewArea(0, 0, 10, 10), config, children)
+		want = []Area{NewArea(0, 0, 6, 5), NewArea(0, 5, 6, 10)}
+		if !slices.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		areas := FlexboxRow(area, NewFlexConfig(), []FlexChild{
+			NewFlexChild(Fixed(5)),
+			NewFlexChild(Fixed(6)).WithOrder(-1),
+			NewFlexChild(Fixed(7)).WithOrder(1),
+			NewFlexChild(Fixed(8)),
+		})
+		// Areas keep the order of the children
+		if got := widths(areas); !slices.Equal(got, []int{5, 6, 7, 8}) {
+			t.Errorf("expected areas in the children's order, got widths %v", got)
+		}
+		if got := starts(areas); !slices.Equal(got, []int{6, 0, 19, 11}) {
+			t.Errorf("expected placement by order, got starts %v", got)
+		}
+	})
+
+	t.Run("Column", func(t *testing.T) {
+		config := NewFlexConfig().WithJustifyContent(JustifyEnd).WithAlignItems(AlignCenter)
+		areas := FlexboxColumn(NewArea(2, 1, 12, 11), config, []FlexChild{
+			NewFlexChild(Fixed(3)).WithCrossSize(Fixed(4)),
+			NewFlexChild(Fixed(2)),
+		})
+		want := []Area{NewArea(5, 6, 9, 9), NewArea(2, 9, 12, 11)}
+		if !slices.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if areas := FlexboxRow(area, NewFlexConfig(), nil); len(areas) != 0 {
+			t.Errorf("expected no areas, got %v", areas)
+		}
+	})
+}
+
 func TestGridLayout(t *testing.T) {
 	area := NewArea(0, 0, 60, 40)
 	config := NewGridConfig(2, 3)
@@ -507,6 +791,306 @@ func TestSpanCell(t *testing.T) {
 	}
 }
 
+func TestGridTemplate(t *testing.T) {
+	area := NewArea(0, 0, 40, 12)
+
+	t.Run("NamedAreas", func(t *testing.T) {
+		template := NewGridTemplate(
+			"header header",
+			"side   main",
+			"footer footer",
+		).WithColumns(Fixed(10), Fr(1)).WithRows(Fixed(1), Fr(1), Fixed(1))
+		areas := template.Layout(area)
+		want := map[string]Area{
+			"header": NewArea(0, 0, 40, 1),
+			"side":   NewArea(0, 1, 10, 11),
+			"main":   NewArea(10, 1, 40, 11),
+			"footer": NewArea(0, 11, 40, 12),
+		}
+		if !maps.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Tracks", func(t *testing.T) {
+		template := NewGridTemplate("a b c d").
+			WithColumns(Fixed(4), Fr(1), Auto{}, Fr(2)).
+			WithColGaps(1)
+		items := []GridItem{NewGridItem("label").WithArea("c").WithSize(6, 1)}
+		areas := template.Layout(area, items...)
+		// 37 columns after the gaps: 4 fixed, 6 auto, 27 shared 1:2
+		if got := []int{areas["a"].Dx(), areas["b"].Dx(), areas["c"].Dx(), areas["d"].Dx()}; !slices.Equal(got, []int{4, 9, 6, 18}) {
+			t.Errorf("expected widths [4 9 6 18], got %v", got)
+		}
+		if areas["label"] != areas["c"] {
+			t.Errorf("expected the item to fill its area, got %v", areas["label"])
+		}
+		if areas["d"].Max.X != 40 {
+			t.Errorf("expected the last track to end at 40, got %v", areas["d"])
+		}
+
+		// Without Fr tracks, Auto tracks share the space left
+		areas = NewGridTemplate("a b").WithColumns(Auto{}, Fixed(10)).Layout(area)
+		if areas["a"].Dx() != 30 {
+			t.Errorf("expected the auto track to stretch to 30, got %d", areas["a"].Dx())
+		}
+	})
+
+	t.Run("AutoPlacement", func(t *testing.T) {
+		template := NewGridTemplate(
+			"nav . .",
+			"nav . .",
+		).WithAutoRows(Fixed(2))
+		areas := template.Layout(NewArea(0, 0, 30, 10),
+			NewGridItem("one"),
+			NewGridItem("two").WithSpan(2, 1),
+			NewGridItem("three").WithSpan(2, 1),
+			NewGridItem("four").WithSpan(5, 1),
+		)
+		// Fr rows share the 6 rows left by the added row
+		want := map[string]Area{
+			"nav":   NewArea(0, 0, 10, 6),
+			"one":   NewArea(10, 0, 20, 3),
+			"two":   NewArea(10, 3, 30, 6),
+			"three": NewArea(0, 6, 20, 8),
+			"four":  NewArea(0, 8, 30, 10),
+		}
+		if !maps.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		if err := NewGridTemplate("a a", "b c").Validate(); err != nil {
+			t.Errorf("expected a valid template, got %v", err)
+		}
+		if err := NewGridTemplate("a b", "b a").Validate(); err == nil {
+			t.Error("expected an error for areas that are not rectangles")
+		}
+		if err := NewGridTemplate("a b", "c").Validate(); err == nil {
+			t.Error("expected an error for rows of different lengths")
+		}
+
+		areas := NewGridTemplate("a b", "b b").Layout(area)
+		if _, ok := areas["b"]; ok {
+			t.Errorf("expected the broken area to be left out, got %v", areas)
+		}
+	})
+
+	t.Run("FrInRowLayout", func(t *testing.T) {
+		areas := RowLayout(NewArea(0, 0, 30, 1), []FlexChild{
+			NewFlexChild(Fr(1)),
+			NewFlexChild(Fr(2)),
+		})
+		if got := widths(areas); !slices.Equal(got, []int{10, 20}) {
+			t.Errorf("expected widths [10 20], got %v", got)
+		}
+	})
+}
+
+// compactComponent records its compact mode.
+type compactComponent struct {
+	compact bool
+}
+
+func (c *compactComponent) SetCompactMode(compact bool) {
+	c.compact = compact
+}
+
+func TestResponsive(t *testing.T) {
+	named := func(name string) LayoutFunc {
+		return func(area Area) map[string]Area {
+			return map[string]Area{name: area}
+		}
+	}
+	newScreen := func() *Responsive {
+		return NewResponsive(named("wide")).
+			Add(Breakpoint{Name: "short", MaxHeight: 29}, named("stacked")).
+			Add(Breakpoint{Name: "narrow", MaxWidth: 99}, named("no-sidebar")).
+			WithHysteresis(2)
+	}
+
+	t.Run("Breakpoints", func(t *testing.T) {
+		tests := []struct {
+			width, height int
+			want          string
+		}{
+			{120, 40, ""},
+			{80, 40, "narrow"},
+			{120, 20, "short"},
+			{80, 20, "short"},
+		}
+		for _, tt := range tests {
+			screen := newScreen()
+			screen.Resize(tt.width, tt.height)
+			if got := screen.Active(); got != tt.want {
+				t.Errorf("%dx%d: expected breakpoint %q, got %q", tt.width, tt.height, tt.want, got)
+			}
+		}
+
+		screen := newScreen()
+		screen.Update(render.WindowSizeMsg{Width: 80, Height: 40})
+		if _, ok := screen.Layout(NewArea(0, 0, 80, 40))["no-sidebar"]; !ok {
+			t.Error("expected the narrow layout")
+		}
+	})
+
+	t.Run("Hysteresis", func(t *testing.T) {
+		screen := newScreen()
+		var changes []string
+		screen.OnChange(func(name string) {
+			changes = append(changes, name)
+		})
+		for _, width := range []int{120, 99, 100, 101, 98, 102, 101, 100, 99} {
+			screen.Resize(width, 40)
+		}
+		// 99 enters narrow, which holds up to 101; 102 leaves it and only
+		// 99 enters it again
+		want := []string{"narrow", "", "narrow"}
+		if !slices.Equal(changes, want) {
+			t.Errorf("expected changes %q, got %q", want, changes)
+		}
+
+		// An earlier breakpoint takes over at once
+		screen.Resize(80, 40)
+		if !screen.Resize(80, 29) || screen.Active() != "short" {
+			t.Errorf("expected short to take over, got %q", screen.Active())
+		}
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		screen := newScreen()
+		sidebar := &compactComponent{}
+		screen.Compact(sidebar, "narrow", "short")
+		screen.Resize(80, 40)
+		if !sidebar.compact {
+			t.Error("expected compact mode under narrow")
+		}
+		screen.Resize(120, 40)
+		if sidebar.compact {
+			t.Error("expected compact mode off when wide")
+		}
+	})
+}
+
+// viewModel is a render.Model with a fixed view.
+type viewModel string
+
+func (m viewModel) Init() render.Cmd                       { return nil }
+func (m viewModel) Update(any) (render.Model, render.Cmd) { return m, nil }
+func (m viewModel) View() string                          { return string(m) }
+
+func TestNode(t *testing.T) {
+	plain := func(s string) string {
+		return ansi.Strip(s)
+	}
+
+	t.Run("RowAndColumn", func(t *testing.T) {
+		tree := Column(
+			Text("title"),
+			Row(Text("ab"), Text("cd").WithGrow(1), Text("ef")).WithGap(1),
+			Text("end"),
+		)
+		want := "title\nab cd ef\nend"
+		if got := plain(tree.RenderString(0, 0)); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+
+		// The growing child takes the extra width
+		want = "title\nab cd    ef\nend"
+		if got := plain(tree.RenderString(11, 3)); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("BorderAndPadding", func(t *testing.T) {
+		tree := Box(Text("hi")).WithPadding(0, 1).WithBorder(lipgloss.NormalBorder(), buffer.Style{})
+		if w, h := tree.Measure(80, 24); w != 6 || h != 3 {
+			t.Errorf("expected 6x3, got %dx%d", w, h)
+		}
+		want := "┌────┐\n│ hi │\n└────┘"
+		if got := plain(tree.RenderString(0, 0)); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("Areas", func(t *testing.T) {
+		tree := Column(
+			Row(Text("ok").WithName("ok"), Text("cancel").WithName("cancel")).
+				WithName("buttons").WithJustify(JustifyEnd).WithGap(1),
+			Text("body").WithName("body").WithGrow(1),
+			Text("x").WithName("mark").WithWidth(Fixed(1)),
+		).WithAlign(AlignCenter)
+		areas := tree.Areas(NewArea(0, 0, 20, 5))
+		want := map[string]Area{
+			"buttons": NewArea(5, 0, 14, 1),
+			"ok":      NewArea(5, 0, 7, 1),
+			"cancel":  NewArea(8, 0, 14, 1),
+			"body":    NewArea(8, 1, 12, 4),
+			"mark":    NewArea(9, 4, 10, 5),
+		}
+		if !maps.Equal(areas, want) {
+			t.Errorf("expected %v, got %v", want, areas)
+		}
+	})
+
+	t.Run("Stack", func(t *testing.T) {
+		tree := Stack(
+			Text("......\n......"),
+			Text("ab").WithWidth(Fixed(2)).WithHeight(Fixed(1)),
+		)
+		want := "ab....\n......"
+		if got := plain(tree.RenderString(0, 0)); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("UnboundedPercent", func(t *testing.T) {
+		tree := Row(Text("ab").WithWidth(Percent(50)), Text("cd"))
+		if w, h := tree.Measure(maxInt, maxInt); w != 4 || h != 1 {
+			t.Errorf("expected the content size 4x1, got %dx%d", w, h)
+		}
+		if got := plain(tree.RenderString(0, 0)); got != "abcd" {
+			t.Errorf("expected %q, got %q", "abcd", got)
+		}
+		if got := plain(tree.RenderString(10, 1)); got != "ab   cd" {
+			t.Errorf("expected %q, got %q", "ab   cd", got)
+		}
+
+		boxed := Box(Text("ab").WithHeight(Percent(100))).WithBorder(lipgloss.NormalBorder(), buffer.Style{})
+		if w, h := boxed.Measure(maxInt, maxInt); w != 4 || h != 3 {
+			t.Errorf("expected the boxed content size 4x3, got %dx%d", w, h)
+		}
+	})
+
+	t.Run("Components", func(t *testing.T) {
+		tree := Row(
+			Component(buffer.NewTextComponent("abc", buffer.Style{})),
+			Model(viewModel("\x1b[1mbold\x1b[0m")).WithWidth(Fixed(3)),
+		)
+		buf := buffer.NewBuffer(10, 2)
+		tree.Render(buf, NewArea(2, 1, 10, 2))
+		want := "\n  abcbol"
+		if got := plain(buf.Render()); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+		if !buf.CellAt(buffer.Point{X: 5, Y: 1}).Style.Bold {
+			t.Error("expected the model's styles to be kept")
+		}
+	})
+
+	t.Run("Style", func(t *testing.T) {
+		style := buffer.Style{Background: "#0000ff"}
+		buf := buffer.NewBuffer(4, 1)
+		Text("a").WithStyle(style).Render(buf, NewArea(0, 0, 4, 1))
+		for x := range 4 {
+			if cell := buf.CellAt(buffer.Point{X: x}); cell.Style != style {
+				t.Errorf("expected cell %d to be styled, got %+v", x, cell.Style)
+			}
+		}
+	})
+}
+
 func TestFixedGrid(t *testing.T) {
 	area := NewArea(0, 0, 100, 100)
 	cells := FixedGrid(area, 20, 20)
@@ -0,0 +1,465 @@
+package layout
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+)
+
+// nodeKind is the kind of a Node.
+type nodeKind int
+
+const (
+	boxNode nodeKind = iota
+	rowNode
+	columnNode
+	stackNode
+	textNode
+	componentNode
+)
+
+// Node is an element of a declarative layout tree. Nodes are built with
+// Box, Row, Column, Stack, Text, Component and Model, configured with the
+// With methods, and the tree is measured and drawn in one go by Render or
+// RenderString, so no coordinates have to be computed by hand.
+//
+// Every node may have a size, padding, a border and a style. A node
+// without a size takes the size of its content: the widest line of a
+// Text, the PreferredSize of a Component, or its children for the others.
+//
+// Example:
+//
+//	screen := layout.Column(
+//	    layout.Text("Taproot").WithStyle(titleStyle),
+//	    layout.Row(
+//	        layout.Model(sidebar).WithWidth(layout.Fixed(20)),
+//	        layout.Component(editor).WithGrow(1),
+//	    ).WithGrow(1).WithGap(1),
+//	    layout.Text("q: quit"),
+//	).WithBorder(lipgloss.RoundedBorder(), borderStyle)
+//	view := screen.RenderString(width, height)
+type Node struct {
+	kind      nodeKind
+	children  []Node
+	text      string
+	component buffer.Renderable
+
+	name        string
+	width       Constraint
+	height      Constraint
+	grow        int
+	padding     [4]int
+	border      *lipgloss.Border
+	borderStyle buffer.Style
+	style       buffer.Style
+
+	gap     int
+	justify Justify
+	align   Align
+}
+
+// Box creates a node that holds a single child, to give it padding, a
+// border or a style.
+func Box(child Node) Node {
+	return Node{kind: boxNode, children: []Node{child}}
+}
+
+// Row creates a node that lays its children out from left to right.
+func Row(children ...Node) Node {
+	return Node{kind: rowNode, children: children}
+}
+
+// Column creates a node that lays its children out from top to bottom.
+func Column(children ...Node) Node {
+	return Node{kind: columnNode, children: children}
+}
+
+// Stack creates a node that draws its children on top of each other, the
+// last one on top. Each child fills the stack unless it has a size.
+func Stack(children ...Node) Node {
+	return Node{kind: stackNode, children: children}
+}
+
+// Text creates a node that draws text. The text may contain ANSI styles;
+// lines longer than the node are cut.
+func Text(text string) Node {
+	return Node{kind: textNode, text: text}
+}
+
+// Component creates a node that draws a buffer component.
+func Component(component buffer.Renderable) Node {
+	return Node{kind: componentNode, component: component}
+}
+
+// Model creates a node that draws the view of a model.
+func Model(model render.Model) Node {
+	return Component(buffer.NewModelComponent(model))
+}
+
+// WithName names the node so its area can be looked up with Areas.
+func (n Node) WithName(name string) Node {
+	n.name = name
+	return n
+}
+
+// WithWidth sets the width of the node and returns the node. Grow and Fr
+// make the node grow in a Row like WithGrow.
+func (n Node) WithWidth(width Constraint) Node {
+	n.width = width
+	return n
+}
+
+// WithHeight sets the height of the node and returns the node. Grow and Fr
+// make the node grow in a Column like WithGrow.
+func (n Node) WithHeight(height Constraint) Node {
+	n.height = height
+	return n
+}
+
+// WithGrow makes the node take a share of the space left in its Row or
+// Column, in proportion to the weight, and returns the node.
+func (n Node) WithGrow(weight int) Node {
+	n.grow = weight
+	return n
+}
+
+// WithPadding sets the empty cells inside the border and returns the node.
+// Like CSS, one value is used for all sides, two for vertical and
+// horizontal and four for top, right, bottom and left.
+func (n Node) WithPadding(sides ...int) Node {
+	switch len(sides) {
+	case 1:
+		n.padding = [4]int{sides[0], sides[0], sides[0], sides[0]}
+	case 2:
+		n.padding = [4]int{sides[0], sides[1], sides[0], sides[1]}
+	case 4:
+		n.padding = [4]int{sides[0], sides[1], sides[2], sides[3]}
+	}
+	return n
+}
+
+// WithBorder draws the border around the node and returns the node.
+func (n Node) WithBorder(border lipgloss.Border, style buffer.Style) Node {
+	n.border = &border
+	n.borderStyle = style
+	return n
+}
+
+// WithStyle fills the node with the style, which also styles plain text,
+// and returns the node.
+func (n Node) WithStyle(style buffer.Style) Node {
+	n.style = style
+	return n
+}
+
+// WithGap sets the empty cells between the children of a Row or Column
+// and returns the node.
+func (n Node) WithGap(gap int) Node {
+	n.gap = gap
+	return n
+}
+
+// WithJustify sets how the space left in a Row or Column is spread and
+// returns the node.
+func (n Node) WithJustify(justify Justify) Node {
+	n.justify = justify
+	return n
+}
+
+// WithAlign sets where the children of a Row or Column sit across it and
+// returns the node. Children are stretched by default.
+func (n Node) WithAlign(align Align) Node {
+	n.align = align
+	return n
+}
+
+// Measure returns the size the node wants, at most maxWidth by maxHeight.
+func (n Node) Measure(maxWidth, maxHeight int) (int, int) {
+	frameW, frameH := n.frame()
+	innerW, innerH := shrink(maxWidth, frameW), shrink(maxHeight, frameH)
+
+	width, height := 0, 0
+	switch n.kind {
+	case textNode:
+		for _, line := range strings.Split(n.text, "\n") {
+			width = max(width, ansi.StringWidth(line))
+		}
+		height = strings.Count(n.text, "\n") + 1
+	case componentNode:
+		if n.component != nil {
+			width, height = n.component.PreferredSize()
+		}
+	case rowNode, columnNode:
+		for _, child := range n.children {
+			w, h := child.Measure(innerW, innerH)
+			if n.kind == columnNode {
+				w, h = h, w
+			}
+			width += w
+			height = max(height, h)
+		}
+		width += max(n.gap, 0) * max(len(n.children)-1, 0)
+		if n.kind == columnNode {
+			width, height = height, width
+		}
+	default:
+		for _, child := range n.children {
+			w, h := child.Measure(innerW, innerH)
+			width, height = max(width, w), max(height, h)
+		}
+	}
+
+	width = measuredSize(n.width, width+frameW, maxWidth)
+	height = measuredSize(n.height, height+frameH, maxHeight)
+	return min(width, maxWidth), min(height, maxHeight)
+}
+
+// measuredSize returns the size a node of the given content size wants
+// within available. Sizes relative to the space, like Percent, mean
+// nothing when it is unbounded, so the content size is used instead.
+func measuredSize(size Constraint, content, available int) int {
+	size = fixedSize(size)
+	if size == nil {
+		return content
+	}
+	if available >= maxInt {
+		switch size := size.(type) {
+		case Percent:
+			return content
+		case MaxSize:
+			if size.Max <= 0 {
+				return content
+			}
+		}
+	}
+	return size.Apply(available)
+}
+
+// shrink returns size less the cells taken, keeping an unbounded size
+// unbounded.
+func shrink(size, taken int) int {
+	if size >= maxInt {
+		return maxInt
+	}
+	return max(size-taken, 0)
+}
+
+// frame returns the width and height taken by the padding and border.
+func (n Node) frame() (int, int) {
+	width := n.padding[1] + n.padding[3]
+	height := n.padding[0] + n.padding[2]
+	if n.border != nil {
+		width += 2
+		height += 2
+	}
+	return width, height
+}
+
+// fixedSize returns the constraint unless it is nil or makes the node
+// grow.
+func fixedSize(size Constraint) Constraint {
+	switch size.(type) {
+	case nil, Grow, Fr:
+		return nil
+	}
+	return size
+}
+
+// Areas returns the areas of the named nodes of the tree laid out in area.
+func (n Node) Areas(area Area) map[string]Area {
+	areas := make(map[string]Area)
+	n.arrange(area, func(node *Node, area Area) {
+		if node.name != "" {
+			areas[node.name] = area
+		}
+	})
+	return areas
+}
+
+// Render draws the tree into buf within area.
+func (n Node) Render(buf *buffer.Buffer, area Area) {
+	area = area.Intersect(NewArea(0, 0, buf.Width(), buf.Height()))
+	n.arrange(area, func(node *Node, area Area) {
+		node.draw(buf, area)
+	})
+}
+
+// RenderString draws the tree in width by height cells and returns it as a
+// string. A size of 0 or less uses the size the tree wants.
+func (n Node) RenderString(width, height int) string {
+	w, h := maxInt, maxInt
+	if width > 0 {
+		w = width
+	}
+	if height > 0 {
+		h = height
+	}
+	mw, mh := n.Measure(w, h)
+	if width <= 0 {
+		w = mw
+	}
+	if height <= 0 {
+		h = mh
+	}
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+
+	buf := buffer.GetBuffer(w, h)
+	defer buffer.PutBuffer(buf)
+	n.Render(buf, NewArea(0, 0, w, h))
+	return buf.Render()
+}
+
+// maxInt is the unbounded size a tree drawn at the size it wants is
+// measured against.
+const maxInt = 1 << 20
+
+// arrange lays the tree out in area and calls visit for every node, a
+// parent before its children.
+func (n Node) arrange(area Area, visit func(node *Node, area Area)) {
+	visit(&n, area)
+
+	inner := n.inner(area)
+	switch n.kind {
+	case boxNode, stackNode:
+		for _, child := range n.children {
+			w, h := child.Measure(inner.Dx(), inner.Dy())
+			if fixedSize(child.width) == nil {
+				w = inner.Dx()
+			}
+			if fixedSize(child.height) == nil {
+				h = inner.Dy()
+			}
+			origin := inner.Rect().Min
+			child.arrange(NewArea(origin.X, origin.Y, origin.X+w, origin.Y+h), visit)
+		}
+	case rowNode, columnNode:
+		horizontal := n.kind == rowNode
+		children := make([]FlexChild, len(n.children))
+		for i, child := range n.children {
+			children[i] = child.flexChild(inner, horizontal, n.align)
+		}
+		config := FlexConfig{JustifyContent: n.justify, AlignItems: n.align, Gap: n.gap}
+		var areas []Area
+		if horizontal {
+			areas = FlexboxRow(inner, config, children)
+		} else {
+			areas = FlexboxColumn(inner, config, children)
+		}
+		for i, child := range n.children {
+			child.arrange(areas[i], visit)
+		}
+	}
+}
+
+// inner returns the area inside the node's border and padding.
+func (n Node) inner(area Area) Area {
+	r := area.Rect()
+	top, right, bottom, left := n.padding[0], n.padding[1], n.padding[2], n.padding[3]
+	if n.border != nil {
+		top, right, bottom, left = top+1, right+1, bottom+1, left+1
+	}
+	minX, minY := min(r.Min.X+left, r.Max.X), min(r.Min.Y+top, r.Max.Y)
+	return NewArea(minX, minY, max(r.Max.X-right, minX), max(r.Max.Y-bottom, minY))
+}
+
+// flexChild returns the FlexChild that sizes the node in a Row or Column
+// laid out in area.
+func (n Node) flexChild(area Area, horizontal bool, align Align) FlexChild {
+	w, h := n.Measure(area.Dx(), area.Dy())
+	mainSize, crossSize := n.width, n.height
+	measured, cross := w, h
+	if !horizontal {
+		mainSize, crossSize = crossSize, mainSize
+		measured, cross = cross, measured
+	}
+
+	child := NewFlexChild(mainSize)
+	if fixedSize(mainSize) == nil {
+		child.Constraint = Fixed(measured)
+		if mainSize != nil {
+			child.Constraint = mainSize
+		}
+	}
+	if n.grow > 0 {
+		child.Weight = n.grow
+	}
+	switch {
+	case fixedSize(crossSize) != nil:
+		child.CrossSize = crossSize
+	case align != AlignAuto && align != AlignStretch:
+		child.CrossSize = Fixed(cross)
+	}
+	return child
+}
+
+// draw draws the node itself, without its children, in area.
+func (n *Node) draw(buf *buffer.Buffer, area Area) {
+	if area.Empty() {
+		return
+	}
+	rect := buffer.Rect{X: area.Min.X, Y: area.Min.Y, Width: area.Dx(), Height: area.Dy()}
+	if n.style != (buffer.Style{}) {
+		buf.FillRect(rect, ' ', n.style)
+	}
+	if n.border != nil {
+		drawBorder(buf, area, *n.border, n.borderStyle)
+	}
+
+	inner := n.inner(area)
+	if inner.Empty() {
+		return
+	}
+	switch n.kind {
+	case textNode:
+		for i, line := range strings.Split(n.text, "\n") {
+			if i >= inner.Dy() {
+				break
+			}
+			p := buffer.Point{X: inner.Min.X, Y: inner.Min.Y + i}
+			line = ansi.Truncate(line, inner.Dx(), "")
+			if strings.Contains(line, "\x1b") {
+				buf.WriteANSI(p, line)
+			} else {
+				buf.WriteString(p, line, n.style)
+			}
+		}
+	case componentNode:
+		if n.component != nil {
+			n.component.Render(buf, buffer.Rect{X: inner.Min.X, Y: inner.Min.Y, Width: inner.Dx(), Height: inner.Dy()})
+		}
+	}
+}
+
+// drawBorder draws the border along the edges of area.
+func drawBorder(buf *buffer.Buffer, area Area, border lipgloss.Border, style buffer.Style) {
+	r := area.Rect()
+	if r.Dx() < 2 || r.Dy() < 2 {
+		return
+	}
+	put := func(x, y int, s string) {
+		if s == "" {
+			s = " "
+		}
+		buf.WriteString(buffer.Point{X: x, Y: y}, s, style)
+	}
+
+	right, bottom := r.Max.X-1, r.Max.Y-1
+	for x := r.Min.X + 1; x < right; x++ {
+		put(x, r.Min.Y, border.Top)
+		put(x, bottom, border.Bottom)
+	}
+	for y := r.Min.Y + 1; y < bottom; y++ {
+		put(r.Min.X, y, border.Left)
+		put(right, y, border.Right)
+	}
+	put(r.Min.X, r.Min.Y, border.TopLeft)
+	put(right, r.Min.Y, border.TopRight)
+	put(r.Min.X, bottom, border.BottomLeft)
+	put(right, bottom, border.BottomRight)
+}
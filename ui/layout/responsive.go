@@ -0,0 +1,204 @@
+package layout
+
+import (
+	"slices"
+
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+// Breakpoint is a range of screen sizes. A bound of 0 means no bound.
+type Breakpoint struct {
+	// Name identifies the breakpoint, e.g. "narrow" or "short".
+	Name string
+	// MinWidth and MaxWidth bound the width, inclusive.
+	MinWidth int
+	MaxWidth int
+	// MinHeight and MaxHeight bound the height, inclusive.
+	MinHeight int
+	MaxHeight int
+}
+
+// Matches reports whether the size is in the breakpoint's range.
+func (b Breakpoint) Matches(width, height int) bool {
+	return b.matches(width, height, 0)
+}
+
+// matches reports whether the size is in the breakpoint's range widened by
+// margin cells on each bound.
+func (b Breakpoint) matches(width, height, margin int) bool {
+	return within(width, b.MinWidth, b.MaxWidth, margin) &&
+		within(height, b.MinHeight, b.MaxHeight, margin)
+}
+
+// within reports whether n is between lo and hi, 0 meaning no bound, with
+// margin cells of slack.
+func within(n, lo, hi, margin int) bool {
+	if lo > 0 && n < lo-margin {
+		return false
+	}
+	if hi > 0 && n > hi+margin {
+		return false
+	}
+	return true
+}
+
+// LayoutFunc computes the named areas of a screen for the given area, like
+// GridTemplate.Layout.
+type LayoutFunc func(area Area) map[string]Area
+
+// Compactable is implemented by components with a compact mode, such as
+// the sidebar and the header.
+type Compactable interface {
+	SetCompactMode(compact bool)
+}
+
+// responsiveLayout is a layout registered for a breakpoint.
+type responsiveLayout struct {
+	breakpoint Breakpoint
+	layout     LayoutFunc
+}
+
+// compactTarget is a component that is compact under some breakpoints.
+type compactTarget struct {
+	component Compactable
+	names     []string
+}
+
+// Responsive picks a screen layout by the size of the terminal. Layouts are
+// registered for breakpoints; the first one whose breakpoint matches the
+// size is used, or the default layout if none does.
+//
+// To keep the layout from flapping while the terminal is resized around a
+// bound, the active breakpoint is kept until the size leaves its range by
+// more than the hysteresis, unless an earlier breakpoint matches.
+//
+// Example:
+//
+//	screen := layout.NewResponsive(wideLayout).
+//	    Add(layout.Breakpoint{Name: "short", MaxHeight: 29}, stackedLayout).
+//	    Add(layout.Breakpoint{Name: "narrow", MaxWidth: 99}, noSidebarLayout).
+//	    WithHysteresis(2)
+//	screen.Compact(sidebar, "narrow", "short")
+//
+//	// In Update
+//	screen.Update(msg)
+//
+//	// In View
+//	areas := screen.Layout(layout.NewArea(0, 0, width, height))
+type Responsive struct {
+	layouts    []responsiveLayout
+	fallback   LayoutFunc
+	hysteresis int
+	active     int
+	width      int
+	height     int
+	onChange   []func(name string)
+	compact    []compactTarget
+}
+
+// NewResponsive creates a new Responsive with the layout used when no
+// breakpoint matches.
+func NewResponsive(fallback LayoutFunc) *Responsive {
+	return &Responsive{fallback: fallback, active: -1}
+}
+
+// Add registers the layout for the breakpoint and returns the Responsive.
+// Breakpoints added first take precedence, so add the most specific first.
+func (r *Responsive) Add(breakpoint Breakpoint, layout LayoutFunc) *Responsive {
+	r.layouts = append(r.layouts, responsiveLayout{breakpoint: breakpoint, layout: layout})
+	return r
+}
+
+// WithHysteresis sets how many cells past its bounds the active breakpoint
+// is kept and returns the Responsive.
+func (r *Responsive) WithHysteresis(cells int) *Responsive {
+	r.hysteresis = max(cells, 0)
+	return r
+}
+
+// OnChange registers a function called with the name of the new
+// breakpoint, "" for the default layout, whenever it changes.
+func (r *Responsive) OnChange(fn func(name string)) {
+	r.onChange = append(r.onChange, fn)
+}
+
+// Compact puts the component in compact mode while one of the named
+// breakpoints is active, and out of it otherwise. The mode is set right
+// away and on every change.
+func (r *Responsive) Compact(component Compactable, names ...string) {
+	target := compactTarget{component: component, names: names}
+	r.compact = append(r.compact, target)
+	target.apply(r.Active())
+}
+
+// apply sets the compact mode of the target for the active breakpoint.
+func (t compactTarget) apply(active string) {
+	t.component.SetCompactMode(slices.Contains(t.names, active))
+}
+
+// Update re-evaluates the breakpoints on a render.WindowSizeMsg and reports
+// whether the active breakpoint changed.
+func (r *Responsive) Update(msg any) bool {
+	if size, ok := msg.(render.WindowSizeMsg); ok {
+		return r.Resize(size.Width, size.Height)
+	}
+	return false
+}
+
+// Resize re-evaluates the breakpoints for the size and reports whether the
+// active breakpoint changed.
+func (r *Responsive) Resize(width, height int) bool {
+	r.width, r.height = width, height
+
+	next := -1
+	for i, l := range r.layouts {
+		if l.breakpoint.Matches(width, height) {
+			next = i
+			break
+		}
+	}
+	// Keep the active breakpoint near its bounds unless a better one matches
+	if r.active >= 0 && (next < 0 || r.active < next) &&
+		r.layouts[r.active].breakpoint.matches(width, height, r.hysteresis) {
+		next = r.active
+	}
+
+	if next == r.active {
+		return false
+	}
+	r.active = next
+	name := r.Active()
+	for _, target := range r.compact {
+		target.apply(name)
+	}
+	for _, fn := range r.onChange {
+		fn(name)
+	}
+	return true
+}
+
+// Active returns the name of the active breakpoint, "" for the default
+// layout.
+func (r *Responsive) Active() string {
+	if r.active < 0 {
+		return ""
+	}
+	return r.layouts[r.active].breakpoint.Name
+}
+
+// Size returns the last size given to Resize.
+func (r *Responsive) Size() (int, int) {
+	return r.width, r.height
+}
+
+// Layout computes the areas of the active layout for the area.
+func (r *Responsive) Layout(area Area) map[string]Area {
+	fn := r.fallback
+	if r.active >= 0 {
+		fn = r.layouts[r.active].layout
+	}
+	if fn == nil {
+		return map[string]Area{}
+	}
+	return fn(area)
+}
@@ -0,0 +1,178 @@
+package layout
+
+// solve sizes children along an axis of length available. Constraints are
+// honored in order of priority:
+//
+//  1. The sizes never add up to more than available, and each stays within
+//     the child's Min and Max. When even the minimums don't fit, the last
+//     children get less than their minimum.
+//  2. Each child gets the size its Constraint prefers. Percentages are
+//     rounded so the rounding errors don't add up: three children of
+//     Percent(33), Percent(33) and Percent(34) fill any size exactly.
+//  3. Space left over goes to the growing children in proportion to their
+//     weights, up to their Max. Space missing is taken from the children
+//     that may shrink first, then from all of them, in proportion to how
+//     far each is above its Min.
+//
+// The sizes add up to available exactly unless no child grows and the
+// preferred sizes leave space over, which stays empty after the last child.
+func solve(available int, children []FlexChild) []int {
+	available = max(available, 0)
+	n := len(children)
+	sizes := make([]int, n)
+	lo := make([]int, n)
+	hi := make([]int, n)
+	weights := make([]int, n)
+
+	// Preferred sizes, rounded on the running total in hundredths
+	cumulative, rounded := 0, 0
+	for i, child := range children {
+		lo[i], hi[i] = child.bounds(available)
+		weights[i] = child.weight()
+
+		switch c := child.Constraint.(type) {
+		case Percent:
+			cumulative += available * min(max(int(c), 0), 100)
+		case nil, Grow, Fr:
+		default:
+			cumulative += c.Apply(available) * 100
+		}
+		next := (cumulative + 50) / 100
+		sizes[i] = min(max(next-rounded, lo[i]), hi[i])
+		rounded = next
+	}
+
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+
+	if total < available {
+		fill(sizes, available-total, weights, hi)
+		return sizes
+	}
+
+	// Shrink toward the minimums, shrinkable children first
+	over := total - available
+	room := make([]int, n)
+	caps := make([]int, n)
+	for pass := 0; pass < 2 && over > 0; pass++ {
+		for i, child := range children {
+			room[i] = 0
+			if pass == 1 || child.Shrink {
+				room[i] = max(sizes[i]-lo[i], 0)
+			}
+			caps[i] = room[i]
+		}
+		taken := make([]int, n)
+		over -= fill(taken, over, room, caps)
+		for i := range sizes {
+			sizes[i] -= taken[i]
+		}
+	}
+
+	// The minimums don't fit: cut from the end
+	for i := n - 1; i >= 0 && over > 0; i-- {
+		cut := min(sizes[i], over)
+		sizes[i] -= cut
+		over -= cut
+	}
+	return sizes
+}
+
+// bounds returns the smallest and largest size the child may get. Min
+// wins over a smaller Max.
+func (c FlexChild) bounds(available int) (int, int) {
+	lo, hi := max(c.Min, 0), available
+	if c.Max > 0 {
+		hi = min(hi, c.Max)
+	}
+	switch constraint := c.Constraint.(type) {
+	case MinSize:
+		lo = max(lo, constraint.Min)
+	case MaxSize:
+		if constraint.Max > 0 {
+			hi = min(hi, constraint.Max)
+		}
+	}
+	return lo, max(hi, lo)
+}
+
+// weight returns the child's share of space left over, 0 if it doesn't
+// grow.
+func (c FlexChild) weight() int {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	if fr, ok := c.Constraint.(Fr); ok {
+		return max(int(fr), 0)
+	}
+	if _, ok := c.Constraint.(Grow); ok || c.Grow {
+		return 1
+	}
+	return 0
+}
+
+// fill adds up to amount to sizes in proportion to weights, without taking
+// any size past its cap, and returns how much it added. Children that
+// reach their cap drop out and the rest is shared among the others.
+func fill(sizes []int, amount int, weights, caps []int) int {
+	active := make([]int, len(weights))
+	added := 0
+	for amount > 0 {
+		for i, w := range weights {
+			active[i] = 0
+			if w > 0 && sizes[i] < caps[i] {
+				active[i] = w
+			}
+		}
+		shares := split(amount, active)
+		given := 0
+		for i, share := range shares {
+			share = min(share, caps[i]-sizes[i])
+			if share > 0 {
+				sizes[i] += share
+				given += share
+			}
+		}
+		if given == 0 {
+			break
+		}
+		amount -= given
+		added += given
+	}
+	return added
+}
+
+// split divides amount in proportion to weights. The remainder of the
+// integer division goes to the largest fractions, earlier children first
+// on ties, so the shares add up to amount whenever a weight is positive.
+func split(amount int, weights []int) []int {
+	shares := make([]int, len(weights))
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return shares
+	}
+
+	given := 0
+	fractions := make([]int, len(weights))
+	for i, w := range weights {
+		shares[i] = amount * w / total
+		fractions[i] = amount * w % total
+		given += shares[i]
+	}
+	for ; given < amount; given++ {
+		best := -1
+		for i, f := range fractions {
+			if weights[i] > 0 && (best < 0 || f > fractions[best]) {
+				best = i
+			}
+		}
+		shares[best]++
+		fractions[best] = -1
+	}
+	return shares
+}
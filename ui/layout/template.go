@@ -0,0 +1,376 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fr is a grid track constraint that takes a share of the space left after
+// the other tracks, in proportion to the other Fr tracks: Fr(2) gets twice
+// as much as Fr(1). In a RowLayout it grows with the same weight.
+type Fr int
+
+// Apply returns all available space.
+func (f Fr) Apply(available int) int {
+	return available
+}
+
+// Auto is a grid track constraint sized to the items placed in it. When no
+// track uses Fr, Auto tracks also share the space left over.
+type Auto struct{}
+
+// Apply returns 0: Auto has no size of its own.
+func (a Auto) Apply(available int) int {
+	return 0
+}
+
+// GridTemplate describes a grid like CSS grid-template: track sizes, named
+// areas drawn as text and the gaps between tracks.
+type GridTemplate struct {
+	// Areas holds one string per row with one name per column, separated by
+	// spaces. A name repeated over a rectangle of cells defines an area; "."
+	// leaves a cell unnamed.
+	Areas []string
+	// Columns holds the column tracks. Columns the Areas use but Columns
+	// doesn't define are Fr(1).
+	Columns []Constraint
+	// Rows holds the row tracks. Rows the Areas use but Rows doesn't
+	// define are Fr(1).
+	Rows []Constraint
+	// AutoRows is the track of rows added for items that don't fit in the
+	// grid, Auto if nil.
+	AutoRows Constraint
+	// RowGaps is the number of empty rows between each row.
+	RowGaps int
+	// ColGaps is the number of empty columns between each column.
+	ColGaps int
+}
+
+// NewGridTemplate creates a new GridTemplate with the given named areas,
+// one string per row.
+//
+// Example:
+//
+//	template := layout.NewGridTemplate(
+//	    "header header",
+//	    "side   main",
+//	    "footer footer",
+//	).WithColumns(layout.Fixed(20), layout.Fr(1)).
+//	    WithRows(layout.Fixed(1), layout.Fr(1), layout.Fixed(1))
+//	areas := template.Layout(screen)
+//	// areas["main"] is right of the 20 column side bar
+func NewGridTemplate(areas ...string) GridTemplate {
+	return GridTemplate{Areas: areas}
+}
+
+// WithColumns sets the column tracks and returns the template.
+func (t GridTemplate) WithColumns(columns ...Constraint) GridTemplate {
+	t.Columns = columns
+	return t
+}
+
+// WithRows sets the row tracks and returns the template.
+func (t GridTemplate) WithRows(rows ...Constraint) GridTemplate {
+	t.Rows = rows
+	return t
+}
+
+// WithAutoRows sets the track of added rows and returns the template.
+func (t GridTemplate) WithAutoRows(track Constraint) GridTemplate {
+	t.AutoRows = track
+	return t
+}
+
+// WithRowGaps sets the row gap and returns the template.
+func (t GridTemplate) WithRowGaps(gap int) GridTemplate {
+	t.RowGaps = gap
+	return t
+}
+
+// WithColGaps sets the column gap and returns the template.
+func (t GridTemplate) WithColGaps(gap int) GridTemplate {
+	t.ColGaps = gap
+	return t
+}
+
+// GridItem is an item placed on a GridTemplate.
+type GridItem struct {
+	// Name is the key of the item's area in the result.
+	Name string
+	// Area is the named area the item fills. An item without one is
+	// placed in the next free cells, left to right and top to bottom.
+	Area string
+	// ColSpan and RowSpan are the number of cells an auto-placed item
+	// covers, 1 if not positive.
+	ColSpan int
+	RowSpan int
+	// Width and Height are the item's content size, used to size the Auto
+	// tracks it is alone in.
+	Width  int
+	Height int
+}
+
+// NewGridItem creates a new GridItem with the given name that is placed in
+// the next free cell.
+func NewGridItem(name string) GridItem {
+	return GridItem{Name: name, ColSpan: 1, RowSpan: 1}
+}
+
+// WithArea places the item in the named area and returns the item.
+func (i GridItem) WithArea(area string) GridItem {
+	i.Area = area
+	return i
+}
+
+// WithSpan sets the number of columns and rows the item covers and returns
+// the item.
+func (i GridItem) WithSpan(cols, rows int) GridItem {
+	i.ColSpan = cols
+	i.RowSpan = rows
+	return i
+}
+
+// WithSize sets the content size of the item and returns the item.
+func (i GridItem) WithSize(width, height int) GridItem {
+	i.Width = width
+	i.Height = height
+	return i
+}
+
+// cellSpan is a rectangle of grid cells, in columns and rows.
+type cellSpan struct {
+	col, row   int
+	cols, rows int
+}
+
+// Validate reports rows of Areas with a different number of cells and
+// names that don't form a rectangle. Layout ignores the broken areas.
+func (t GridTemplate) Validate() error {
+	for r, row := range t.Areas {
+		if got, want := len(strings.Fields(row)), len(strings.Fields(t.Areas[0])); got != want {
+			return fmt.Errorf("grid template row %d has %d cells, want %d", r, got, want)
+		}
+	}
+	names, _ := t.parse()
+	for name, span := range names {
+		if span == nil {
+			return fmt.Errorf("grid template area %q is not a rectangle", name)
+		}
+	}
+	return nil
+}
+
+// parse returns the named areas of the template, nil for names that don't
+// form a rectangle, and the names of the cells by row.
+func (t GridTemplate) parse() (map[string]*cellSpan, [][]string) {
+	cells := make([][]string, len(t.Areas))
+	names := make(map[string]*cellSpan)
+	for r, row := range t.Areas {
+		cells[r] = strings.Fields(row)
+		for c, name := range cells[r] {
+			if name == "." {
+				continue
+			}
+			span, seen := names[name]
+			switch {
+			case !seen:
+				names[name] = &cellSpan{col: c, row: r, cols: 1, rows: 1}
+			case span == nil:
+			default:
+				// Grow the bounding box, the check below catches holes
+				right, bottom := max(span.col+span.cols, c+1), max(span.row+span.rows, r+1)
+				span.col, span.row = min(span.col, c), min(span.row, r)
+				span.cols, span.rows = right-span.col, bottom-span.row
+			}
+		}
+	}
+
+	for name, span := range names {
+		if span == nil {
+			continue
+		}
+		for r := span.row; r < span.row+span.rows && names[name] != nil; r++ {
+			for c := span.col; c < span.col+span.cols; c++ {
+				if c >= len(cells[r]) || cells[r][c] != name {
+					names[name] = nil
+					break
+				}
+			}
+		}
+	}
+	return names, cells
+}
+
+// Layout returns the areas of the template within area, keyed by name:
+// every named area of the template, and every item by its Name.
+//
+// Items with an Area get that area. The others are auto-placed, in order,
+// at the first free cells after the previous auto-placed item, where no
+// named area or earlier item is. Rows of AutoRows are added below the
+// template when they don't fit. An item wider than the grid is cut to its
+// width.
+//
+// Fixed and Percent tracks get their size first, then Auto tracks the
+// largest Width (or Height) of the items spanning only them. Fr tracks
+// share what is left. Tracks shrink like a RowLayout when they don't fit.
+func (t GridTemplate) Layout(area Area, items ...GridItem) map[string]Area {
+	result := make(map[string]Area)
+	names, cells := t.parse()
+
+	cols := len(t.Columns)
+	for _, row := range cells {
+		cols = max(cols, len(row))
+	}
+	cols = max(cols, 1)
+	rows := max(len(t.Rows), len(cells))
+
+	// Mark the cells taken by named areas
+	var taken [][]bool
+	grow := func(n int) {
+		for len(taken) < n {
+			taken = append(taken, make([]bool, cols))
+		}
+	}
+	grow(rows)
+	for r, row := range cells {
+		for c, name := range row {
+			taken[r][c] = name != "."
+		}
+	}
+
+	// Place the items
+	spans := make([]*cellSpan, len(items))
+	col, row := 0, 0
+	for i, item := range items {
+		if item.Area != "" {
+			spans[i] = names[item.Area]
+			continue
+		}
+		span := &cellSpan{cols: min(max(item.ColSpan, 1), cols), rows: max(item.RowSpan, 1)}
+		for ; ; col, row = 0, row+1 {
+			grow(row + span.rows)
+			for ; col+span.cols <= cols; col++ {
+				if free(taken, col, row, span.cols, span.rows) {
+					break
+				}
+			}
+			if col+span.cols <= cols {
+				break
+			}
+		}
+		span.col, span.row = col, row
+		for r := row; r < row+span.rows; r++ {
+			for c := col; c < col+span.cols; c++ {
+				taken[r][c] = true
+			}
+		}
+		spans[i] = span
+		col += span.cols
+	}
+	rows = len(taken)
+
+	// Size the tracks
+	autoRows := t.AutoRows
+	if autoRows == nil {
+		autoRows = Auto{}
+	}
+	colTracks := make([]Constraint, cols)
+	rowTracks := make([]Constraint, rows)
+	for c := range colTracks {
+		colTracks[c] = track(t.Columns, c, Fr(1))
+	}
+	for r := range rowTracks {
+		fallback := Constraint(Fr(1))
+		if r >= len(cells) {
+			fallback = autoRows
+		}
+		rowTracks[r] = track(t.Rows, r, fallback)
+	}
+	colContent := make([]int, cols)
+	rowContent := make([]int, rows)
+	for i, span := range spans {
+		if span == nil {
+			continue
+		}
+		if span.cols == 1 {
+			colContent[span.col] = max(colContent[span.col], items[i].Width)
+		}
+		if span.rows == 1 {
+			rowContent[span.row] = max(rowContent[span.row], items[i].Height)
+		}
+	}
+	xs := trackOffsets(area.Rect().Min.X, area.Dx(), colTracks, colContent, t.ColGaps)
+	ys := trackOffsets(area.Rect().Min.Y, area.Dy(), rowTracks, rowContent, t.RowGaps)
+
+	spanArea := func(span *cellSpan) Area {
+		right, bottom := span.col+span.cols-1, span.row+span.rows-1
+		return NewArea(xs[span.col][0], ys[span.row][0], xs[right][1], ys[bottom][1]).Intersect(area)
+	}
+	for name, span := range names {
+		if span != nil {
+			result[name] = spanArea(span)
+		}
+	}
+	for i, item := range items {
+		if spans[i] != nil {
+			result[item.Name] = spanArea(spans[i])
+		}
+	}
+	return result
+}
+
+// GridTemplateLayout returns the areas of the template within area. See
+// GridTemplate.Layout.
+func GridTemplateLayout(area Area, template GridTemplate, items ...GridItem) map[string]Area {
+	return template.Layout(area, items...)
+}
+
+// free reports whether the cells of the span at col and row are free.
+func free(taken [][]bool, col, row, cols, rows int) bool {
+	for r := row; r < row+rows; r++ {
+		for c := col; c < col+cols; c++ {
+			if taken[r][c] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// track returns the i-th track, or fallback if there is none.
+func track(tracks []Constraint, i int, fallback Constraint) Constraint {
+	if i < len(tracks) && tracks[i] != nil {
+		return tracks[i]
+	}
+	return fallback
+}
+
+// trackOffsets sizes the tracks in length cells from start, minus the
+// gaps, and returns the start and end of each.
+func trackOffsets(start, length int, tracks []Constraint, content []int, gap int) [][2]int {
+	gap = max(gap, 0)
+	children := make([]FlexChild, len(tracks))
+	stretch := true
+	for _, t := range tracks {
+		switch t.(type) {
+		case Fr, Grow:
+			stretch = false
+		}
+	}
+	for i, t := range tracks {
+		children[i] = NewFlexChild(t)
+		if _, ok := t.(Auto); ok {
+			children[i] = NewFlexChild(Fixed(content[i]))
+			children[i].Grow = stretch
+		}
+	}
+
+	sizes := solve(length-gap*(len(tracks)-1), children)
+	offsets := make([][2]int, len(tracks))
+	pos := start
+	for i, size := range sizes {
+		offsets[i] = [2]int{pos, pos + size}
+		pos += size + gap
+	}
+	return offsets
+}
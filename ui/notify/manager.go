@@ -13,10 +13,9 @@ type tickMsg struct {
 }
 
 func tickCmd() render.Cmd {
-	return func() render.Msg {
-		time.Sleep(time.Millisecond * 100)
-		return tickMsg{time: time.Now()}
-	}
+	return render.Tick(time.Millisecond*100, func(t time.Time) render.Msg {
+		return tickMsg{time: t}
+	})
 }
 
 // Manager handles the display and lifecycle of notifications
@@ -1,9 +1,11 @@
 package render
 
 import (
-	"io"
+	"context"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 // BubbleteaEngine implements Engine using the Bubbletea framework.
@@ -11,6 +13,7 @@ type BubbleteaEngine struct {
 	program *tea.Program
 	running bool
 	config  *EngineConfig
+	cancel  context.CancelFunc
 }
 
 // NewBubbleteaEngine creates a new Bubbletea engine.
@@ -35,6 +38,9 @@ func (e *BubbleteaEngine) Start(model Model) error {
 	if e.config.EnableAltScreen {
 		opts = append(opts, tea.WithAltScreen())
 	}
+	if e.config.EnableFocusReporting {
+		opts = append(opts, tea.WithReportFocus())
+	}
 	// Note: tea.WithoutCursor() is not available in the vendored version
 	// if !e.config.EnableCursor {
 	// 	opts = append(opts, tea.WithoutCursor())
@@ -42,18 +48,27 @@ func (e *BubbleteaEngine) Start(model Model) error {
 
 	// Input/Output
 	if e.config.Input != nil {
-		if r, ok := e.config.Input.(io.Reader); ok {
-			opts = append(opts, tea.WithInput(r))
-		}
+		opts = append(opts, tea.WithInput(e.config.Input))
 	}
 	if e.config.Output != nil {
-		if w, ok := e.config.Output.(io.Writer); ok {
-			opts = append(opts, tea.WithOutput(w))
-		}
+		opts = append(opts, tea.WithOutput(e.config.Output))
 	}
+	opts = append(opts, tea.WithEnvironment(e.config.environ()))
+	// Buffers downsample their colors for the terminal we write to
+	_, out := e.config.streams()
+	profile := colorprofile.DetectOutput(out, e.config.environ())
+	// Bubbletea has its own frame limit and skips unchanged views
+	if e.config.MaxFPS > 0 {
+		opts = append(opts, tea.WithFPS(e.config.MaxFPS))
+	}
+
+	// Commands are cancelled when the engine stops
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	defer cancel()
 
 	// Wrap model
-	teaModel := &teaAdapter{internal: model}
+	teaModel := &teaAdapter{internal: model, ctx: ctx, config: e.config, profile: profile}
 
 	e.program = tea.NewProgram(teaModel, opts...)
 	e.running = true
@@ -65,6 +80,9 @@ func (e *BubbleteaEngine) Start(model Model) error {
 
 // Stop gracefully shuts down the engine.
 func (e *BubbleteaEngine) Stop() error {
+	if e.cancel != nil {
+		e.cancel()
+	}
 	if e.program != nil {
 		e.program.Quit()
 	}
@@ -97,26 +115,61 @@ func (e *BubbleteaEngine) Running() bool {
 // teaAdapter wraps our Model to satisfy tea.Model.
 type teaAdapter struct {
 	internal Model
+	ctx      context.Context
+	config   *EngineConfig
+	profile  colorprofile.Profile
 }
 
 func (m *teaAdapter) Init() tea.Cmd {
-	cmd := m.internal.Init()
-	return adaptCmd(cmd)
+	profile := ColorProfileMsg{Profile: m.profile}
+	cmd := tea.Batch(func() tea.Msg { return profile }, adaptCmd(m.ctx, m.internal.Init()))
+
+	// Bubbletea only reports the size of terminal devices; sessions over
+	// other streams start at the configured size.
+	if _, out := m.config.streams(); !isTerminal(out) && m.config.Width > 0 && m.config.Height > 0 {
+		size := tea.WindowSizeMsg{Width: m.config.Width, Height: m.config.Height}
+		cmd = tea.Batch(cmd, func() tea.Msg { return size })
+	}
+	return cmd
 }
 
 func (m *teaAdapter) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var internalMsg Msg = msg
 
-	// Convert tea.KeyMsg to render.KeyMsg
+	// QuitMsg is delivered by quit commands inside a Sequence
+	if _, ok := msg.(QuitMsg); ok {
+		return m, tea.Quit
+	}
+	switch msg := msg.(type) {
+	case PrintLineMsg:
+		return m, tea.Println(msg.Text)
+	case ExecMsg:
+		return m, tea.Exec(msg.Command, func(err error) tea.Msg { return msg.Done(err) })
+	case SuspendMsg:
+		return m, tea.Suspend
+	case tea.ResumeMsg:
+		internalMsg = ResumeMsg{}
+	case tea.FocusMsg:
+		internalMsg = FocusMsg{Focused: true}
+	case tea.BlurMsg:
+		internalMsg = FocusMsg{Focused: false}
+	case ProbeMsg:
+		// Bubbletea consumes the terminal's replies itself
+		internalMsg = CapabilitiesMsg{}
+	}
+
+	// Convert tea.KeyMsg to render.KeyMsg, or PasteMsg for pasted text
 	if key, ok := msg.(tea.KeyMsg); ok {
-		internalMsg = KeyMsg{
-			Key: key.String(),
-			// Note: We use the string representation for matching,
-			// similar to how internal/ui/list works.
-			// Detailed modifier bools are not populated here to avoid
-			// imperfect parsing, as Key.String() is the source of truth.
+		if key.Paste {
+			internalMsg = PasteMsg{Text: string(key.Runes)}
+		} else {
+			internalMsg = fromTeaKey(key)
 		}
 	}
+	// Convert tea.MouseMsg to render.MouseMsg
+	if mouse, ok := msg.(tea.MouseMsg); ok {
+		internalMsg = fromTeaMouse(mouse)
+	}
 	// Convert tea.WindowSizeMsg to render.WindowSizeMsg
 	if size, ok := msg.(tea.WindowSizeMsg); ok {
 		internalMsg = WindowSizeMsg{
@@ -127,51 +180,131 @@ func (m *teaAdapter) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	newModel, cmd := m.internal.Update(internalMsg)
 	m.internal = newModel
-	return m, adaptCmd(cmd)
+	return m, adaptCmd(m.ctx, cmd)
 }
 
+// View returns the model's view. Bubble Tea cannot move the terminal
+// cursor, so the cursor placed by a focused component is drawn as a
+// styled cell instead.
 func (m *teaAdapter) View() string {
-	return m.internal.View()
+	return DrawCursor(m.config.view(m.internal.View()))
 }
 
-// adaptCmd converts our Cmd interface to tea.Cmd.
-func adaptCmd(cmd Cmd) tea.Cmd {
-	if cmd == nil {
-		return nil
+// fromTeaKey converts a tea.KeyMsg to a render.KeyMsg.
+// Bubbletea v1 does not report key releases, repeats or the extended
+// modifiers, so only Alt, Ctrl, Shift, Code and Text are populated.
+func fromTeaKey(key tea.KeyMsg) KeyMsg {
+	if key.Type == tea.KeyRunes && !key.Paste {
+		text := string(key.Runes)
+		msg := KeyMsg{Key: text, Alt: key.Alt}
+		if len(key.Runes) == 1 {
+			msg.Code = key.Runes[0]
+		}
+		if !key.Alt {
+			msg.Text = text
+		}
+		return msg
 	}
 
-	// Check for quit command
-	if q, ok := cmd.(interface{ IsQuit() bool }); ok && q.IsQuit() {
-		return tea.Quit
+	// Named keys carry their modifiers in the type name, e.g. "ctrl+shift+up".
+	name := key.String()
+	if key.Alt {
+		name = strings.TrimPrefix(name, "alt+")
+	}
+	msg := ParseKey(name)
+	msg.Alt = key.Alt
+	if key.Alt {
+		msg.Text = ""
 	}
+	return msg
+}
 
-	// If it's a Command func (func() error), wrap it
-	if c, ok := cmd.(Command); ok {
-		return func() tea.Msg {
-			err := c.Execute()
-			if err != nil {
-				return ErrorMsg{Error: err}
-			}
-			return nil
-		}
+// fromTeaMouse converts a tea.MouseMsg to a render.MouseMsg.
+func fromTeaMouse(m tea.MouseMsg) MouseMsg {
+	msg := MouseMsg{
+		X:     m.X,
+		Y:     m.Y,
+		Alt:   m.Alt,
+		Ctrl:  m.Ctrl,
+		Shift: m.Shift,
 	}
 
-	// If it's already a tea.Cmd (func() tea.Msg), cast it
-	if c, ok := cmd.(tea.Cmd); ok {
-		return c
+	switch m.Button {
+	case tea.MouseButtonLeft:
+		msg.Button = MouseButtonLeft
+	case tea.MouseButtonMiddle:
+		msg.Button = MouseButtonMiddle
+	case tea.MouseButtonRight:
+		msg.Button = MouseButtonRight
+	case tea.MouseButtonWheelUp:
+		msg.Button = MouseButtonWheelUp
+	case tea.MouseButtonWheelDown:
+		msg.Button = MouseButtonWheelDown
+	case tea.MouseButtonWheelLeft:
+		msg.Button = MouseButtonWheelLeft
+	case tea.MouseButtonWheelRight:
+		msg.Button = MouseButtonWheelRight
+	case tea.MouseButtonBackward:
+		msg.Button = MouseButtonBackward
+	case tea.MouseButtonForward:
+		msg.Button = MouseButtonForward
 	}
 
-	// Check if it matches func() tea.Msg signature but not typed as tea.Cmd
-	if c, ok := cmd.(func() tea.Msg); ok {
-		return c
+	switch {
+	case tea.MouseEvent(m).IsWheel():
+		msg.Action = MouseWheel
+	case m.Action == tea.MouseActionRelease:
+		msg.Action = MouseRelease
+	case m.Action == tea.MouseActionMotion:
+		msg.Action = MouseMotion
+	default:
+		msg.Action = MousePress
 	}
 
-	// If it's a func() Msg, wrap it
-	if c, ok := cmd.(func() Msg); ok {
-		return func() tea.Msg {
-			return c()
+	return msg
+}
+
+// TeaCmd converts a Cmd to a tea.Cmd. It lets components written against
+// this package run inside a plain Bubbletea program.
+func TeaCmd(cmd Cmd) tea.Cmd {
+	return adaptCmd(context.Background(), cmd)
+}
+
+// adaptCmd converts our Cmd interface to tea.Cmd.
+// Batches and sequences map onto their Bubbletea counterparts; every other
+// command is resolved by the shared command runtime, so ticks stop when ctx
+// is cancelled.
+func adaptCmd(ctx context.Context, cmd Cmd) tea.Cmd {
+	switch c := cmd.(type) {
+	case nil, noneCmd:
+		return nil
+	case BatchCmd:
+		cmds := make([]tea.Cmd, 0, len(c))
+		for _, sub := range c {
+			cmds = append(cmds, adaptCmd(ctx, sub))
 		}
+		return tea.Batch(cmds...)
+	case sequenceCmd:
+		cmds := make([]tea.Cmd, 0, len(c))
+		for _, sub := range c {
+			cmds = append(cmds, adaptCmd(ctx, sub))
+		}
+		return tea.Sequence(cmds...)
+	case tea.Cmd:
+		// Already a tea.Cmd, pass it through
+		return c
+	case func() tea.Msg:
+		return c
+	case printCmd:
+		return tea.Println(string(c))
 	}
 
-	return nil
+	// Check for quit command
+	if IsQuit(cmd) {
+		return tea.Quit
+	}
+
+	return func() tea.Msg {
+		return resolve(ctx, cmd)
+	}
 }
@@ -1,21 +1,61 @@
 package render
 
 import (
+	"context"
+	"errors"
+	"io"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 // UltravioletEngine implements the Engine interface using the Ultraviolet rendering engine.
+//
+// A single goroutine started by Start owns the model: terminal events,
+// messages from Send and results of commands are queued and applied to the
+// model one at a time. Frames are drawn by a Scheduler, at most
+// EngineConfig.MaxFPS times a second and only when the view changed;
+// FrameStats reports how many were drawn and skipped.
+//
+// The engine serves the terminal behind the configured streams. A stream
+// that is not a terminal device, such as a socket or an SSH channel, is
+// treated as a session with a remote terminal: it starts at the configured
+// size, is resized with Resize, and the engine stops when its input ends.
+// Each session needs its own engine and model.
 type UltravioletEngine struct {
-	config  *EngineConfig
-	term    *uv.Terminal
-	running bool
-	model   Model
+	config *EngineConfig
+	term   screen
+	remote bool // term is a streamScreen
+	model  Model
+	cmds   *Runtime
+	frames *Scheduler
+
+	mu       sync.Mutex
+	running  bool
+	queue    []Msg         // pending messages, guarded by mu
+	notify   chan struct{} // signals that queue is non-empty
+	done     chan struct{} // closed when the engine is stopping
+	stopOnce sync.Once
+
+	profile colorprofile.Profile // color profile of the terminal
+	caps    termcap.Capabilities // answer to the last probe, owned by the loop
+	probe   *termcap.Collector   // running capability probe, owned by the loop
+	probeID int
+
+	cursorStyle int // DECSCUSR style last written, 0 for the default
 }
 
 // NewUltravioletEngine creates a new UltravioletEngine instance.
 func NewUltravioletEngine(config *EngineConfig) Engine {
+	if config == nil {
+		config = DefaultConfig()
+	}
 	return &UltravioletEngine{
 		config: config,
 	}
@@ -26,147 +66,562 @@ func (e *UltravioletEngine) Type() EngineType {
 	return EngineUltraviolet
 }
 
-// Start initializes the engine and begins the event loop.
+// Start initializes the engine and runs the event loop.
+// It blocks until Stop is called or the model returns Quit, and restores the
+// terminal before returning.
 func (e *UltravioletEngine) Start(model Model) error {
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		return errors.New("engine already running")
+	}
 	e.model = model
+	e.queue = nil
+	e.notify = make(chan struct{}, 1)
+	e.done = make(chan struct{})
+	e.stopOnce = sync.Once{}
+	e.probe = nil
+	e.cmds = NewRuntime(context.Background(), e.enqueue)
+	e.frames = NewScheduler(e.config.MaxFPS, SystemClock(), func() { e.enqueue(frameMsg{}) })
 	e.running = true
+	e.mu.Unlock()
 
-	// Initialize initialization command
-	cmd := e.model.Init()
-	if c, ok := cmd.(Command); ok {
-		if err := c.Execute(); err != nil {
-			return err
-		}
-	}
+	defer e.cmds.Stop()
+	defer e.frames.Stop()
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
 
-	// Create terminal instance
-	// UV uses standard IO by default or takes them as args
-	e.term = uv.NewTerminal(os.Stdin, os.Stdout, os.Environ())
+	in, out := e.config.streams()
+	e.remote = !isTerminal(in) && !isTerminal(out)
+	if e.remote {
+		e.term = newStreamScreen(in, out, e.config.environ(), e.config.Width, e.config.Height)
+	} else {
+		e.term = uv.NewTerminal(in, out, e.config.environ())
+	}
+	// Buffers downsample their colors for the terminal at the other end;
+	// a remote one is known by its TERM alone
+	if e.remote {
+		e.profile = colorprofile.Detect(e.config.environ())
+	} else {
+		e.profile = colorprofile.DetectOutput(out, e.config.environ())
+	}
+	e.enqueue(ColorProfileMsg{Profile: e.profile})
 
-	// Start the terminal (enters raw mode, alt screen if configured in UV, etc)
+	// Start the terminal (enters raw mode and starts reading input)
 	if err := e.term.Start(); err != nil {
 		return err
 	}
+	defer e.teardown()
 
-	// Initial render
-	e.render()
+	e.setupTerminal()
 
-	// Event loop
-	go func() {
-		for event := range e.term.Events() {
-			if !e.running {
-				break
-			}
+	go e.readEvents(e.term, e.done)
 
-			var msg Msg
+	e.cmds.Run(e.model.Init())
+	e.frames.Draw(e.model, e.render)
 
-			switch evt := event.(type) {
-			case uv.KeyPressEvent:
-				// Translate UV key event to internal KeyMsg
-				// Note: UV KeyPressEvent might need adaptation to our KeyMsg struct
-				// For now, we use the String() representation which is usually enough
-				msg = KeyMsg{
-					Key: evt.String(),
-				}
-			case uv.WindowSizeEvent:
-				msg = WindowSizeMsg{
-					Width:  evt.Width,
-					Height: evt.Height,
+	for {
+		select {
+		case <-e.done:
+			return nil
+		case <-e.notify:
+			for _, msg := range e.drain() {
+				if !e.update(msg) {
+					// Flush lines printed just before quitting
+					e.frames.Draw(e.model, e.render)
+					return nil
 				}
-			case error:
-				// Handle errors
-				continue
 			}
+			if e.frames.Due() {
+				e.frames.Draw(e.model, e.render)
+			}
+		}
+	}
+}
+
+// setupTerminal applies the engine configuration to a started terminal.
+func (e *UltravioletEngine) setupTerminal() {
+	if e.config.EnableAltScreen {
+		e.term.EnterAltScreen()
+	} else {
+		e.term.ExitAltScreen()
+	}
+	if e.config.EnableCursor {
+		e.term.ShowCursor()
+	} else {
+		e.term.HideCursor()
+	}
+
+	// Pasted text arrives as a single PasteMsg
+	e.term.WriteString(ansi.SetModeBracketedPaste)
+
+	if e.config.EnableFocusReporting {
+		e.term.WriteString(ansi.SetModeFocusEvent)
+	}
+
+	// Enable cell-motion mouse tracking with SGR extended coordinates
+	if e.config.EnableMouse {
+		e.term.WriteString(ansi.SetModeMouseButtonEvent + ansi.SetModeMouseExtSgr)
+	}
+
+	// Request the kitty keyboard protocol; the terminal answers with its
+	// enabled flags, which arrive as a KeyboardEnhancementsMsg.
+	if e.config.EnableKeyboardEnhancements {
+		e.term.WriteString(ansi.PushKittyKeyboard(e.keyboardFlags()) + ansi.RequestKittyKeyboard)
+	}
+}
+
+// resetModes resets the modes enabled in setupTerminal.
+func (e *UltravioletEngine) resetModes() {
+	e.term.WriteString(ansi.ResetModeBracketedPaste)
+	if e.config.EnableFocusReporting {
+		e.term.WriteString(ansi.ResetModeFocusEvent)
+	}
+	if e.cursorStyle != 0 {
+		e.term.WriteString(ansi.SetCursorStyle(0))
+		e.cursorStyle = 0
+	}
+	if e.config.EnableMouse {
+		e.term.WriteString(ansi.ResetModeMouseButtonEvent + ansi.ResetModeMouseExtSgr)
+	}
+	if e.config.EnableKeyboardEnhancements {
+		e.term.WriteString(ansi.PopKittyKeyboard(1))
+	}
+}
+
+// teardown resets the modes enabled in setupTerminal and restores the
+// terminal to its original state.
+func (e *UltravioletEngine) teardown() {
+	e.resetModes()
+	_ = e.term.Teardown()
+}
+
+// releaseTerminal hands the terminal back to the shell: it leaves raw mode
+// and the alternate screen and stops reading input.
+func (e *UltravioletEngine) releaseTerminal() error {
+	e.resetModes()
+	return e.term.Pause()
+}
+
+// restoreTerminal takes the terminal back after releaseTerminal. The
+// screen is redrawn from scratch by the next render, since whatever ran in
+// the meantime has overwritten it.
+func (e *UltravioletEngine) restoreTerminal() error {
+	if err := e.term.Resume(); err != nil {
+		return err
+	}
+	e.setupTerminal()
+	e.term.Erase()
+	e.frames.Invalidate()
+	return nil
+}
+
+// exec runs an external command with the terminal released.
+func (e *UltravioletEngine) exec(msg ExecMsg) {
+	if err := e.releaseTerminal(); err != nil {
+		if reply := msg.Done(err); reply != nil {
+			e.enqueue(reply)
+		}
+		return
+	}
+
+	in, out := e.config.streams()
+	var stderr io.Writer = os.Stderr
+	if e.remote {
+		stderr = out
+	}
+	reply := msg.Run(in, out, stderr)
+	if err := e.restoreTerminal(); err != nil {
+		reply = ErrorMsg{Error: err}
+	}
+	if reply != nil {
+		e.enqueue(reply)
+	}
+}
 
-			if msg != nil {
-				e.update(msg)
+// suspend stops the process until it is resumed by the shell. Remote
+// sessions share the process with others, so they resume at once.
+func (e *UltravioletEngine) suspend() {
+	if e.remote {
+		e.enqueue(ResumeMsg{})
+		return
+	}
+	if err := e.releaseTerminal(); err != nil {
+		e.enqueue(ErrorMsg{Error: err})
+		return
+	}
+	_ = uv.Suspend()
+	if err := e.restoreTerminal(); err != nil {
+		e.enqueue(ErrorMsg{Error: err})
+		return
+	}
+	e.enqueue(ResumeMsg{})
+}
+
+// startProbe writes the capability queries to the terminal. The replies
+// come back through readEvents; the probe ends when the terminal has
+// answered them all or the timeout expires. A probe already running is
+// left to finish.
+func (e *UltravioletEngine) startProbe(timeout time.Duration) {
+	if e.probe != nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = termcap.DefaultTimeout
+	}
+	e.probe = &termcap.Collector{}
+	e.probeID++
+	id := e.probeID
+	e.term.WriteString(termcap.Query)
+	// The query goes out with the next frame
+	e.frames.Invalidate()
+	time.AfterFunc(timeout, func() { e.enqueue(probeTimeoutMsg{id: id}) })
+}
+
+// finishProbe keeps what the terminal answered and reports it to the
+// model. If the terminal answered nothing, the answer to an earlier probe
+// is reported again.
+func (e *UltravioletEngine) finishProbe() {
+	if caps := e.probe.Capabilities(); caps.Probed {
+		e.caps = caps
+	}
+	e.probe = nil
+	e.enqueue(CapabilitiesMsg{Capabilities: e.caps})
+}
+
+// readEvents translates terminal events and queues them until done is
+// closed. The end of a remote session's input stops the engine.
+func (e *UltravioletEngine) readEvents(term screen, done <-chan struct{}) {
+	events := term.Events()
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-events:
+			if !ok {
+				e.enqueue(QuitMsg{})
+				return
+			}
+			// Replies to a probe are collected by the event loop
+			if termcap.IsReply(event) {
+				e.enqueue(probeReplyMsg{event: event})
+				continue
+			}
+			if msg := translateUVEvent(event); msg != nil {
+				e.enqueue(msg)
 			}
 		}
-	}()
+	}
+}
 
-	// Block until stopped (UV doesn't block main thread usually, but we need to keep alive?
-	// The Engine.Start contract in this codebase seems to imply blocking or non-blocking?
-	// Looking at adapter_tea.go (standard Bubbletea), Program.Run() blocks.
-	// So we should probably block here too to match behavior if possible, or coordinate.
-	// However, the interface says Start() error. If Bubbletea's Run() blocks, then Start() blocks.
-	// We need a way to wait.
-	
-	// For now, let's just block on a channel since we are the main driver.
-	// But wait, if we block, we can't run other logic?
-	// Standard Bubbletea Run() BLOCKS. So we should block.
-	
-	// We need a channel to signal completion.
-	// Since we are inside Start, we can just select{} or wait on a done channel.
-	
-	// Refactoring to support proper blocking:
-	<-make(chan struct{}) // Temporary: blocking forever until killed
+// translateUVEvent converts an Ultraviolet event to a message.
+// It returns nil for events the engine does not forward.
+func translateUVEvent(event uv.Event) Msg {
+	switch evt := event.(type) {
+	case uv.KeyPressEvent:
+		return fromUVKey(uv.Key(evt), KeyPress)
+	case uv.KeyReleaseEvent:
+		return fromUVKey(uv.Key(evt), KeyRelease)
+	case uv.PasteEvent:
+		return PasteMsg{Text: evt.Content}
+	case uv.KeyboardEnhancementsEvent:
+		return KeyboardEnhancementsMsg{Flags: evt.Flags}
+	case uv.FocusEvent:
+		return FocusMsg{Focused: true}
+	case uv.BlurEvent:
+		return FocusMsg{Focused: false}
+	case uv.MouseClickEvent, uv.MouseReleaseEvent, uv.MouseWheelEvent, uv.MouseMotionEvent:
+		return fromUVMouse(evt)
+	case uv.WindowSizeEvent:
+		return WindowSizeMsg{
+			Width:  evt.Width,
+			Height: evt.Height,
+		}
+	}
 	return nil
 }
 
-// update handles the model update cycle
-func (e *UltravioletEngine) update(msg Msg) {
+// ParseInput decodes raw terminal input, such as keystrokes captured in a
+// recording, into messages the way UltravioletEngine reads them from the
+// terminal. Sequences without a corresponding message are skipped.
+func ParseInput(b []byte) []Msg {
+	var decoder uv.EventDecoder
+	var msgs []Msg
+	for len(b) > 0 {
+		n, event := decoder.Decode(b)
+		if n == 0 {
+			break
+		}
+		b = b[n:]
+
+		// Bracketed paste is delivered as is up to the end marker
+		if _, ok := event.(uv.PasteStartEvent); ok {
+			text, rest, _ := strings.Cut(string(b), ansi.BracketedPasteEnd)
+			msgs = append(msgs, PasteMsg{Text: text})
+			b = []byte(rest)
+			continue
+		}
+		if msg := translateUVEvent(event); msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// enqueue adds a message to the queue and wakes up the event loop.
+func (e *UltravioletEngine) enqueue(msg Msg) {
+	e.mu.Lock()
+	e.queue = append(e.queue, msg)
+	e.mu.Unlock()
+
+	select {
+	case e.notify <- struct{}{}:
+	default:
+	}
+}
+
+// drain removes and returns all queued messages.
+func (e *UltravioletEngine) drain() []Msg {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msgs := e.queue
+	e.queue = nil
+	return msgs
+}
+
+// update applies a message to the model and runs the returned command.
+// It returns false if the engine should quit.
+func (e *UltravioletEngine) update(msg Msg) bool {
 	// Check for quit message
 	if _, ok := msg.(QuitMsg); ok {
-		e.Stop()
-		return
+		return false
+	}
+
+	switch msg := msg.(type) {
+	case frameMsg:
+		return true
+	case ExecMsg:
+		e.exec(msg)
+		return true
+	case SuspendMsg:
+		e.suspend()
+		return true
+	case ProbeMsg:
+		e.startProbe(msg.Timeout)
+		return true
+	case probeReplyMsg:
+		if e.probe != nil {
+			e.probe.Handle(msg.event)
+			if e.probe.Done() {
+				e.finishProbe()
+			}
+		}
+		return true
+	case probeTimeoutMsg:
+		if e.probe != nil && msg.id == e.probeID {
+			e.finishProbe()
+		}
+		return true
+	}
+
+	// Printed lines only show up in the normal screen's scrollback
+	if line, ok := msg.(PrintLineMsg); ok {
+		if !e.config.EnableAltScreen {
+			e.term.PrependString(line.Text)
+			e.frames.Invalidate()
+		}
+		return true
+	}
+
+	if size, ok := msg.(WindowSizeMsg); ok {
+		_ = e.term.Resize(size.Width, size.Height)
+		e.term.Erase()
+		e.frames.Invalidate()
 	}
 
 	// Update model
 	newModel, cmd := e.model.Update(msg)
-	e.model = newModel
+	if newModel != nil {
+		e.model = newModel
+	}
+	e.frames.Request()
 
 	// Check for quit command
 	if IsQuit(cmd) {
-		e.Stop()
-		return
+		return false
 	}
 
-	// Render
-	e.render()
+	e.cmds.Run(cmd)
+	return true
 }
 
-// render performs the rendering step
-func (e *UltravioletEngine) render() {
-	if e.term == nil {
+// render draws a frame of the model's view
+func (e *UltravioletEngine) render(view string) {
+	view, cursor := FindCursor(e.config.view(view))
+	// UV requires a Drawable. We use NewStyledString for text content.
+	e.term.Draw(uv.NewStyledString(view))
+	e.placeCursor(cursor)
+	_ = e.term.Display()
+}
+
+// placeCursor shows the terminal cursor where a focused component put its
+// marker, or hides it again if there is none. The cursor is moved by the
+// next Display, after the frame has been drawn.
+func (e *UltravioletEngine) placeCursor(cursor *Cursor) {
+	if cursor == nil {
+		if !e.config.EnableCursor {
+			e.term.HideCursor()
+		}
+		e.term.MoveTo(-1, -1)
 		return
 	}
-	
-	view := e.model.View()
-	// UV requires a Drawable. We use NewStyledString for text content.
-	drawable := uv.NewStyledString(view)
-	e.term.Draw(drawable)
-	e.term.Display()
+
+	e.term.ShowCursor()
+	e.term.MoveTo(cursor.X, cursor.Y)
+	if style := cursor.style(); style != e.cursorStyle {
+		e.term.WriteString(ansi.SetCursorStyle(style))
+		e.cursorStyle = style
+	}
 }
 
 // Stop gracefully shuts down the engine.
+// Start returns once the event loop has exited and the terminal is restored.
 func (e *UltravioletEngine) Stop() error {
-	e.running = false
-	if e.term != nil {
-		e.term.Stop()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return nil
 	}
-	// In a real implementation we would signal the blocking Start to return
-	os.Exit(0) // Aggressive exit for now matching behavior
+	e.stopOnce.Do(func() { close(e.done) })
 	return nil
 }
 
 // Send sends a message to the model.
+// It is safe to call from any goroutine, including from within Update.
 func (e *UltravioletEngine) Send(msg Msg) error {
-	// In UV adaptation, we'd need a way to inject messages into the event loop.
-	// Since we own the loop in Start(), we could use a channel.
-	// For now, simpler implementation.
-	if e.running {
-		e.update(msg)
+	if !e.Running() {
+		return errors.New("engine not running")
 	}
+	e.enqueue(msg)
 	return nil
 }
 
 // Resize notifies the engine of a terminal size change.
 func (e *UltravioletEngine) Resize(width, height int) error {
-	e.update(WindowSizeMsg{Width: width, Height: height})
-	return nil
+	return e.Send(WindowSizeMsg{Width: width, Height: height})
+}
+
+// FrameStats returns the frame counts of the current or last run.
+func (e *UltravioletEngine) FrameStats() FrameStats {
+	e.mu.Lock()
+	frames := e.frames
+	e.mu.Unlock()
+	if frames == nil {
+		return FrameStats{}
+	}
+	return frames.Stats()
 }
 
 // Running returns true if the engine is active.
 func (e *UltravioletEngine) Running() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	return e.running
 }
+
+// keyboardFlags returns the kitty keyboard flags requested by the config.
+func (e *UltravioletEngine) keyboardFlags() int {
+	flags := ansi.KittyDisambiguateEscapeCodes | ansi.KittyReportAlternateKeys
+	if e.config.EnableKeyReleases {
+		flags |= ansi.KittyReportEventTypes
+	}
+	return flags
+}
+
+// fromUVKey converts an Ultraviolet key to a render.KeyMsg.
+func fromUVKey(k uv.Key, typ KeyType) KeyMsg {
+	msg := KeyMsg{
+		Type:        typ,
+		Alt:         k.Mod.Contains(uv.ModAlt),
+		Ctrl:        k.Mod.Contains(uv.ModCtrl),
+		Shift:       k.Mod.Contains(uv.ModShift),
+		Meta:        k.Mod.Contains(uv.ModMeta),
+		Hyper:       k.Mod.Contains(uv.ModHyper),
+		Super:       k.Mod.Contains(uv.ModSuper),
+		ShiftedCode: k.ShiftedCode,
+		BaseCode:    k.BaseCode,
+		Repeat:      k.IsRepeat,
+	}
+
+	// Printable keys are named by the text they produce, like "A" for
+	// shift+a, so key maps written against Bubbletea keep matching.
+	if k.Text != "" && k.Text != " " && !msg.Alt && !msg.Ctrl {
+		msg.Key = k.Text
+		msg.Text = k.Text
+		msg.Code = k.Code
+		return msg
+	}
+
+	name := uv.Key{Code: k.Code, Text: k.Text}.Keystroke()
+	if name == "space" {
+		name = " "
+		if !msg.Alt && !msg.Ctrl {
+			msg.Text = " "
+		}
+	}
+	msg.Key = name
+	msg.Code = KeyCode(name)
+	return msg
+}
+
+// fromUVMouse converts an Ultraviolet mouse event to a render.MouseMsg.
+func fromUVMouse(evt uv.Event) MouseMsg {
+	var m uv.Mouse
+	var action MouseAction
+
+	switch evt := evt.(type) {
+	case uv.MouseClickEvent:
+		m, action = uv.Mouse(evt), MousePress
+	case uv.MouseReleaseEvent:
+		m, action = uv.Mouse(evt), MouseRelease
+	case uv.MouseWheelEvent:
+		m, action = uv.Mouse(evt), MouseWheel
+	case uv.MouseMotionEvent:
+		m, action = uv.Mouse(evt), MouseMotion
+	}
+
+	msg := MouseMsg{
+		X:      m.X,
+		Y:      m.Y,
+		Action: action,
+		Alt:    m.Mod.Contains(uv.ModAlt),
+		Ctrl:   m.Mod.Contains(uv.ModCtrl),
+		Shift:  m.Mod.Contains(uv.ModShift),
+	}
+
+	switch m.Button {
+	case uv.MouseLeft:
+		msg.Button = MouseButtonLeft
+	case uv.MouseMiddle:
+		msg.Button = MouseButtonMiddle
+	case uv.MouseRight:
+		msg.Button = MouseButtonRight
+	case uv.MouseWheelUp:
+		msg.Button = MouseButtonWheelUp
+	case uv.MouseWheelDown:
+		msg.Button = MouseButtonWheelDown
+	case uv.MouseWheelLeft:
+		msg.Button = MouseButtonWheelLeft
+	case uv.MouseWheelRight:
+		msg.Button = MouseButtonWheelRight
+	case uv.MouseBackward:
+		msg.Button = MouseButtonBackward
+	case uv.MouseForward:
+		msg.Button = MouseButtonForward
+	}
+
+	return msg
+}
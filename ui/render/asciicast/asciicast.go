@@ -0,0 +1,163 @@
+// Package asciicast records terminal sessions in the asciicast v2 format
+// used by asciinema, and replays recorded input into a render.Model.
+//
+// A recording is a JSON header line followed by one JSON array per event:
+//
+//	{"version": 2, "width": 80, "height": 24, "timestamp": 1704067200}
+//	[0.104, "o", "\u001b[H\u001b[2Jhello"]
+//	[1.250, "i", "q"]
+//	[2.000, "r", "100x30"]
+//
+// Recordings play back with any asciicast player, and ReplayEngine re-runs
+// their input against a model to reproduce rendering bugs.
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Version is the asciicast format version written and read by this package.
+const Version = 2
+
+// EventType identifies the kind of a recorded event.
+type EventType string
+
+const (
+	// EventOutput is data written to the terminal.
+	EventOutput EventType = "o"
+	// EventInput is data read from the terminal, i.e. keystrokes.
+	EventInput EventType = "i"
+	// EventResize is a terminal size change; Data is "COLSxROWS".
+	EventResize EventType = "r"
+	// EventMarker is a named point in the recording.
+	EventMarker EventType = "m"
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a single timed entry of a recording.
+type Event struct {
+	// Time is the number of seconds since the start of the recording.
+	Time float64
+	Type EventType
+	Data string
+}
+
+// MarshalJSON encodes the event as a [time, type, data] array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time, e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes a [time, type, data] array.
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("asciicast: event has %d fields, want 3", len(fields))
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(fields[1], &e.Type); err != nil {
+		return err
+	}
+	return json.Unmarshal(fields[2], &e.Data)
+}
+
+// Size parses the data of a resize event.
+func (e Event) Size() (width, height int, ok bool) {
+	w, h, found := strings.Cut(e.Data, "x")
+	if e.Type != EventResize || !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	return width, height, errW == nil && errH == nil
+}
+
+// Cast is a decoded recording.
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+// maxLineSize bounds a single line of a recording; full-screen redraws of
+// large terminals can be long.
+const maxLineSize = 16 << 20
+
+// Decode reads a recording.
+func Decode(r io.Reader) (*Cast, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("asciicast: empty recording")
+	}
+
+	cast := &Cast{}
+	if err := json.Unmarshal(scanner.Bytes(), &cast.Header); err != nil {
+		return nil, fmt.Errorf("asciicast: header: %w", err)
+	}
+	if cast.Header.Version != Version {
+		return nil, fmt.Errorf("asciicast: unsupported version %d", cast.Header.Version)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("asciicast: line %d: %w", line, err)
+		}
+		cast.Events = append(cast.Events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cast, nil
+}
+
+// Encode writes the recording to w.
+func (c *Cast) Encode(w io.Writer) error {
+	header := c.Header
+	header.Version = Version
+	if err := writeLine(w, header); err != nil {
+		return err
+	}
+	for _, event := range c.Events {
+		if err := writeLine(w, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLine writes v as a single line of JSON.
+func writeLine(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,256 @@
+package asciicast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/rendertest"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	cast := &Cast{
+		Header: Header{Width: 80, Height: 24, Timestamp: 1704067200, Title: "demo"},
+		Events: []Event{
+			{Time: 0.5, Type: EventOutput, Data: "\x1b[1mhello\x1b[0m"},
+			{Time: 1.25, Type: EventInput, Data: "q"},
+			{Time: 2, Type: EventResize, Data: "100x30"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cast.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != `{"version":2,"width":80,"height":24,"timestamp":1704067200,"title":"demo"}` {
+		t.Errorf("header = %s", lines[0])
+	}
+	if lines[1] != `[0.5,"o","\u001b[1mhello\u001b[0m"]` {
+		t.Errorf("event = %s", lines[1])
+	}
+
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Version != Version || got.Header.Width != 80 || got.Header.Title != "demo" {
+		t.Errorf("header = %+v", got.Header)
+	}
+	if len(got.Events) != 3 || got.Events[0] != cast.Events[0] || got.Events[2] != cast.Events[2] {
+		t.Errorf("events = %+v", got.Events)
+	}
+	if w, h, ok := got.Events[2].Size(); !ok || w != 100 || h != 30 {
+		t.Errorf("Size() = %d, %d, %v", w, h, ok)
+	}
+
+	for _, bad := range []string{"", `{"version":1,"width":80,"height":24}`, "{\"version\":2}\n[1,\"o\"]"} {
+		if _, err := Decode(strings.NewReader(bad)); err == nil {
+			t.Errorf("Decode(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, Header{Width: 40, Height: 10, Timestamp: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := rendertest.NewClock(rendertest.Epoch)
+	rec.SetClock(clock)
+
+	var screen bytes.Buffer
+	out := rec.Output(&screen)
+	in := rec.Input(strings.NewReader("ab"))
+
+	out.Write([]byte("hi "))
+	clock.Advance(1500 * time.Millisecond)
+	// A character split across writes is recorded once complete
+	out.Write([]byte("中")[:2])
+	out.Write([]byte("中")[2:])
+	p := make([]byte, 8)
+	in.Read(p)
+	clock.Advance(time.Second)
+	rec.Resize(50, 12)
+	rec.Marker("done")
+
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if screen.String() != "hi 中" {
+		t.Errorf("screen = %q", screen.String())
+	}
+
+	cast, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Time: 0, Type: EventOutput, Data: "hi "},
+		{Time: 1.5, Type: EventOutput, Data: "中"},
+		{Time: 1.5, Type: EventInput, Data: "ab"},
+		{Time: 2.5, Type: EventResize, Data: "50x12"},
+		{Time: 2.5, Type: EventMarker, Data: "done"},
+	}
+	if fmt.Sprint(cast.Events) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", cast.Events, want)
+	}
+}
+
+func TestRecorderModel(t *testing.T) {
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf, Header{Width: 40, Height: 10})
+	d := rendertest.New(rec.Model(&typingModel{}), 40, 10)
+	d.Resize(60, 20)
+
+	cast, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []string
+	for _, e := range cast.Events {
+		sizes = append(sizes, e.Data)
+	}
+	if strings.Join(sizes, " ") != "40x10 60x20" {
+		t.Errorf("resize events = %v", sizes)
+	}
+}
+
+type tickMsg struct{}
+
+// typingModel shows the typed text and a tick count, and quits on ctrl+c.
+type typingModel struct {
+	width, height int
+	text          string
+	ticks         int
+}
+
+func (m *typingModel) Init() render.Cmd {
+	return m.tick()
+}
+
+func (m *typingModel) tick() render.Cmd {
+	return render.Every(time.Second, func(time.Time) render.Msg { return tickMsg{} })
+}
+
+func (m *typingModel) Update(msg any) (render.Model, render.Cmd) {
+	switch msg := msg.(type) {
+	case render.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case render.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, render.Quit()
+		case "backspace":
+			if m.text != "" {
+				m.text = m.text[:len(m.text)-1]
+			}
+		default:
+			m.text += msg.Text
+		}
+	case render.PasteMsg:
+		m.text += msg.Text
+	case tickMsg:
+		m.ticks++
+		return m, m.tick()
+	}
+	return m, nil
+}
+
+func (m *typingModel) View() string {
+	return fmt.Sprintf("%dx%d t=%d %s", m.width, m.height, m.ticks, m.text)
+}
+
+func TestReplayEngine(t *testing.T) {
+	cast := &Cast{
+		Header: Header{Width: 80, Height: 24},
+		Events: []Event{
+			{Time: 0.1, Type: EventOutput, Data: "ignored"},
+			{Time: 0.5, Type: EventInput, Data: "hi"},
+			{Time: 2.5, Type: EventInput, Data: "x\x7f"},
+			{Time: 3, Type: EventResize, Data: "100x30"},
+			{Time: 3.5, Type: EventInput, Data: "\x1b[200~pasted\x1b[201~"},
+			{Time: 4, Type: EventInput, Data: "\x03"},
+			{Time: 5, Type: EventInput, Data: "after quit"},
+		},
+	}
+
+	var out bytes.Buffer
+	config := render.DefaultConfig()
+	config.Output = &out
+	engine := NewReplayEngine(cast, config)
+	if engine.Type() != render.EngineReplay {
+		t.Errorf("Type() = %v", engine.Type())
+	}
+	if err := engine.Start(&typingModel{}); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Running() {
+		t.Error("engine still running after replay")
+	}
+
+	var views []string
+	for _, f := range engine.Frames() {
+		views = append(views, f.View)
+	}
+	want := []string{
+		"80x24 t=0 ",
+		"80x24 t=0 hi",
+		"80x24 t=2 hi",
+		"100x30 t=3 hi",
+		"100x30 t=3 hipasted",
+		"100x30 t=4 hipasted",
+	}
+	if strings.Join(views, "|") != strings.Join(want, "|") {
+		t.Errorf("frames = %q, want %q", views, want)
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[H\x1b[2J100x30 t=4 hipasted") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	// Replays are deterministic
+	again := NewReplayEngine(cast, nil)
+	if err := again.Start(&typingModel{}); err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffFrames(engine.Frames(), again.Frames()); len(diffs) != 0 {
+		t.Errorf("replays differ: %+v", diffs)
+	}
+
+	// Registered engines read the recording from the input
+	var recording bytes.Buffer
+	cast.Header.Version = Version
+	if err := cast.Encode(&recording); err != nil {
+		t.Fatal(err)
+	}
+	created, err := render.CreateEngine(render.EngineReplay, &render.EngineConfig{Input: &recording})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := created.Start(&typingModel{}); err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffFrames(engine.Frames(), created.(*ReplayEngine).Frames()); len(diffs) != 0 {
+		t.Errorf("created engine replay differs: %+v", diffs)
+	}
+}
+
+func TestDiffFrames(t *testing.T) {
+	want := []Frame{{View: "a"}, {View: "b"}, {View: "c"}}
+	got := []Frame{{Time: 1, View: "a"}, {View: "B"}}
+
+	diffs := DiffFrames(want, got)
+	if len(diffs) != 2 {
+		t.Fatalf("diffs = %+v", diffs)
+	}
+	if diffs[0] != (FrameDiff{Index: 1, Want: "b", Got: "B"}) {
+		t.Errorf("diffs[0] = %+v", diffs[0])
+	}
+	if diffs[1] != (FrameDiff{Index: 2, Want: "c", Got: ""}) {
+		t.Errorf("diffs[1] = %+v", diffs[1])
+	}
+}
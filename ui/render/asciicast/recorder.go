@@ -0,0 +1,232 @@
+package asciicast
+
+import (
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+// Recorder writes an asciicast recording as a session runs.
+//
+// Wrap an engine's configuration before creating the engine to capture
+// everything it draws, and optionally the input it reads:
+//
+//	rec, _ := asciicast.NewRecorder(file, asciicast.Header{Width: 80, Height: 24})
+//	config := render.DefaultConfig()
+//	rec.Wrap(config, true)
+//	engine, _ := render.CreateEngine(render.EngineUltraviolet, config)
+//	engine.Start(rec.Model(model))
+//
+// Events are written immediately, so a recording of a session that crashes
+// is still readable up to the crash. A Recorder is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	clock render.Clock
+	start time.Time
+	err   error
+}
+
+// NewRecorder writes header to w and returns a recorder for the events
+// that follow. A zero Timestamp is set to the current time.
+func NewRecorder(w io.Writer, header Header) (*Recorder, error) {
+	r := &Recorder{w: w, clock: render.SystemClock()}
+	r.start = r.clock.Now()
+
+	header.Version = Version
+	if header.Timestamp == 0 {
+		header.Timestamp = r.start.Unix()
+	}
+	if err := writeLine(w, header); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetClock sets the clock used for event times and restarts timing at its
+// current time. Call it before any events are recorded.
+func (r *Recorder) SetClock(clock render.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = clock
+	r.start = clock.Now()
+}
+
+// Err returns the first error encountered while writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Record appends an event stamped with the current time.
+func (r *Recorder) Record(typ EventType, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	elapsed := r.clock.Now().Sub(r.start).Seconds()
+	event := Event{
+		Time: math.Round(elapsed*1e6) / 1e6,
+		Type: typ,
+		Data: data,
+	}
+	r.err = writeLine(r.w, event)
+}
+
+// Resize records a terminal size change.
+func (r *Recorder) Resize(width, height int) {
+	r.Record(EventResize, strconv.Itoa(width)+"x"+strconv.Itoa(height))
+}
+
+// Marker records a named marker, e.g. the step a bug report refers to.
+func (r *Recorder) Marker(label string) {
+	r.Record(EventMarker, label)
+}
+
+// Output returns a writer that passes writes to w and records them as
+// output events.
+func (r *Recorder) Output(w io.Writer) io.Writer {
+	s := &stream{rec: r, typ: EventOutput, w: w}
+	if f, ok := w.(file); ok {
+		return &fileStream{stream: s, f: f}
+	}
+	return s
+}
+
+// Input returns a reader that reads from rd and records what it reads as
+// input events.
+func (r *Recorder) Input(rd io.Reader) io.Reader {
+	s := &stream{rec: r, typ: EventInput, r: rd}
+	if f, ok := rd.(file); ok {
+		return &fileStream{stream: s, f: f}
+	}
+	return s
+}
+
+// Wrap records the output of engines created with config, and their
+// input if input is true. Unset streams default to stdin and stdout.
+func (r *Recorder) Wrap(config *render.EngineConfig, input bool) {
+	var out io.Writer = os.Stdout
+	if config.Output != nil {
+		out = config.Output
+	}
+	config.Output = r.Output(out)
+
+	if input {
+		var in io.Reader = os.Stdin
+		if config.Input != nil {
+			in = config.Input
+		}
+		config.Input = r.Input(in)
+	}
+}
+
+// Model wraps m so the window sizes it receives are recorded as resize
+// events, which lets a replay resize the model at the same points.
+func (r *Recorder) Model(m render.Model) render.Model {
+	return &recordedModel{Model: m, rec: r}
+}
+
+type recordedModel struct {
+	render.Model
+	rec *Recorder
+}
+
+func (m *recordedModel) Update(msg any) (render.Model, render.Cmd) {
+	if size, ok := msg.(render.WindowSizeMsg); ok {
+		m.rec.Resize(size.Width, size.Height)
+	}
+	next, cmd := m.Model.Update(msg)
+	if next != nil {
+		m.Model = next
+	}
+	return m, cmd
+}
+
+// stream records the data passing through a reader or writer. Event data
+// must be valid UTF-8, so a multi-byte character split across two calls
+// is held back until it is complete.
+type stream struct {
+	rec     *Recorder
+	typ     EventType
+	w       io.Writer
+	r       io.Reader
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.record(p[:n])
+	return n, err
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.record(p[:n])
+	return n, err
+}
+
+func (s *stream) record(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	data := append(s.pending, p...)
+	cut := len(data)
+	// Back up over an incomplete trailing character
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	s.pending = append([]byte(nil), data[cut:]...)
+	s.mu.Unlock()
+
+	if cut > 0 {
+		s.rec.Record(s.typ, string(data[:cut]))
+	}
+}
+
+// file is a terminal stream. Engines put files into raw mode and query
+// their size, so wrappers of files keep exposing them.
+type file interface {
+	io.ReadWriteCloser
+	Fd() uintptr
+}
+
+// fileStream records one direction of a file and passes the rest through.
+type fileStream struct {
+	*stream
+	f file
+}
+
+func (s *fileStream) Read(p []byte) (int, error) {
+	if s.stream.r != nil {
+		return s.stream.Read(p)
+	}
+	return s.f.Read(p)
+}
+
+func (s *fileStream) Write(p []byte) (int, error) {
+	if s.stream.w != nil {
+		return s.stream.Write(p)
+	}
+	return s.f.Write(p)
+}
+
+func (s *fileStream) Close() error { return s.f.Close() }
+func (s *fileStream) Fd() uintptr  { return s.f.Fd() }
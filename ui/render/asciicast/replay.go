@@ -0,0 +1,250 @@
+package asciicast
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/rendertest"
+)
+
+var _ render.Engine = (*ReplayEngine)(nil)
+
+func init() {
+	render.RegisterEngine(render.EngineReplay, func(config *render.EngineConfig) render.Engine {
+		return &ReplayEngine{input: config.Input, output: config.Output}
+	})
+}
+
+// Frame is the view of the model after a replayed event.
+type Frame struct {
+	// Time is the time of the event that produced the frame, in seconds.
+	Time float64
+	View string
+}
+
+// ReplayEngine re-runs the input of a recording against a model.
+//
+// Start runs the whole recording on the calling goroutine: the model gets
+// a WindowSizeMsg with the recorded size, then each input event is decoded
+// into key, mouse and paste messages and each resize event becomes a
+// WindowSizeMsg. The model runs on a rendertest.Driver whose clock follows
+// the event times, so a replay is deterministic and takes no real time.
+// External programs are not run again and capability probes report an
+// unprobed terminal, as the recording holds neither. The view after every
+// input or resize event is kept as a Frame, and written to the configured
+// output if there is one.
+//
+// Importing this package registers the engine as render.EngineReplay;
+// engines created with render.CreateEngine read the recording from the
+// configured Input when they start.
+type ReplayEngine struct {
+	cast   *Cast
+	input  io.Reader
+	output io.Writer
+
+	mu      sync.Mutex
+	driver  *rendertest.Driver
+	model   render.Model
+	pending []render.Msg
+	frames  []Frame
+	running bool
+}
+
+// NewReplayEngine creates an engine replaying cast. config may be nil;
+// only its Output is used.
+func NewReplayEngine(cast *Cast, config *render.EngineConfig) *ReplayEngine {
+	e := &ReplayEngine{cast: cast}
+	if config != nil {
+		e.output = config.Output
+	}
+	return e
+}
+
+// Type returns the engine type.
+func (e *ReplayEngine) Type() render.EngineType {
+	return render.EngineReplay
+}
+
+// Start replays the recording against model and returns when the
+// recording ends or the model quits.
+func (e *ReplayEngine) Start(model render.Model) error {
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		return fmt.Errorf("engine already running")
+	}
+	if e.cast == nil {
+		if e.input == nil {
+			e.mu.Unlock()
+			return errors.New("asciicast: no recording to replay")
+		}
+		cast, err := Decode(e.input)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+		e.cast = cast
+	}
+	e.model = model
+	e.pending = nil
+	e.frames = nil
+	e.running = true
+	e.mu.Unlock()
+
+	defer e.Stop()
+
+	// Ticks see the wall-clock time of the recording
+	start := time.Unix(e.cast.Header.Timestamp, 0)
+	clock := rendertest.NewClock(start)
+	d := rendertest.NewWithClock(model, e.cast.Header.Width, e.cast.Header.Height, clock)
+	e.mu.Lock()
+	e.driver = d
+	e.mu.Unlock()
+
+	if err := d.Err(); err != nil {
+		return err
+	}
+	if err := e.frame(d, 0); err != nil {
+		return err
+	}
+
+	for _, event := range e.cast.Events {
+		if d.Quit() || !e.Running() {
+			break
+		}
+		target := start.Add(time.Duration(event.Time * float64(time.Second)))
+		d.Advance(target.Sub(clock.Now()))
+		d.Send(e.takePending()...)
+
+		switch event.Type {
+		case EventInput:
+			d.Send(render.ParseInput([]byte(event.Data))...)
+		case EventResize:
+			w, h, ok := event.Size()
+			if !ok {
+				return fmt.Errorf("asciicast: invalid resize %q at %gs", event.Data, event.Time)
+			}
+			d.Resize(w, h)
+		default:
+			continue
+		}
+
+		if err := d.Err(); err != nil {
+			return err
+		}
+		if err := e.frame(d, event.Time); err != nil {
+			return err
+		}
+	}
+	return d.Err()
+}
+
+// Stop ends the replay and cancels pending commands.
+func (e *ReplayEngine) Stop() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return nil
+	}
+	e.running = false
+	if e.driver != nil {
+		e.driver.Close()
+		e.driver = nil
+	}
+	return nil
+}
+
+// Send queues a message for the model. It is applied along with the next
+// replayed event.
+func (e *ReplayEngine) Send(msg render.Msg) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return fmt.Errorf("engine not running")
+	}
+	e.pending = append(e.pending, msg)
+	return nil
+}
+
+// Resize queues a WindowSizeMsg for the model.
+func (e *ReplayEngine) Resize(width, height int) error {
+	return e.Send(render.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Running returns true while the replay is in progress.
+func (e *ReplayEngine) Running() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running
+}
+
+// Frames returns the frames produced by the replay: the initial view,
+// then one per input or resize event.
+func (e *ReplayEngine) Frames() []Frame {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Frame(nil), e.frames...)
+}
+
+// Model returns the model as of the end of the replay.
+func (e *ReplayEngine) Model() render.Model {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.model
+}
+
+// takePending returns and clears the messages queued by Send.
+func (e *ReplayEngine) takePending() []render.Msg {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	msgs := e.pending
+	e.pending = nil
+	return msgs
+}
+
+// frame records the driver's current view.
+func (e *ReplayEngine) frame(d *rendertest.Driver, t float64) error {
+	view := d.View()
+	e.mu.Lock()
+	e.model = d.Model()
+	e.frames = append(e.frames, Frame{Time: t, View: view})
+	e.mu.Unlock()
+
+	if e.output == nil {
+		return nil
+	}
+	_, err := io.WriteString(e.output, "\x1b[H\x1b[2J"+view)
+	return err
+}
+
+// FrameDiff is a frame whose view differs between two replays.
+type FrameDiff struct {
+	Index int
+	Want  string
+	Got   string
+}
+
+// DiffFrames compares two replays frame by frame, e.g. a replay of a bug
+// report against a replay after the fix. Frame times are ignored. If one
+// replay has more frames, the extra frames are compared against empty
+// views.
+func DiffFrames(want, got []Frame) []FrameDiff {
+	var diffs []FrameDiff
+	for i := 0; i < max(len(want), len(got)); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i].View
+		}
+		if i < len(got) {
+			g = got[i].View
+		}
+		if w != g {
+			diffs = append(diffs, FrameDiff{Index: i, Want: w, Got: g})
+		}
+	}
+	return diffs
+}
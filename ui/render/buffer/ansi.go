@@ -0,0 +1,290 @@
+package buffer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// WriteANSI writes a string containing ANSI escape sequences at the given
+// position, such as the output of lipgloss or a component's View.
+// SGR sequences set the style of the cells that follow and OSC 8 sequences
+// their hyperlink; other escape sequences are skipped. Newlines continue at
+// p.X on the next row and text beyond the right edge is clipped.
+// Returns the number of lines used
+func (b *Buffer) WriteANSI(p Point, s string) int {
+	if !b.Valid(p) {
+		return 0
+	}
+
+	var style Style
+	x, y := p.X, p.Y
+	linesUsed := 1
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == 0x1b:
+			n, params, final := scanEscape(s[i:])
+			switch final {
+			case 'm':
+				// An SGR reset does not end a hyperlink
+				link := style.Hyperlink
+				style = applySGR(style, params)
+				style.Hyperlink = link
+			case ']':
+				if url, ok := parseHyperlink(params); ok {
+					style.Hyperlink = url
+				}
+			}
+			i += n
+			continue
+		case c == '\n':
+			x = p.X
+			y++
+			if y >= b.height {
+				return linesUsed
+			}
+			linesUsed++
+			i++
+			continue
+		case c == '\r':
+			x = p.X
+			i++
+			continue
+		case c == '\t':
+			next := p.X + ((x-p.X)/8+1)*8
+			for x < next {
+				x += b.putGrapheme(x, y, " ", 1, style)
+			}
+			i++
+			continue
+		case c < 0x20 || c == 0x7f:
+			i++
+			continue
+		}
+
+		// Printable text up to the next control character, split into
+		// grapheme clusters
+		j := i + 1
+		for j < len(s) && s[j] >= 0x20 && s[j] != 0x7f {
+			j++
+		}
+		text := s[i:j]
+		state := -1
+		for len(text) > 0 {
+			var cluster string
+			var width int
+			cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+			x += b.putGrapheme(x, y, cluster, width, style)
+		}
+		i = j
+	}
+
+	return linesUsed
+}
+
+// scanEscape scans the escape sequence at the start of s, which begins with
+// ESC. It returns the sequence length, its parameters and its final byte.
+// For OSC and other string sequences the final byte is the introducer
+// (e.g. ']') and params holds the string content.
+func scanEscape(s string) (n int, params string, final byte) {
+	if len(s) < 2 {
+		return len(s), "", 0
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes, then a final byte
+		j := 2
+		for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+			j++
+		}
+		if j == len(s) {
+			return len(s), "", 0
+		}
+		return j + 1, s[2:j], s[j]
+	case ']', 'P', 'X', '^', '_':
+		// String sequences end with BEL or ST (ESC \)
+		for j := 2; j < len(s); j++ {
+			if s[j] == 0x07 {
+				return j + 1, s[2:j], s[1]
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2, s[2:j], s[1]
+			}
+		}
+		return len(s), s[2:], s[1]
+	default:
+		return 2, "", 0
+	}
+}
+
+// applySGR applies SGR parameters such as "1;38;5;202" to style.
+func applySGR(style Style, params string) Style {
+	if params == "" {
+		return Style{}
+	}
+
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		// Colon-separated sub-parameters, e.g. "38:2::255:0:0" or "4:3"
+		if strings.Contains(ps[i], ":") {
+			sub := strings.Split(ps[i], ":")
+			switch sub[0] {
+			case "4":
+				style = applyUnderlineStyle(style, sub[1])
+			case "38":
+				style.Foreground, _ = extendedColor(sub[1:], true)
+			case "48":
+				style.Background, _ = extendedColor(sub[1:], true)
+			case "58":
+				style.UnderlineColor, _ = extendedColor(sub[1:], true)
+			}
+			continue
+		}
+
+		code, _ := strconv.Atoi(ps[i])
+		switch {
+		case code == 0:
+			style = Style{}
+		case code == 1:
+			style.Bold = true
+		case code == 2:
+			style.Faint = true
+		case code == 3:
+			style.Italic = true
+		case code == 4:
+			style.Underline = true
+			style.UnderlineStyle = UnderlineSingle
+		case code == 5 || code == 6:
+			style.Blink = true
+		case code == 7:
+			style.Reverse = true
+		case code == 9:
+			style.Strikethrough = true
+		case code == 21:
+			style.Underline = true
+			style.UnderlineStyle = UnderlineDouble
+		case code == 22:
+			style.Bold = false
+			style.Faint = false
+		case code == 23:
+			style.Italic = false
+		case code == 24:
+			style.Underline = false
+			style.UnderlineStyle = UnderlineSingle
+		case code == 25:
+			style.Blink = false
+		case code == 27:
+			style.Reverse = false
+		case code == 29:
+			style.Strikethrough = false
+		case code >= 30 && code <= 37:
+			style.Foreground = IndexColor(code - 30)
+		case code == 38:
+			color, n := extendedColor(ps[i+1:], false)
+			style.Foreground = color
+			i += n
+		case code == 39:
+			style.Foreground = ""
+		case code >= 40 && code <= 47:
+			style.Background = IndexColor(code - 40)
+		case code == 48:
+			color, n := extendedColor(ps[i+1:], false)
+			style.Background = color
+			i += n
+		case code == 49:
+			style.Background = ""
+		case code == 58:
+			color, n := extendedColor(ps[i+1:], false)
+			style.UnderlineColor = color
+			i += n
+		case code == 59:
+			style.UnderlineColor = ""
+		case code >= 90 && code <= 97:
+			style.Foreground = IndexColor(code - 90 + 8)
+		case code >= 100 && code <= 107:
+			style.Background = IndexColor(code - 100 + 8)
+		}
+	}
+
+	return style
+}
+
+// applyUnderlineStyle applies the sub-parameter of "4:n": 0 turns the
+// underline off, 1-5 select single, double, curly, dotted or dashed.
+func applyUnderlineStyle(style Style, n string) Style {
+	switch n {
+	case "0":
+		style.Underline = false
+		style.UnderlineStyle = UnderlineSingle
+	case "2":
+		style.Underline = true
+		style.UnderlineStyle = UnderlineDouble
+	case "3":
+		style.Underline = true
+		style.UnderlineStyle = UnderlineCurly
+	case "4":
+		style.Underline = true
+		style.UnderlineStyle = UnderlineDotted
+	case "5":
+		style.Underline = true
+		style.UnderlineStyle = UnderlineDashed
+	default:
+		style.Underline = true
+		style.UnderlineStyle = UnderlineSingle
+	}
+	return style
+}
+
+// parseHyperlink parses the content of an OSC sequence as an OSC 8
+// hyperlink ("8;params;url"). An empty url ends the link.
+func parseHyperlink(osc string) (string, bool) {
+	rest, ok := strings.CutPrefix(osc, "8;")
+	if !ok {
+		return "", false
+	}
+	_, url, ok := strings.Cut(rest, ";")
+	return url, ok
+}
+
+// extendedColor parses the arguments following 38 or 48: "5;n" for a
+// palette index or "2;r;g;b" for true color. It returns the color and the
+// number of arguments consumed. colon reports whether the arguments came
+// from the colon-separated form.
+func extendedColor(args []string, colon bool) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n > 255 {
+			return "", 2
+		}
+		return IndexColor(n), 2
+	case "2":
+		rgb := args[1:]
+		// The colon form may carry a color space ID before r, g and b
+		if colon && len(rgb) == 4 {
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return "", len(args)
+		}
+		var c [3]uint8
+		for j := range c {
+			v, _ := strconv.Atoi(rgb[j])
+			c[j] = uint8(v)
+		}
+		return RGBColor(c[0], c[1], c[2]), 4
+	default:
+		return "", 1
+	}
+}
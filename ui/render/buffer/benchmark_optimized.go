@@ -1,7 +1,10 @@
 package buffer
 
 import (
+	"io"
 	"testing"
+
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 // BenchmarkRenderWithOptimizations tests the optimized rendering
@@ -31,7 +34,7 @@ func BenchmarkRenderWithOptimizations(b *testing.B) {
 func BenchmarkStyleCache(b *testing.B) {
 	style := Style{Foreground: "202", Bold: true}
 
-	cache := NewStyleCache()
+	cache := NewStyleCache(colorprofile.TrueColor)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -41,7 +44,7 @@ func BenchmarkStyleCache(b *testing.B) {
 
 // BenchmarkStyleCacheConcurrent tests concurrent style cache access
 func BenchmarkStyleCacheConcurrent(b *testing.B) {
-	cache := NewStyleCache()
+	cache := NewStyleCache(colorprofile.TrueColor)
 	style := Style{Foreground: "202", Bold: true}
 
 	b.RunParallel(func(pb *testing.PB) {
@@ -117,3 +120,46 @@ func BenchmarkRenderWithMixedStyles(b *testing.B) {
 		_ = buf.Render()
 	}
 }
+
+// benchmarkFrame fills buf with a static screen and updates a status line
+// and a counter, simulating a typical incremental frame.
+func benchmarkFrame(buf *Buffer, frame int) {
+	if frame == 0 {
+		style := Style{Foreground: "244"}
+		for y := 0; y < buf.height; y++ {
+			buf.WriteString(Point{X: 0, Y: y}, "lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod", style)
+		}
+	}
+	counter := []rune("|/-\\")
+	buf.SetCell(Point{X: 0, Y: buf.height - 1}, Cell{Char: counter[frame%len(counter)], Width: 1, Style: Style{Bold: true}})
+	buf.WriteString(Point{X: 2, Y: buf.height - 1}, "frame "+string(rune('0'+frame%10)), Style{Foreground: "2"})
+}
+
+// BenchmarkFullFrameOutput measures writing the whole grid every frame
+func BenchmarkFullFrameOutput(b *testing.B) {
+	buf := NewBuffer(80, 24)
+	var written int
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkFrame(buf, i)
+		n, _ := io.WriteString(io.Discard, "\x1b[H"+buf.Render())
+		written += n
+	}
+	b.ReportMetric(float64(written)/float64(b.N), "bytes/frame")
+}
+
+// BenchmarkDiffFrameOutput measures writing only changed cells every frame
+func BenchmarkDiffFrameOutput(b *testing.B) {
+	buf := NewBuffer(80, 24)
+	r := NewDiffRenderer(io.Discard)
+	var written int
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkFrame(buf, i)
+		n, _ := r.Render(buf)
+		written += n
+	}
+	b.ReportMetric(float64(written)/float64(b.N), "bytes/frame")
+}
@@ -2,6 +2,9 @@ package buffer
 
 import (
 	"strings"
+
+	"github.com/rivo/uniseg"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 // Point represents a coordinate in the buffer
@@ -32,13 +35,41 @@ type Style struct {
 	Italic     bool
 	Underline  bool
 	Reverse    bool
+
+	Faint         bool
+	Strikethrough bool
+	Blink         bool
+
+	// UnderlineStyle selects the underline shape when Underline is set.
+	// Terminals without support fall back to a single underline.
+	UnderlineStyle UnderlineStyle
+	// UnderlineColor colors the underline, using the same notation as
+	// Foreground.
+	UnderlineColor string
+
+	// Hyperlink makes the cell part of an OSC 8 hyperlink to this URL.
+	Hyperlink string
 }
 
+// UnderlineStyle is the shape of an underline
+type UnderlineStyle int
+
+const (
+	UnderlineSingle UnderlineStyle = iota
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
 // Cell represents a single character cell in the buffer
 // For wide characters (2 columns), the first cell has Width=2 and IsContinuation=false,
 // and the second cell has Width=0 or 1 and IsContinuation=true
 type Cell struct {
-	Char           rune
+	Char rune
+	// Grapheme holds the whole grapheme cluster when it is more than one
+	// rune (combining marks, flags, ZWJ emoji); Char is then its first rune.
+	Grapheme       string
 	Width          int
 	Style          Style
 	IsContinuation bool // true if this is the second cell of a wide character
@@ -49,6 +80,8 @@ type Buffer struct {
 	width  int
 	height int
 	cells  [][]Cell
+	// profile is the color profile Render downsamples colors to
+	profile colorprofile.Profile
 }
 
 // NewBuffer creates a new buffer with specified dimensions
@@ -77,6 +110,18 @@ func NewBuffer(width, height int) *Buffer {
 	return b
 }
 
+// SetProfile sets the color profile that Render and DiffRenderer
+// downsample colors to. Buffers start out as TrueColor; engines report the
+// profile of their terminal with render.ColorProfileMsg.
+func (b *Buffer) SetProfile(profile colorprofile.Profile) {
+	b.profile = profile
+}
+
+// Profile returns the color profile of the buffer
+func (b *Buffer) Profile() colorprofile.Profile {
+	return b.profile
+}
+
 // Size returns the buffer dimensions
 func (b *Buffer) Size() Size {
 	return Size{Width: b.width, Height: b.height}
@@ -107,6 +152,15 @@ func (b *Buffer) SetCell(p Point, cell Cell) bool {
 	return true
 }
 
+// CellAt returns the cell at p, or a blank cell if p is outside the
+// buffer.
+func (b *Buffer) CellAt(p Point) Cell {
+	if !b.Valid(p) {
+		return Cell{Char: ' ', Width: 1}
+	}
+	return b.cells[p.Y][p.X]
+}
+
 // clearCellAt clears a cell and handles wide character continuation
 // This prevents "ghost characters" when overwriting wide characters
 func (b *Buffer) clearCellAt(x, y int) {
@@ -173,44 +227,19 @@ func (b *Buffer) WriteString(p Point, text string, style Style) int {
 	x := p.X
 	y := p.Y
 	colsUsed := 0
-	textRunes := []rune(text)
+	state := -1
 
-	for _, r := range textRunes {
-		if x >= b.width {
-			break
-		}
-
-		width := 1
-		if isWideChar(r) {
-			width = 2
-			// Check if there's enough space for wide char
-			if x+1 >= b.width {
-				break
-			}
-		}
-
-		// Clear the cell first to handle continuation cells
-		b.clearCellAt(x, y)
-
-		// Set the head cell
-		b.cells[y][x] = Cell{
-			Char:           r,
-			Width:          width,
-			Style:          style,
-			IsContinuation: false,
-		}
+	for len(text) > 0 && x < b.width {
+		var cluster string
+		var width int
+		cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
 
-		// If wide character, set the continuation cell
-		if width == 2 {
-			b.clearCellAt(x+1, y)
-			b.cells[y][x+1] = Cell{
-				Char:           0,
-				Width:          0,
-				Style:          style,
-				IsContinuation: true,
-			}
+		// Check if there's enough space for wide char
+		if x+clusterWidth(width) > b.width {
+			break
 		}
 
+		width = b.putGrapheme(x, y, cluster, width, style)
 		x += width
 		colsUsed += width
 	}
@@ -234,14 +263,13 @@ func (b *Buffer) WriteStringWrapped(p Point, maxWidth int, text string, style St
 	linesUsed := 1
 	wordStart := 0
 	wordCols := 0
+	state := -1
 
-	for i, r := range text {
-		width := 1
-		if isWideChar(r) {
-			width = 2
-		}
+	for i := 0; i < len(text); {
+		cluster, _, width, newState := uniseg.FirstGraphemeClusterInString(text[i:], state)
+		state = newState
 
-		if r == ' ' || r == '\t' || r == '\n' {
+		if cluster == " " || cluster == "\t" || cluster == "\n" || cluster == "\r\n" {
 			// Write the word
 			if x+wordCols > p.X+maxWidth {
 				// Word doesn't fit, move to next line
@@ -253,36 +281,9 @@ func (b *Buffer) WriteStringWrapped(p Point, maxWidth int, text string, style St
 				}
 			}
 
-			// Write word content
-			wordRunes := []rune(text[wordStart:i])
-			for _, r := range wordRunes {
-				if x >= b.width {
-					break
-				}
-				width := cellWidthForRune(r)
-				b.clearCellAt(x, y)
-				b.cells[y][x] = Cell{
-					Char:           r,
-					Width:          width,
-					Style:          style,
-					IsContinuation: false,
-				}
-				// If wide character, set the continuation cell
-				if width == 2 {
-					if x+1 < b.width {
-						b.clearCellAt(x+1, y)
-						b.cells[y][x+1] = Cell{
-							Char:           0,
-							Width:          0,
-							Style:          style,
-							IsContinuation: true,
-						}
-					}
-				}
-				x += width
-			}
+			x = b.writeWord(x, y, text[wordStart:i], style)
 
-			if r == '\n' {
+			if cluster == "\n" || cluster == "\r\n" {
 				// Move to next line
 				x = p.X
 				y++
@@ -293,6 +294,7 @@ func (b *Buffer) WriteStringWrapped(p Point, maxWidth int, text string, style St
 			} else {
 				// Write space
 				if x < b.width {
+					b.clearCellAt(x, y)
 					b.cells[y][x] = Cell{
 						Char:  ' ',
 						Width: 1,
@@ -302,11 +304,12 @@ func (b *Buffer) WriteStringWrapped(p Point, maxWidth int, text string, style St
 				}
 			}
 
-			wordStart = i + 1
+			wordStart = i + len(cluster)
 			wordCols = 0
 		} else {
-			wordCols += width
+			wordCols += clusterWidth(width)
 		}
+		i += len(cluster)
 	}
 
 	// Write remaining word
@@ -316,39 +319,30 @@ func (b *Buffer) WriteStringWrapped(p Point, maxWidth int, text string, style St
 			y++
 			linesUsed++
 		}
-
-		wordRunes := []rune(text[wordStart:])
-		for _, r := range wordRunes {
-			if x >= b.width {
-				break
-			}
-			width := cellWidthForRune(r)
-			b.clearCellAt(x, y)
-			b.cells[y][x] = Cell{
-				Char:           r,
-				Width:          width,
-				Style:          style,
-				IsContinuation: false,
-			}
-			// If wide character, set the continuation cell
-			if width == 2 {
-				if x+1 < b.width {
-					b.clearCellAt(x+1, y)
-					b.cells[y][x+1] = Cell{
-						Char:           0,
-						Width:          0,
-						Style:          style,
-						IsContinuation: true,
-					}
-				}
-			}
-			x += width
+		if y < b.height {
+			b.writeWord(x, y, text[wordStart:], style)
 		}
 	}
 
 	return linesUsed
 }
 
+// writeWord writes word at (x, y) one grapheme cluster at a time, stopping
+// at the right edge, and returns the column after it.
+func (b *Buffer) writeWord(x, y int, word string, style Style) int {
+	state := -1
+	for len(word) > 0 && x < b.width {
+		var cluster string
+		var width int
+		cluster, word, width, state = uniseg.FirstGraphemeClusterInString(word, state)
+		if x+clusterWidth(width) > b.width {
+			break
+		}
+		x += b.putGrapheme(x, y, cluster, width, style)
+	}
+	return x
+}
+
 // WriteBuffer writes another buffer's content into this buffer
 // Returns true if successful
 func (b *Buffer) WriteBuffer(p Point, other *Buffer) bool {
@@ -391,8 +385,21 @@ func (b *Buffer) Render() string {
 func (b *Buffer) renderLineToBuilder(y int, output *strings.Builder) {
 	x := 0
 	var lastStyleStr string
+	styles := styleCache(b.profile)
+
+	var lastLink string
+
+	// Skip trailing empty cells; inner ones keep the columns aligned
+	end := b.width
+	for end > 0 {
+		cell := b.cells[y][end-1]
+		if !cell.IsContinuation && (cell.Char != ' ' || cell.Style != (Style{})) {
+			break
+		}
+		end--
+	}
 
-	for x < b.width {
+	for x < end {
 		cell := b.cells[y][x]
 
 		// Skip continuation cells (second part of wide characters)
@@ -401,14 +408,14 @@ func (b *Buffer) renderLineToBuilder(y int, output *strings.Builder) {
 			continue
 		}
 
-		// Skip empty cells
-		if cell.Char == ' ' && cell.Style.Foreground == "" {
-			x++
-			continue
+		// Hyperlinks are independent of SGR state
+		if cell.Style.Hyperlink != lastLink {
+			output.WriteString(hyperlinkSequence(cell.Style.Hyperlink))
+			lastLink = cell.Style.Hyperlink
 		}
 
 		// Use cached style string
-		styleStr := globalStyleCache.Get(cell.Style)
+		styleStr := styles.Get(cell.Style)
 
 		// Only write reset code if style changed
 		if styleStr != lastStyleStr {
@@ -421,38 +428,39 @@ func (b *Buffer) renderLineToBuilder(y int, output *strings.Builder) {
 			lastStyleStr = styleStr
 		}
 
-		output.WriteRune(cell.Char)
-		x += cell.Width
+		if cell.Grapheme != "" {
+			output.WriteString(cell.Grapheme)
+		} else {
+			output.WriteRune(cell.Char)
+		}
+		x += max(cell.Width, 1)
 	}
 
-	// Reset style at end of line
+	// Reset style and close any hyperlink at end of line
 	if lastStyleStr != "" {
 		output.WriteString("\x1b[0m")
 	}
+	if lastLink != "" {
+		output.WriteString(hyperlinkSequence(""))
+	}
+}
+
+// hyperlinkSequence returns the OSC 8 sequence starting a link to url, or
+// ending the current link if url is empty
+func hyperlinkSequence(url string) string {
+	return "\x1b]8;;" + url + "\x1b\\"
 }
 
 // isWideChar checks if a rune should be displayed with double width
 func isWideChar(r rune) bool {
-	// Simple heuristic: CJK characters are wide
-	return r >= 0x1100 &&
-		(r <= 0x115F ||
-			r == 0x2329 ||
-			r == 0x232A ||
-			(r >= 0x2E80 && r <= 0xA4CF && r != 0x303F) ||
-			(r >= 0xAC00 && r <= 0xD7A3) ||
-			(r >= 0xF900 && r <= 0xFAFF) ||
-			(r >= 0xFE10 && r <= 0xFE19) ||
-			(r >= 0xFE30 && r <= 0xFE6F) ||
-			(r >= 0xFF00 && r <= 0xFF60) ||
-			(r >= 0xFFE0 && r <= 0xFFE6) ||
-			(r >= 0x20000 && r <= 0x2FFFD) ||
-			(r >= 0x30000 && r <= 0x3FFFD))
+	return cellWidthForRune(r) == 2
 }
 
-// cellWidthForRune returns the display width of a rune
+// cellWidthForRune returns the display width of a rune on its own
 func cellWidthForRune(r rune) int {
-	if isWideChar(r) {
-		return 2
+	width := clusterWidth(uniseg.StringWidth(string(r)))
+	if width < 1 {
+		return 1
 	}
-	return 1
+	return width
 }
@@ -1,7 +1,15 @@
 package buffer
 
 import (
+	"bytes"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 func TestNewBuffer(t *testing.T) {
@@ -113,10 +121,17 @@ func TestSetCell(t *testing.T) {
 		t.Errorf("SetCell() cell char = %q, want 'X'", b.cells[5][5].Char)
 	}
 
+	if got := b.CellAt(Point{X: 5, Y: 5}); got != cell {
+		t.Errorf("CellAt() = %+v, want %+v", got, cell)
+	}
+
 	// Invalid position
 	if b.SetCell(Point{X: 20, Y: 20}, cell) {
 		t.Error("SetCell() returned true for invalid position")
 	}
+	if got := b.CellAt(Point{X: 20, Y: 20}); got.Char != ' ' || got.Width != 1 {
+		t.Errorf("CellAt() outside the buffer = %+v", got)
+	}
 }
 
 func TestFillRect(t *testing.T) {
@@ -543,6 +558,449 @@ func TestLayoutManagerSetSize(t *testing.T) {
 
 // Performance benchmarks
 
+func TestRenderKeepsInnerSpaces(t *testing.T) {
+	b := NewBuffer(10, 1)
+	b.WriteString(Point{X: 2, Y: 0}, "a b", Style{})
+
+	if got, want := b.Render(), "  a b"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestApplySGR(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  Style
+		params string
+		want   Style
+	}{
+		{"reset", Style{Bold: true, Foreground: "1"}, "0", Style{}},
+		{"empty resets", Style{Italic: true}, "", Style{}},
+		{"attributes", Style{}, "1;3;4;7", Style{Bold: true, Italic: true, Underline: true, Reverse: true}},
+		{"attributes off", Style{Bold: true, Italic: true, Underline: true, Reverse: true}, "22;23;24;27", Style{}},
+		{"basic colors", Style{}, "31;42", Style{Foreground: "1", Background: "2"}},
+		{"bright colors", Style{}, "91;103", Style{Foreground: "9", Background: "11"}},
+		{"256 colors", Style{}, "38;5;202;48;5;17", Style{Foreground: "202", Background: "17"}},
+		{"true color", Style{}, "38;2;255;128;0;1", Style{Foreground: "#ff8000", Bold: true}},
+		{"true color colon", Style{}, "48:2::0:0:255", Style{Background: "#0000ff"}},
+		{"default colors", Style{Foreground: "1", Background: "2"}, "39;49", Style{}},
+		{"extended attributes", Style{}, "2;5;9", Style{Faint: true, Blink: true, Strikethrough: true}},
+		{"extended attributes off", Style{Bold: true, Faint: true, Blink: true, Strikethrough: true}, "22;25;29", Style{}},
+		{"double underline", Style{}, "21", Style{Underline: true, UnderlineStyle: UnderlineDouble}},
+		{"curly underline", Style{}, "4:3", Style{Underline: true, UnderlineStyle: UnderlineCurly}},
+		{"underline off colon", Style{Underline: true, UnderlineStyle: UnderlineDashed}, "4:0", Style{}},
+		{"underline color", Style{}, "4;58;5;196", Style{Underline: true, UnderlineColor: "196"}},
+		{"underline color colon", Style{}, "58:2::255:0:0", Style{UnderlineColor: "#ff0000"}},
+		{"default underline color", Style{UnderlineColor: "196"}, "59", Style{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applySGR(tt.start, tt.params); got != tt.want {
+				t.Errorf("applySGR(%q) = %+v, want %+v", tt.params, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteANSI(t *testing.T) {
+	b := NewBuffer(10, 3)
+	lines := b.WriteANSI(Point{X: 1, Y: 0}, "\x1b[1;31mab\x1b[0mc\n中\x1b]8;;http://x\x07d\x1b[2Kclipped-text\nlast\nextra")
+
+	if lines != 3 {
+		t.Errorf("WriteANSI() lines = %d, want 3", lines)
+	}
+	if got := b.cells[0][1]; got.Char != 'a' || got.Style != (Style{Bold: true, Foreground: "1"}) {
+		t.Errorf("cell (1,0) = %+v, want bold red 'a'", got)
+	}
+	if got := b.cells[0][3]; got.Char != 'c' || got.Style != (Style{}) {
+		t.Errorf("cell (3,0) = %+v, want unstyled 'c'", got)
+	}
+	if got := b.cells[1][1]; got.Char != '中' || got.Width != 2 || !b.cells[1][2].IsContinuation {
+		t.Errorf("cell (1,1) = %+v, want wide char with continuation", got)
+	}
+	if got := plainText(b); got != " abc      \n 中dclippe\n last     " {
+		t.Errorf("WriteANSI() text = %q", got)
+	}
+}
+
+func TestWriteANSIHyperlink(t *testing.T) {
+	b := NewBuffer(10, 1)
+	b.WriteANSI(Point{X: 0, Y: 0}, "a\x1b]8;id=1;https://example.com\x1b\\\x1b[4mb\x1b[0mc\x1b]8;;\x1b\\d")
+
+	want := []Style{
+		{},
+		{Underline: true, Hyperlink: "https://example.com"},
+		{Hyperlink: "https://example.com"},
+		{},
+	}
+	for x, style := range want {
+		if got := b.cells[0][x].Style; got != style {
+			t.Errorf("cell (%d,0) style = %+v, want %+v", x, got, style)
+		}
+	}
+}
+
+func TestStyleCacheExtendedAttributes(t *testing.T) {
+	sc := NewStyleCache(colorprofile.TrueColor)
+	tests := []struct {
+		style Style
+		want  string
+	}{
+		{Style{Faint: true, Strikethrough: true}, "\x1b[2;9m"},
+		{Style{Blink: true}, "\x1b[5m"},
+		{Style{Underline: true, UnderlineStyle: UnderlineCurly, UnderlineColor: "#ff0000"}, "\x1b[4:3;58;2;255;0;0m"},
+		{Style{Underline: true, UnderlineColor: "196"}, "\x1b[4;58;5;196m"},
+		{Style{UnderlineColor: "196"}, ""},
+		{Style{Bold: true, Hyperlink: "https://example.com"}, "\x1b[1m"},
+	}
+
+	for _, tt := range tests {
+		if got := sc.Get(tt.style); got != tt.want {
+			t.Errorf("Get(%+v) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+
+	// Styles differing in one field never share an entry
+	if sc.Get(Style{Foreground: "1"}) == sc.Get(Style{Background: "1"}) {
+		t.Error("Get() should differ for the same foreground and background color")
+	}
+	if sc.Get(Style{Underline: true}) == sc.Get(Style{Underline: true, UnderlineStyle: UnderlineDouble}) {
+		t.Error("Get() should differ for different underline styles")
+	}
+}
+
+func TestStyleCacheColorProfile(t *testing.T) {
+	style := Style{Bold: true, Foreground: "#ff0000", Background: "202"}
+	tests := []struct {
+		profile colorprofile.Profile
+		want    string
+	}{
+		{colorprofile.TrueColor, "\x1b[1;38;2;255;0;0;48;5;202m"},
+		{colorprofile.ANSI256, "\x1b[1;38;5;196;48;5;202m"},
+		{colorprofile.ANSI, "\x1b[1;91;101m"},
+		{colorprofile.NoColor, "\x1b[1m"},
+	}
+
+	for _, tt := range tests {
+		if got := NewStyleCache(tt.profile).Get(style); got != tt.want {
+			t.Errorf("Get() with %s profile = %q, want %q", tt.profile, got, tt.want)
+		}
+
+		// Buffers render with their own profile
+		b := NewBuffer(2, 1)
+		b.SetProfile(tt.profile)
+		b.WriteString(Point{X: 0, Y: 0}, "x", style)
+		if got := b.Render(); got != tt.want+"x\x1b[0m" {
+			t.Errorf("Render() with %s profile = %q, want %q", tt.profile, got, tt.want+"x\x1b[0m")
+		}
+	}
+}
+
+func TestRenderHyperlink(t *testing.T) {
+	b := NewBuffer(10, 1)
+	b.WriteString(Point{X: 0, Y: 0}, "go", Style{Hyperlink: "https://go.dev"})
+	b.WriteString(Point{X: 3, Y: 0}, "x", Style{})
+
+	want := "\x1b]8;;https://go.dev\x1b\\go\x1b]8;;\x1b\\ x"
+	if got := b.Render(); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteStringGraphemes(t *testing.T) {
+	b := NewBuffer(12, 2)
+	text := "e\u0301👍🏽🇯🇵👨\u200d👩\u200d👧x"
+
+	if got := b.WriteString(Point{X: 0, Y: 0}, text, Style{}); got != 8 {
+		t.Errorf("WriteString() cols = %d, want 8", got)
+	}
+	want := []struct {
+		x        int
+		grapheme string
+		width    int
+	}{
+		{0, "e\u0301", 1},
+		{1, "👍🏽", 2},
+		{3, "🇯🇵", 2},
+		{5, "👨\u200d👩\u200d👧", 2},
+		{7, "", 1},
+	}
+	for _, w := range want {
+		cell := b.cells[0][w.x]
+		if cell.Grapheme != w.grapheme || cell.Width != w.width {
+			t.Errorf("cell (%d,0) = %+v, want grapheme %q width %d", w.x, cell, w.grapheme, w.width)
+		}
+	}
+	if !b.cells[0][2].IsContinuation || !b.cells[0][6].IsContinuation {
+		t.Error("wide clusters should be followed by continuation cells")
+	}
+	if got := b.Render(); got != text+"\n" {
+		t.Errorf("Render() = %q, want %q", got, text+"\n")
+	}
+
+	// ANSI text is split the same way
+	b2 := NewBuffer(12, 1)
+	b2.WriteANSI(Point{X: 0, Y: 0}, "\x1b[1m🇯🇵\x1b[0me\u0301")
+	if b2.cells[0][0].Content() != "🇯🇵" || b2.cells[0][2].Content() != "e\u0301" {
+		t.Errorf("WriteANSI() cells = %+v", b2.cells[0][:3])
+	}
+
+	if got := stringWidth(text); got != 8 {
+		t.Errorf("stringWidth() = %d, want 8", got)
+	}
+	if got := truncateString(text, 4); got != "e\u0301👍🏽" {
+		t.Errorf("truncateString() = %q, want %q", got, "e\u0301👍🏽")
+	}
+}
+
+func TestWriteStringWrappedGraphemes(t *testing.T) {
+	b := NewBuffer(6, 3)
+	lines := b.WriteStringWrapped(Point{X: 0, Y: 0}, 6, "🇯🇵🇯🇵 e\u0301e\u0301", Style{})
+
+	if lines != 2 {
+		t.Errorf("WriteStringWrapped() lines = %d, want 2", lines)
+	}
+	if got := b.cells[1][1].Content(); got != "e\u0301" {
+		t.Errorf("cell (1,1) = %q, want accented e", got)
+	}
+}
+
+func TestWriteANSIRoundTrip(t *testing.T) {
+	b := NewBuffer(20, 1)
+	in := "\x1b[1;38;5;202mhot\x1b[0m \x1b[38;2;0;128;255mcool\x1b[0m"
+	b.WriteANSI(Point{X: 0, Y: 0}, in)
+
+	out := b.Render()
+	if ansi.Strip(out) != "hot cool" {
+		t.Errorf("Render() text = %q, want %q", ansi.Strip(out), "hot cool")
+	}
+	if !strings.Contains(out, "38;5;202") || !strings.Contains(out, "38;2;0;128;255") {
+		t.Errorf("Render() = %q, want 256 and true color sequences", out)
+	}
+}
+
+func TestModelComponent(t *testing.T) {
+	model := render.NewTestModel("status")
+	mc := NewModelComponent(model)
+
+	if w, h := mc.PreferredSize(); w != len("status [80x24]") || h != 1 {
+		t.Errorf("PreferredSize() = %dx%d", w, h)
+	}
+
+	b := NewBuffer(12, 2)
+	mc.Render(b, Rect{X: 2, Y: 1, Width: 8, Height: 1})
+	if got := plainText(b); got != "            \n  status [  " {
+		t.Errorf("Render() text = %q", got)
+	}
+
+	// Styled views keep their colors
+	styled := NewModelComponent(render.NewTestModel(lipgloss.NewStyle().Bold(true).Render("x")))
+	styled.Render(b, Rect{X: 0, Y: 0, Width: 12, Height: 1})
+	if b.cells[0][0].Char != 'x' {
+		t.Errorf("styled Render() cell = %+v", b.cells[0][0])
+	}
+}
+
+// screen is a minimal terminal emulator understanding the sequences
+// DiffRenderer emits: cursor position, clear screen and SGR (ignored).
+type screen struct {
+	cells [][]rune
+	x, y  int
+}
+
+func newScreen(width, height int) *screen {
+	s := &screen{cells: make([][]rune, height)}
+	for y := range s.cells {
+		s.cells[y] = make([]rune, width)
+	}
+	s.clear()
+	return s
+}
+
+func (s *screen) clear() {
+	for y := range s.cells {
+		for x := range s.cells[y] {
+			s.cells[y][x] = ' '
+		}
+	}
+}
+
+func (s *screen) write(out string) {
+	runes := []rune(out)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != 0x1b {
+			if s.y < len(s.cells) && s.x < len(s.cells[s.y]) {
+				s.cells[s.y][s.x] = runes[i]
+				if cellWidthForRune(runes[i]) == 2 && s.x+1 < len(s.cells[s.y]) {
+					s.cells[s.y][s.x+1] = 0
+				}
+			}
+			s.x += cellWidthForRune(runes[i])
+			continue
+		}
+		// CSI: ESC [ params final
+		j := i + 2
+		for j < len(runes) && (runes[j] < 0x40 || runes[j] > 0x7e) {
+			j++
+		}
+		params := strings.Split(string(runes[i+2:j]), ";")
+		switch runes[j] {
+		case 'H':
+			s.y, s.x = 0, 0
+			if len(params) == 2 {
+				s.y, _ = strconv.Atoi(params[0])
+				s.x, _ = strconv.Atoi(params[1])
+				s.y--
+				s.x--
+			}
+		case 'J':
+			s.clear()
+		}
+		i = j
+	}
+}
+
+func (s *screen) String() string {
+	lines := make([]string, len(s.cells))
+	for y, row := range s.cells {
+		lines[y] = strings.ReplaceAll(string(row), "\x00", "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainText returns the buffer's characters without styles.
+func plainText(b *Buffer) string {
+	lines := make([]string, b.height)
+	for y := 0; y < b.height; y++ {
+		var sb strings.Builder
+		for x := 0; x < b.width; x++ {
+			if !b.cells[y][x].IsContinuation {
+				sb.WriteRune(b.cells[y][x].Char)
+			}
+		}
+		lines[y] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDiffRenderer(t *testing.T) {
+	t.Run("FullThenDiff", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		b := NewBuffer(20, 5)
+		b.WriteString(Point{X: 0, Y: 0}, "hello", Style{Bold: true})
+
+		if _, err := r.Render(b); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if !strings.Contains(out.String(), "\x1b[2J") {
+			t.Errorf("first frame should clear the screen, got %q", out.String())
+		}
+
+		out.Reset()
+		n, err := r.Render(b)
+		if err != nil || n != 0 || out.Len() != 0 {
+			t.Errorf("unchanged frame wrote %d bytes (%q), err = %v", n, out.String(), err)
+		}
+
+		b.SetCell(Point{X: 4, Y: 2}, Cell{Char: 'x', Width: 1})
+		out.Reset()
+		r.Render(b)
+		if got, want := out.String(), "\x1b[3;5Hx"; got != want {
+			t.Errorf("single cell change = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("StyleChange", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		b := NewBuffer(10, 1)
+		b.WriteString(Point{X: 0, Y: 0}, "ab", Style{})
+		r.Render(b)
+
+		b.WriteString(Point{X: 1, Y: 0}, "b", Style{Foreground: "1"})
+		out.Reset()
+		r.Render(b)
+		if got, want := out.String(), "\x1b[1;2H\x1b[0m\x1b[31mb\x1b[0m"; got != want {
+			t.Errorf("style change = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Hyperlink", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		b := NewBuffer(10, 1)
+		r.Render(b)
+
+		b.WriteString(Point{X: 2, Y: 0}, "ok", Style{Hyperlink: "https://go.dev"})
+		out.Reset()
+		r.Render(b)
+		if got, want := out.String(), "\x1b[1;3H\x1b]8;;https://go.dev\x1b\\ok\x1b]8;;\x1b\\"; got != want {
+			t.Errorf("hyperlink = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ResizeRepaints", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		r.Render(NewBuffer(10, 2))
+
+		out.Reset()
+		r.Render(NewBuffer(12, 2))
+		if !strings.Contains(out.String(), "\x1b[2J") {
+			t.Errorf("resized frame should repaint, got %q", out.String())
+		}
+
+		out.Reset()
+		r.Invalidate()
+		r.Render(NewBuffer(12, 2))
+		if !strings.Contains(out.String(), "\x1b[2J") {
+			t.Errorf("invalidated frame should repaint, got %q", out.String())
+		}
+	})
+
+	t.Run("ProfileRepaints", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		b := NewBuffer(10, 1)
+		b.WriteString(Point{X: 0, Y: 0}, "ab", Style{Foreground: "#ff0000"})
+		r.Render(b)
+
+		b.SetProfile(colorprofile.ANSI)
+		out.Reset()
+		r.Render(b)
+		if got := out.String(); !strings.Contains(got, "\x1b[2J") || !strings.Contains(got, "\x1b[91mab") {
+			t.Errorf("frame with a new profile should repaint downsampled, got %q", got)
+		}
+	})
+
+	t.Run("MatchesBuffer", func(t *testing.T) {
+		var out bytes.Buffer
+		r := NewDiffRenderer(&out)
+		scr := newScreen(30, 6)
+		b := NewBuffer(30, 6)
+
+		frames := []func(){
+			func() { b.WriteString(Point{X: 0, Y: 0}, "status: idle", Style{}) },
+			func() { b.WriteString(Point{X: 8, Y: 0}, "busy", Style{Foreground: "32"}) },
+			func() { b.WriteString(Point{X: 2, Y: 3}, "中文 text", Style{}) },
+			func() { b.WriteString(Point{X: 3, Y: 3}, "ab", Style{}) },
+			func() { b.FillRect(Rect{X: 0, Y: 4, Width: 30, Height: 2}, '-', Style{Reverse: true}) },
+			func() { b.WriteString(Point{X: 0, Y: 5}, "end", Style{}) },
+		}
+		for i, frame := range frames {
+			frame()
+			out.Reset()
+			r.Render(b)
+			scr.write(out.String())
+			if got, want := scr.String(), plainText(b); got != want {
+				t.Fatalf("frame %d: screen =\n%s\nwant\n%s", i, got, want)
+			}
+		}
+	})
+}
+
 func BenchmarkFillRect(b *testing.B) {
 	style := Style{}
 	buf := NewBuffer(100, 100)
@@ -3,24 +3,31 @@ package buffer
 import (
 	"strings"
 	"sync"
+
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 // StyleCache caches ANSI escape sequences for styles
+// Colors are downsampled to the cache's color profile.
 type StyleCache struct {
-	cache map[uint32]string
-	mu    sync.RWMutex
+	cache   map[Style]string
+	profile colorprofile.Profile
+	mu      sync.RWMutex
 }
 
-// NewStyleCache creates a new style cache
-func NewStyleCache() *StyleCache {
+// NewStyleCache creates a new style cache for the given color profile
+func NewStyleCache(profile colorprofile.Profile) *StyleCache {
 	return &StyleCache{
-		cache: make(map[uint32]string, 64),
+		cache:   make(map[Style]string, 64),
+		profile: profile,
 	}
 }
 
 // Get retrieves cached style string
 func (sc *StyleCache) Get(s Style) string {
-	key := sc.styleToKey(s)
+	// Hyperlink is not emitted as SGR, so links share their style's entry
+	key := s
+	key.Hyperlink = ""
 
 	sc.mu.RLock()
 	cached, exists := sc.cache[key]
@@ -31,7 +38,7 @@ func (sc *StyleCache) Get(s Style) string {
 	}
 
 	// Build and cache
-	styleStr := sc.buildStyle(s)
+	styleStr := sc.buildStyle(s, sc.profile)
 	sc.mu.Lock()
 	sc.cache[key] = styleStr
 	sc.mu.Unlock()
@@ -39,67 +46,54 @@ func (sc *StyleCache) Get(s Style) string {
 	return styleStr
 }
 
-// styleToKey converts style to a unique uint32 key
-func (sc *StyleCache) styleToKey(s Style) uint32 {
-	var key uint32
-
-	if s.Bold {
-		key |= 1 << 0
-	}
-	if s.Italic {
-		key |= 1 << 1
-	}
-	if s.Underline {
-		key |= 1 << 2
-	}
-	if s.Reverse {
-		key |= 1 << 3
-	}
-
-	// Simple hash of foreground/background (since they're strings)
-	if s.Foreground != "" {
-		key = key*31 + uint32(len(s.Foreground))
-		for i, c := range s.Foreground {
-			key = key*31 + uint32(c)*uint32(i+1)
-		}
-	}
-	if s.Background != "" {
-		key = key*31 + uint32(len(s.Background))
-		for i, c := range s.Background {
-			key = key*31 + uint32(c)*uint32(i+1)
-		}
-	}
-
-	return key
-}
-
-// buildStyle builds ANSI style string from style
-func (sc *StyleCache) buildStyle(s Style) string {
-	if !s.Bold && !s.Italic && !s.Underline && !s.Reverse &&
-		s.Foreground == "" && s.Background == "" {
-		return ""
-	}
-
+// buildStyle builds ANSI style string from style, with colors converted
+// to profile
+func (sc *StyleCache) buildStyle(s Style, profile colorprofile.Profile) string {
 	var styles []string
 
 	if s.Bold {
 		styles = append(styles, "1")
 	}
+	if s.Faint {
+		styles = append(styles, "2")
+	}
 	if s.Italic {
 		styles = append(styles, "3")
 	}
 	if s.Underline {
-		styles = append(styles, "4")
+		switch s.UnderlineStyle {
+		case UnderlineDouble:
+			styles = append(styles, "4:2")
+		case UnderlineCurly:
+			styles = append(styles, "4:3")
+		case UnderlineDotted:
+			styles = append(styles, "4:4")
+		case UnderlineDashed:
+			styles = append(styles, "4:5")
+		default:
+			styles = append(styles, "4")
+		}
+	}
+	if s.Blink {
+		styles = append(styles, "5")
 	}
 	if s.Reverse {
 		styles = append(styles, "7")
 	}
+	if s.Strikethrough {
+		styles = append(styles, "9")
+	}
 
-	if s.Foreground != "" {
-		styles = append(styles, s.Foreground)
+	if fg := colorParams(profile.Convert(s.Foreground), true); fg != "" {
+		styles = append(styles, fg)
 	}
-	if s.Background != "" {
-		styles = append(styles, s.Background)
+	if bg := colorParams(profile.Convert(s.Background), false); bg != "" {
+		styles = append(styles, bg)
+	}
+	if s.Underline {
+		if ul := underlineColorParams(profile.Convert(s.UnderlineColor)); ul != "" {
+			styles = append(styles, ul)
+		}
 	}
 
 	if len(styles) > 0 {
@@ -109,5 +103,18 @@ func (sc *StyleCache) buildStyle(s Style) string {
 	return ""
 }
 
-// Global style cache instance
-var globalStyleCache = NewStyleCache()
+// Shared style caches, one per color profile
+var styleCaches = [...]*StyleCache{
+	colorprofile.TrueColor: NewStyleCache(colorprofile.TrueColor),
+	colorprofile.ANSI256:   NewStyleCache(colorprofile.ANSI256),
+	colorprofile.ANSI:      NewStyleCache(colorprofile.ANSI),
+	colorprofile.NoColor:   NewStyleCache(colorprofile.NoColor),
+}
+
+// styleCache returns the shared style cache for profile
+func styleCache(profile colorprofile.Profile) *StyleCache {
+	if profile < 0 || int(profile) >= len(styleCaches) {
+		profile = colorprofile.TrueColor
+	}
+	return styleCaches[profile]
+}
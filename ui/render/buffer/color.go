@@ -0,0 +1,96 @@
+package buffer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Colors in Style.Foreground and Style.Background use the same notation as
+// lipgloss colors:
+//
+//	""         terminal default
+//	"0"-"255"  ANSI palette index; 0-15 are the basic and bright colors
+//	"#rrggbb"  true color ("#rgb" is also accepted)
+//
+// Any other value is treated as raw SGR parameters, e.g. "38;5;244", and is
+// emitted unchanged.
+
+// IndexColor returns the color string for an ANSI palette index.
+func IndexColor(n int) string {
+	return strconv.Itoa(n)
+}
+
+// RGBColor returns the color string for a true color.
+func RGBColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// colorParams returns the SGR parameters selecting c as the foreground
+// (fg) or background color. It returns "" for the default color.
+func colorParams(c string, fg bool) string {
+	if c == "" {
+		return ""
+	}
+
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		switch {
+		case n < 8 && fg:
+			return strconv.Itoa(30 + n)
+		case n < 8:
+			return strconv.Itoa(40 + n)
+		case n < 16 && fg:
+			return strconv.Itoa(90 + n - 8)
+		case n < 16:
+			return strconv.Itoa(100 + n - 8)
+		case fg:
+			return "38;5;" + c
+		default:
+			return "48;5;" + c
+		}
+	}
+
+	if r, g, b, ok := parseHexColor(c); ok {
+		prefix := "48;2;"
+		if fg {
+			prefix = "38;2;"
+		}
+		return prefix + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+	}
+
+	return c
+}
+
+// underlineColorParams returns the SGR parameters selecting c as the
+// underline color, or "" for the default color.
+func underlineColorParams(c string) string {
+	if c == "" {
+		return ""
+	}
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		return "58;5;" + c
+	}
+	if r, g, b, ok := parseHexColor(c); ok {
+		return "58;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+	}
+	return c
+}
+
+// parseHexColor parses "#rrggbb" or "#rgb".
+func parseHexColor(c string) (r, g, b uint8, ok bool) {
+	if !strings.HasPrefix(c, "#") {
+		return 0, 0, 0, false
+	}
+	hex := c[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
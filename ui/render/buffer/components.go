@@ -3,6 +3,9 @@ package buffer
 import (
 	"fmt"
 	"strings"
+
+	"github.com/rivo/uniseg"
+	"github.com/wwsheng009/taproot/ui/render"
 )
 
 // Renderable is an interface for components that can render to a buffer
@@ -235,32 +238,107 @@ func (fc *FillComponent) PreferredSize() (int, int) {
 	return 1, 1
 }
 
+// ModelComponent adapts a render.Model to a Renderable, so components that
+// produce ANSI strings can be placed in buffer layouts.
+// The model's View is drawn with WriteANSI and clipped to the rect.
+type ModelComponent struct {
+	model render.Model
+}
+
+// NewModelComponent creates a component that renders model's view
+func NewModelComponent(model render.Model) *ModelComponent {
+	return &ModelComponent{model: model}
+}
+
+// Model returns the wrapped model
+func (mc *ModelComponent) Model() render.Model {
+	return mc.model
+}
+
+// SetModel replaces the wrapped model, e.g. with the one returned by Update
+func (mc *ModelComponent) SetModel(model render.Model) {
+	mc.model = model
+}
+
+// Render draws the model's view into rect
+func (mc *ModelComponent) Render(buf *Buffer, rect Rect) {
+	if mc.model == nil || rect.Width <= 0 || rect.Height <= 0 {
+		return
+	}
+
+	view := GetBuffer(rect.Width, rect.Height)
+	defer PutBuffer(view)
+
+	view.WriteANSI(Point{X: 0, Y: 0}, mc.model.View())
+	buf.WriteBuffer(Point{X: rect.X, Y: rect.Y}, view)
+}
+
+// MinSize returns the minimum size
+func (mc *ModelComponent) MinSize() (int, int) {
+	return 1, 1
+}
+
+// PreferredSize returns the size of the model's current view
+func (mc *ModelComponent) PreferredSize() (int, int) {
+	if mc.model == nil {
+		return 1, 1
+	}
+	lines := strings.Split(mc.model.View(), "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, ansiStringWidth(line))
+	}
+	return width, len(lines)
+}
+
 // Helper functions
 
-func stringWidth(s string) int {
+// ansiStringWidth returns the display width of s, ignoring escape sequences
+func ansiStringWidth(s string) int {
 	width := 0
-	for _, r := range s {
-		if isWideChar(r) {
-			width += 2
-		} else {
-			width += 1
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			n, _, _ := scanEscape(s[i:])
+			i += n
+			continue
 		}
+		j := i + 1
+		for j < len(s) && s[j] >= 0x20 && s[j] != 0x7f {
+			j++
+		}
+		width += stringWidth(s[i:j])
+		i = j
+	}
+	return width
+}
+
+// stringWidth returns the display width of s, measuring each grapheme
+// cluster as a whole
+func stringWidth(s string) int {
+	width := 0
+	state := -1
+	for len(s) > 0 {
+		var w int
+		_, s, w, state = uniseg.FirstGraphemeClusterInString(s, state)
+		width += clusterWidth(w)
 	}
 	return width
 }
 
+// truncateString cuts s to at most maxWidth columns without splitting a
+// grapheme cluster
 func truncateString(s string, maxWidth int) string {
-	r := []rune(s)
 	width := 0
-	for i, c := range r {
-		cw := 1
-		if isWideChar(c) {
-			cw = 2
-		}
-		if width+cw > maxWidth {
-			return string(r[:i])
+	state := -1
+	for rest := s; len(rest) > 0; {
+		var w int
+		var cluster string
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		w = clusterWidth(w)
+		if width+w > maxWidth {
+			return s[:len(s)-len(rest)-len(cluster)]
 		}
-		width += cw
+		width += w
 	}
 	return s
 }
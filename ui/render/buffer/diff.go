@@ -0,0 +1,178 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
+)
+
+// diffMergeGap is the longest run of unchanged cells that is rewritten
+// instead of skipped with a cursor move. A cursor move costs at least six
+// bytes ("\x1b[1;1H"), so reprinting a few cells is usually cheaper.
+const diffMergeGap = 4
+
+// DiffRenderer writes frames to an io.Writer, emitting only the cells that
+// changed since the previous frame.
+//
+// The first frame, frames with a different size or color profile and
+// frames after Invalidate clear the screen and repaint. Subsequent frames move the cursor
+// to each changed run and rewrite just those cells, which keeps output small
+// on slow links. The renderer assumes nothing else writes to the screen in
+// between frames.
+type DiffRenderer struct {
+	w    io.Writer
+	out  bytes.Buffer
+	prev [][]Cell
+
+	width   int
+	height  int
+	profile colorprofile.Profile
+	valid   bool
+
+	// Cursor position and pen after the last write, used to skip redundant
+	// cursor moves and SGR sequences. The pen's Hyperlink is unused; links
+	// are opened per run and closed before the cursor moves.
+	curX, curY int
+	pen        Style
+}
+
+// NewDiffRenderer creates a diffing renderer writing to w.
+func NewDiffRenderer(w io.Writer) *DiffRenderer {
+	return &DiffRenderer{w: w}
+}
+
+// Invalidate forces the next frame to be a full repaint, e.g. after
+// another program has drawn over the screen.
+func (r *DiffRenderer) Invalidate() {
+	r.valid = false
+}
+
+// Render writes the changes between the previous frame and buf.
+// It returns the number of bytes written; unchanged frames write nothing.
+func (r *DiffRenderer) Render(buf *Buffer) (int, error) {
+	r.out.Reset()
+
+	if !r.valid || buf.width != r.width || buf.height != r.height || buf.profile != r.profile {
+		r.reset(buf.width, buf.height)
+		r.profile = buf.profile
+		r.out.WriteString("\x1b[0m\x1b[H\x1b[2J")
+	}
+
+	for y := 0; y < buf.height; y++ {
+		r.diffLine(buf, y)
+	}
+
+	if r.pen != (Style{}) {
+		r.out.WriteString("\x1b[0m")
+		r.pen = Style{}
+	}
+
+	if r.out.Len() == 0 {
+		return 0, nil
+	}
+	return r.w.Write(r.out.Bytes())
+}
+
+// reset sizes the previous frame for a full repaint. After the screen is
+// cleared every cell is blank, so the previous frame becomes a blank grid.
+func (r *DiffRenderer) reset(width, height int) {
+	r.width = width
+	r.height = height
+	r.valid = true
+	r.curX, r.curY = -1, -1
+	r.pen = Style{}
+
+	r.prev = make([][]Cell, height)
+	for y := range r.prev {
+		r.prev[y] = make([]Cell, width)
+		for x := range r.prev[y] {
+			r.prev[y][x] = Cell{Char: ' ', Width: 1}
+		}
+	}
+}
+
+// diffLine emits the changed runs of line y and records them as drawn.
+func (r *DiffRenderer) diffLine(buf *Buffer, y int) {
+	cur := buf.cells[y]
+	prev := r.prev[y]
+
+	x := 0
+	for x < buf.width {
+		if cur[x] == prev[x] {
+			x++
+			continue
+		}
+
+		// Start at the head of a wide character
+		start := x
+		if cur[start].IsContinuation && start > 0 {
+			start--
+		}
+
+		// Extend the run over changed cells and short unchanged gaps
+		end := x + 1
+		gap := 0
+		for i := end; i < buf.width && gap <= diffMergeGap; i++ {
+			if cur[i] != prev[i] {
+				end = i + 1
+				gap = 0
+			} else {
+				gap++
+			}
+		}
+
+		r.writeRun(cur[start:end], start, y, styleCache(buf.profile))
+		copy(prev[start:end], cur[start:end])
+		x = end
+	}
+}
+
+// writeRun moves the cursor to (x, y) if needed and writes cells with
+// their styles from styles.
+func (r *DiffRenderer) writeRun(cells []Cell, x, y int, styles *StyleCache) {
+	if r.curX != x || r.curY != y {
+		r.out.WriteString("\x1b[")
+		r.out.WriteString(strconv.Itoa(y + 1))
+		r.out.WriteByte(';')
+		r.out.WriteString(strconv.Itoa(x + 1))
+		r.out.WriteByte('H')
+	}
+
+	link := ""
+	for _, cell := range cells {
+		if cell.IsContinuation {
+			continue
+		}
+
+		style := cell.Style
+		if style.Hyperlink != link {
+			r.out.WriteString(hyperlinkSequence(style.Hyperlink))
+			link = style.Hyperlink
+		}
+		style.Hyperlink = ""
+		if style != r.pen {
+			r.out.WriteString("\x1b[0m")
+			r.out.WriteString(styles.Get(style))
+			r.pen = style
+		}
+
+		if content := cell.Content(); content != "" {
+			r.out.WriteString(content)
+		} else {
+			r.out.WriteByte(' ')
+		}
+
+		width := cell.Width
+		if width < 1 {
+			width = 1
+		}
+		x += width
+	}
+	if link != "" {
+		r.out.WriteString(hyperlinkSequence(""))
+	}
+
+	r.curX, r.curY = x, y
+}
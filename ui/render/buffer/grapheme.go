@@ -0,0 +1,67 @@
+package buffer
+
+import "unicode/utf8"
+
+// Text is split into grapheme clusters, the units a user perceives as one
+// character: "e" followed by a combining accent, a flag made of two
+// regional indicators, or an emoji joined with ZWJ and skin tone modifiers.
+// Each cluster occupies one cell (two for wide clusters), so cursor
+// positions and column counts stay in step with what the terminal draws.
+
+// Content returns the text displayed by the cell.
+func (c Cell) Content() string {
+	if c.Grapheme != "" {
+		return c.Grapheme
+	}
+	if c.Char == 0 {
+		return ""
+	}
+	return string(c.Char)
+}
+
+// graphemeCell creates the head cell for cluster. Char is set to the first
+// rune; Grapheme is only set when the cluster has more than one rune, so
+// cells written from plain runes compare equal to cells set with Char.
+func graphemeCell(cluster string, width int, style Style) Cell {
+	r, size := utf8.DecodeRuneInString(cluster)
+	cell := Cell{Char: r, Width: width, Style: style}
+	if size < len(cluster) {
+		cell.Grapheme = cluster
+	}
+	return cell
+}
+
+// clusterWidth clamps a uniseg width to the cell model: clusters are
+// either one or two columns wide.
+func clusterWidth(width int) int {
+	if width > 2 {
+		return 2
+	}
+	return width
+}
+
+// putGrapheme writes cluster at (x, y) if it fits and returns its width.
+// Zero-width clusters, such as a combining mark without a base, are
+// dropped.
+func (b *Buffer) putGrapheme(x, y int, cluster string, width int, style Style) int {
+	width = clusterWidth(width)
+	if width == 0 {
+		return 0
+	}
+	if x < 0 || x+width > b.width || y < 0 || y >= b.height {
+		return width
+	}
+
+	b.clearCellAt(x, y)
+	b.cells[y][x] = graphemeCell(cluster, width, style)
+	if width == 2 {
+		b.clearCellAt(x+1, y)
+		b.cells[y][x+1] = Cell{
+			Char:           0,
+			Width:          0,
+			Style:          style,
+			IsContinuation: true,
+		}
+	}
+	return width
+}
@@ -3,6 +3,8 @@ package buffer
 import (
 	"strings"
 	"sync"
+
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
 )
 
 // BufferPool manages a pool of reusable Buffer objects
@@ -24,6 +26,7 @@ var stringBuilderPool = sync.Pool{
 // GetBuffer retrieves a buffer from the pool
 func GetBuffer(width, height int) *Buffer {
 	buf := bufferPool.Get().(*Buffer)
+	buf.profile = colorprofile.TrueColor
 
 	// Reuse or resize if needed
 	if buf.width == width && buf.height == height && len(buf.cells) == height {
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
+)
+
+// ProbeCapabilities returns a command that asks the terminal what it
+// supports. The engine writes termcap.Query to the terminal and collects
+// the replies from its input stream, so keys typed meanwhile still reach
+// the model. Once the terminal has answered, or after timeout
+// (termcap.DefaultTimeout if zero), the result is kept by the engine and
+// sent to the model as a CapabilitiesMsg.
+//
+// Engines that cannot query a terminal, such as Bubbletea, which swallows
+// the replies, answer with capabilities that are not Probed.
+// Capabilities are kept per engine, since one process may serve several
+// terminals; a program with a single terminal can share them with image
+// and clipboard code through termcap.Set.
+func ProbeCapabilities(timeout time.Duration) Cmd {
+	return func() Msg {
+		return ProbeMsg{Timeout: timeout}
+	}
+}
+
+// ProbeMsg is sent by ProbeCapabilities. Engines handle it by probing the
+// terminal; it is not passed to the model.
+type ProbeMsg struct {
+	Timeout time.Duration
+}
+
+// CapabilitiesMsg reports the result of ProbeCapabilities.
+type CapabilitiesMsg struct {
+	Capabilities termcap.Capabilities
+}
+
+// ColorProfileMsg is sent when an engine starts, with the color profile
+// of its terminal. Models that render buffers set it on them with
+// buffer.SetProfile, so their colors are downsampled for that terminal.
+type ColorProfileMsg struct {
+	Profile colorprofile.Profile
+}
+
+// probeReplyMsg carries a terminal reply to the engine's probe.
+type probeReplyMsg struct {
+	event any
+}
+
+// probeTimeoutMsg ends the probe with the given id if it is still running.
+type probeTimeoutMsg struct {
+	id int
+}
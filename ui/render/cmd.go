@@ -0,0 +1,393 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Tick returns a command that waits for d and then sends the message
+// returned by fn. fn receives the time the timer fired.
+//
+// A tick fires once. To keep ticking, return another Tick from Update when
+// the message arrives.
+func Tick(d time.Duration, fn func(time.Time) Msg) Cmd {
+	if fn == nil {
+		return nil
+	}
+	return tickCmd{d: d, fn: fn}
+}
+
+// Every is like Tick but fires in sync with the system clock, e.g. every
+// full second for d == time.Second, regardless of when it was issued.
+func Every(d time.Duration, fn func(time.Time) Msg) Cmd {
+	if fn == nil {
+		return nil
+	}
+	return tickCmd{d: d, fn: fn, every: true}
+}
+
+// Sequence returns a command that runs cmds one after another, each one
+// starting only after the previous one has delivered its message.
+// Compare with Batch, which runs commands concurrently.
+func Sequence(cmds ...Cmd) Cmd {
+	var seq sequenceCmd
+	for _, cmd := range cmds {
+		if cmd == nil || IsNone(cmd) {
+			continue
+		}
+		seq = append(seq, cmd)
+	}
+	if len(seq) == 0 {
+		return None()
+	}
+	return seq
+}
+
+// Func returns a command that runs fn in the background and sends the
+// message it returns, if any.
+func Func(fn func() Msg) Cmd {
+	if fn == nil {
+		return nil
+	}
+	return fn
+}
+
+// Println returns a command that prints a line above the live view of an
+// inline program (EnableAltScreen off). Printed lines go to the terminal's
+// scrollback and are never redrawn, so they suit finished output such as
+// the completed messages of a transcript. The arguments are formatted as
+// with fmt.Sprint. In the alternate screen the line is discarded.
+func Println(args ...any) Cmd {
+	return printCmd(fmt.Sprint(args...))
+}
+
+// Printf is like Println but formats the line as with fmt.Sprintf.
+func Printf(format string, args ...any) Cmd {
+	return printCmd(fmt.Sprintf(format, args...))
+}
+
+// PrintLineMsg is sent by Println and Printf. Engines handle it by
+// printing Text above the live view; it is not passed to the model.
+type PrintLineMsg struct {
+	Text string
+}
+
+// printCmd is the command returned by Println and Printf.
+type printCmd string
+
+// tickCmd is the command returned by Tick and Every.
+type tickCmd struct {
+	d     time.Duration
+	fn    func(time.Time) Msg
+	every bool
+}
+
+// delay returns how long to wait from now until the tick fires.
+func (c tickCmd) delay(now time.Time) time.Duration {
+	if c.every && c.d > 0 {
+		return now.Truncate(c.d).Add(c.d).Sub(now)
+	}
+	return c.d
+}
+
+// wait blocks until the tick fires or ctx is cancelled.
+func (c tickCmd) wait(ctx context.Context) Msg {
+	timer := time.NewTimer(c.delay(time.Now()))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case t := <-timer.C:
+		return c.fn(t)
+	}
+}
+
+// sequenceCmd is the command returned by Sequence.
+type sequenceCmd []Cmd
+
+// resolve runs a single, non-composite command to completion and returns
+// the message it produces. It returns nil for unknown commands, commands
+// without a result and when ctx is cancelled first.
+func resolve(ctx context.Context, cmd Cmd) Msg {
+	switch c := cmd.(type) {
+	case quitCmd:
+		return QuitMsg{}
+	case printCmd:
+		return PrintLineMsg{Text: string(c)}
+	case tickCmd:
+		return c.wait(ctx)
+	case Command:
+		if err := c.Execute(); err != nil {
+			return ErrorMsg{Error: err}
+		}
+	case func() error:
+		if err := c(); err != nil {
+			return ErrorMsg{Error: err}
+		}
+	case func() Msg:
+		return c()
+	}
+	return nil
+}
+
+// Clock is the time source used by the command runtime for Tick and Every.
+// Tests replace it with a fake clock to control when ticks fire.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f with the current time once d has elapsed.
+	// The returned stop function cancels the call and reports whether it
+	// did so before f ran.
+	AfterFunc(d time.Duration, f func(now time.Time)) (stop func() bool)
+}
+
+// SystemClock returns a Clock backed by the time package.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func(time.Time)) func() bool {
+	return time.AfterFunc(d, func() { f(time.Now()) }).Stop
+}
+
+// Runtime executes commands and delivers their messages to a send function.
+// Engines create one Runtime per run and stop it when the engine stops,
+// which cancels pending ticks and drops late results.
+//
+// Batches run concurrently, sequences in order; quit commands deliver a
+// QuitMsg that the engine is expected to act on. Ticks are scheduled on the
+// runtime's Clock and do not occupy a goroutine while waiting.
+type Runtime struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	send   func(Msg)
+	clock  Clock
+	sync   bool
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	active    int                    // commands currently executing
+	timers    map[uint64]func() bool // pending ticks and their stop functions
+	nextTimer uint64
+}
+
+// NewRuntime creates a runtime that delivers messages to send until ctx is
+// cancelled or Stop is called. send may be called from any goroutine.
+func NewRuntime(ctx context.Context, send func(Msg)) *Runtime {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &Runtime{
+		ctx:    ctx,
+		cancel: cancel,
+		send:   send,
+		clock:  SystemClock(),
+		timers: make(map[uint64]func() bool),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// SetClock replaces the clock used for ticks. It must be called before the
+// first Run.
+func (r *Runtime) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock()
+	}
+	r.clock = clock
+}
+
+// SetSynchronous makes Run execute commands on the calling goroutine and
+// deliver their messages before returning. Ticks still fire from the clock.
+// Combined with a fake clock this makes command execution deterministic.
+// It must be called before the first Run.
+func (r *Runtime) SetSynchronous(sync bool) {
+	r.sync = sync
+}
+
+// Context returns the runtime's context. It is done once the runtime stops.
+func (r *Runtime) Context() context.Context {
+	return r.ctx
+}
+
+// Run starts executing cmd and returns immediately, unless the runtime is
+// synchronous.
+func (r *Runtime) Run(cmd Cmd) {
+	if cmd == nil || IsNone(cmd) {
+		return
+	}
+	r.run(cmd, func() {})
+}
+
+// Wait blocks until no commands are running and no ticks are pending.
+// Commands that keep rescheduling themselves, like a repeating Tick,
+// prevent Wait from returning until the runtime is stopped.
+func (r *Runtime) Wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.active > 0 || len(r.timers) > 0 {
+		r.cond.Wait()
+	}
+}
+
+// WaitIdle blocks until no commands are running. Pending ticks are ignored.
+func (r *Runtime) WaitIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.active > 0 {
+		r.cond.Wait()
+	}
+}
+
+// Pending returns the number of ticks waiting to fire.
+func (r *Runtime) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.timers)
+}
+
+// Stop cancels pending ticks and discards messages produced afterwards.
+// It does not wait for running commands; call Wait for that.
+func (r *Runtime) Stop() {
+	r.cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, stop := range r.timers {
+		if stop != nil {
+			stop()
+		}
+		delete(r.timers, id)
+	}
+	r.cond.Broadcast()
+}
+
+// run executes cmd and calls next once it has delivered all its messages.
+// next is not called if the runtime stops first.
+func (r *Runtime) run(cmd Cmd, next func()) {
+	if r.ctx.Err() != nil {
+		return
+	}
+
+	switch c := cmd.(type) {
+	case nil, noneCmd:
+		next()
+	case BatchCmd:
+		if len(c) == 0 {
+			next()
+			return
+		}
+		var mu sync.Mutex
+		remaining := len(c)
+		done := func() {
+			mu.Lock()
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+			if last {
+				next()
+			}
+		}
+		for _, sub := range c {
+			r.run(sub, done)
+		}
+	case sequenceCmd:
+		r.runSequence(c, next)
+	case tickCmd:
+		r.schedule(c, next)
+	default:
+		r.begin()
+		r.spawn(func() {
+			defer r.end()
+			r.deliver(resolve(r.ctx, cmd))
+			next()
+		})
+	}
+}
+
+func (r *Runtime) runSequence(seq sequenceCmd, next func()) {
+	if len(seq) == 0 {
+		next()
+		return
+	}
+	r.run(seq[0], func() {
+		r.runSequence(seq[1:], next)
+	})
+}
+
+// schedule arms a timer for a tick on the runtime's clock.
+func (r *Runtime) schedule(c tickCmd, next func()) {
+	r.mu.Lock()
+	id := r.nextTimer
+	r.nextTimer++
+	r.timers[id] = nil
+	r.mu.Unlock()
+
+	stop := r.clock.AfterFunc(c.delay(r.clock.Now()), func(now time.Time) {
+		if !r.fire(id) {
+			return
+		}
+		defer r.end()
+		r.deliver(c.fn(now))
+		next()
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.timers[id]; ok {
+		r.timers[id] = stop
+	} else if r.ctx.Err() != nil {
+		// Stopped while arming the timer
+		stop()
+	}
+}
+
+// fire removes a timer that is about to run and marks it active.
+// It returns false if the timer was cancelled.
+func (r *Runtime) fire(id uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.timers[id]; !ok {
+		return false
+	}
+	delete(r.timers, id)
+	r.active++
+	return true
+}
+
+func (r *Runtime) spawn(fn func()) {
+	if r.sync {
+		fn()
+		return
+	}
+	go fn()
+}
+
+func (r *Runtime) begin() {
+	r.mu.Lock()
+	r.active++
+	r.mu.Unlock()
+}
+
+func (r *Runtime) end() {
+	r.mu.Lock()
+	r.active--
+	if r.active == 0 {
+		r.cond.Broadcast()
+	}
+	r.mu.Unlock()
+}
+
+func (r *Runtime) deliver(msg Msg) {
+	if msg == nil || r.ctx.Err() != nil {
+		return
+	}
+	r.send(msg)
+}
@@ -0,0 +1,89 @@
+// Package colorprofile tells the buffer StyleCache how many colors the
+// terminal supports and downsamples colors in lipgloss notation to fit.
+//
+// Detection is done by github.com/charmbracelet/colorprofile. There is no
+// process-wide profile, since one process may serve several terminals:
+// each engine detects the profile of its own terminal and reports it to
+// the model, which sets it on the buffers it renders. lipgloss detects its
+// own profile and is not changed by this package.
+package colorprofile
+
+import (
+	"io"
+
+	cp "github.com/charmbracelet/colorprofile"
+)
+
+// Profile is the set of colors a terminal can display.
+type Profile int
+
+const (
+	// TrueColor supports 24-bit colors.
+	TrueColor Profile = iota
+	// ANSI256 supports the 256-color palette.
+	ANSI256
+	// ANSI supports the 16 basic and bright colors.
+	ANSI
+	// NoColor disables colors; other attributes are kept.
+	NoColor
+)
+
+// String returns the profile name.
+func (p Profile) String() string {
+	switch p {
+	case TrueColor:
+		return "truecolor"
+	case ANSI256:
+		return "256"
+	case ANSI:
+		return "16"
+	case NoColor:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// Detect determines the profile of a terminal from environment variables
+// given as "KEY=value" pairs, as returned by os.Environ. It honors
+// NO_COLOR, CLICOLOR_FORCE, COLORTERM and TERM; see colorprofile.Env.
+func Detect(environ []string) Profile {
+	return fromProfile(cp.Env(environ))
+}
+
+// DetectOutput determines the profile for writing to output with the given
+// environment. Unlike Detect, output that is not a terminal (a pipe or a
+// log file) gets no colors unless CLICOLOR_FORCE is set; see
+// colorprofile.Detect.
+func DetectOutput(output io.Writer, environ []string) Profile {
+	return fromProfile(cp.Detect(output, environ))
+}
+
+// fromProfile returns the equivalent of a charmbracelet/colorprofile
+// profile.
+func fromProfile(p cp.Profile) Profile {
+	switch p {
+	case cp.TrueColor:
+		return TrueColor
+	case cp.ANSI256:
+		return ANSI256
+	case cp.ANSI:
+		return ANSI
+	default:
+		return NoColor
+	}
+}
+
+// profile returns the equivalent charmbracelet/colorprofile profile.
+func (p Profile) profile() cp.Profile {
+	switch p {
+	case TrueColor:
+		return cp.TrueColor
+	case ANSI256:
+		return cp.ANSI256
+	case ANSI:
+		return cp.ANSI
+	default:
+		return cp.ASCII
+	}
+}
@@ -0,0 +1,100 @@
+package colorprofile
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		want    Profile
+	}{
+		{"no color", []string{"NO_COLOR=1", "COLORTERM=truecolor", "TERM=xterm-256color"}, NoColor},
+		{"empty no color is ignored", []string{"NO_COLOR=", "TERM=xterm-256color"}, ANSI256},
+		{"colorterm truecolor", []string{"COLORTERM=truecolor", "TERM=xterm"}, TrueColor},
+		{"colorterm 24bit", []string{"COLORTERM=24bit", "TERM=xterm"}, TrueColor},
+		{"xterm 256", []string{"TERM=xterm-256color"}, ANSI256},
+		{"tmux", []string{"TERM=tmux-256color"}, ANSI256},
+		{"screen", []string{"TERM=screen"}, ANSI256},
+		{"linux console", []string{"TERM=linux"}, ANSI},
+		{"kitty", []string{"TERM=xterm-kitty"}, TrueColor},
+		{"direct", []string{"TERM=xterm-direct"}, TrueColor},
+		{"dumb", []string{"TERM=dumb"}, NoColor},
+		{"unset", nil, NoColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.environ); got != tt.want {
+				t.Errorf("Detect(%q) = %s, want %s", tt.environ, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectOutput(t *testing.T) {
+	var out bytes.Buffer
+	if got := DetectOutput(&out, []string{"TERM=xterm-256color"}); got != NoColor {
+		t.Errorf("DetectOutput to a pipe = %s, want %s", got, NoColor)
+	}
+	if got := DetectOutput(&out, []string{"TERM=xterm-256color", "CLICOLOR_FORCE=1"}); got != ANSI256 {
+		t.Errorf("DetectOutput with CLICOLOR_FORCE = %s, want %s", got, ANSI256)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		color   string
+		want    string
+	}{
+		{TrueColor, "#6B50FF", "#6B50FF"},
+		{TrueColor, "202", "202"},
+		{ANSI256, "#ff0000", "196"},
+		{ANSI256, "#f00", "196"},
+		{ANSI256, "#808080", "244"},
+		{ANSI256, "#0f0f1a", "233"},
+		{ANSI256, "202", "202"},
+		{ANSI256, "1", "1"},
+		{ANSI, "#ff0000", "9"},
+		{ANSI, "#800000", "1"},
+		{ANSI, "196", "9"},
+		{ANSI, "232", "0"},
+		{ANSI, "7", "7"},
+		{NoColor, "#ff0000", ""},
+		{NoColor, "1", ""},
+		{ANSI256, "", ""},
+		{ANSI, "38;5;202", "38;5;202"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile.Convert(tt.color); got != tt.want {
+			t.Errorf("%s.Convert(%q) = %q, want %q", tt.profile, tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestRGB(t *testing.T) {
+	tests := []struct {
+		color   string
+		r, g, b int
+		ok      bool
+	}{
+		{"#6B50FF", 0x6b, 0x50, 0xff, true},
+		{"#f00", 0xff, 0, 0, true},
+		{"1", 0x80, 0, 0, true},
+		{"196", 0xff, 0, 0, true},
+		{"244", 0x80, 0x80, 0x80, true},
+		{"", 0, 0, 0, false},
+		{"38;5;202", 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		r, g, b, ok := RGB(tt.color)
+		if r != tt.r || g != tt.g || b != tt.b || ok != tt.ok {
+			t.Errorf("RGB(%q) = %d, %d, %d, %v", tt.color, r, g, b, ok)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package colorprofile
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Convert maps a color in lipgloss notation ("" for the default color,
+// "0"-"255" for a palette index, "#rrggbb" or "#rgb" for true color) to the
+// nearest color p can display, in the same notation. NoColor returns "".
+// Unrecognized values are returned unchanged unless p is NoColor.
+func (p Profile) Convert(c string) string {
+	if c == "" || p == NoColor {
+		return ""
+	}
+	if p == TrueColor {
+		return c
+	}
+
+	var in color.Color
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		in = ansi.IndexedColor(n)
+	} else if r, g, b, ok := parseHex(c); ok {
+		in = color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+	} else {
+		return c
+	}
+
+	switch out := p.profile().Convert(in).(type) {
+	case ansi.BasicColor:
+		return strconv.Itoa(int(out))
+	case ansi.IndexedColor:
+		return strconv.Itoa(int(out))
+	}
+	return c
+}
+
+// RGB returns the red, green and blue components of a color in lipgloss
+// notation, using the default xterm colors for palette indexes. ok is false
+// for the default color and unrecognized values.
+func RGB(c string) (r, g, b int, ok bool) {
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+		r, g, b = paletteRGB(n)
+		return r, g, b, true
+	}
+	return parseHex(c)
+}
+
+// ansiPalette holds the default xterm colors of the 16 basic and bright
+// colors.
+var ansiPalette = [16][3]int{
+	{0x00, 0x00, 0x00}, {0x80, 0x00, 0x00}, {0x00, 0x80, 0x00}, {0x80, 0x80, 0x00},
+	{0x00, 0x00, 0x80}, {0x80, 0x00, 0x80}, {0x00, 0x80, 0x80}, {0xc0, 0xc0, 0xc0},
+	{0x80, 0x80, 0x80}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x00, 0x00, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube (16-231).
+var cubeLevels = [6]int{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// paletteRGB returns the color of palette index n.
+func paletteRGB(n int) (r, g, b int) {
+	switch {
+	case n < 16:
+		c := ansiPalette[n]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+// parseHex parses "#rrggbb" or "#rgb".
+func parseHex(c string) (r, g, b int, ok bool) {
+	if !strings.HasPrefix(c, "#") {
+		return 0, 0, 0, false
+	}
+	hex := c[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff), true
+}
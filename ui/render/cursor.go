@@ -0,0 +1,207 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// CursorShape is the shape of the terminal cursor.
+type CursorShape int
+
+const (
+	// CursorBlock fills the whole cell.
+	CursorBlock CursorShape = iota
+	// CursorUnderline is a line below the cell.
+	CursorUnderline
+	// CursorBar is a vertical line at the left edge of the cell.
+	CursorBar
+)
+
+// String returns the name of the shape.
+func (s CursorShape) String() string {
+	switch s {
+	case CursorUnderline:
+		return "underline"
+	case CursorBar:
+		return "bar"
+	default:
+		return "block"
+	}
+}
+
+// Cursor is the position and look of the terminal cursor. X and Y are the
+// cell the cursor is on, relative to the view it belongs to.
+type Cursor struct {
+	X, Y  int
+	Shape CursorShape
+	Blink bool
+}
+
+// CursorProvider is implemented by components that want the terminal
+// cursor while they are focused, such as text inputs. TerminalCursor
+// returns the cursor relative to the component's view, or nil if the
+// component shows none.
+//
+// Views are composited as strings, so a component also embeds its cursor
+// into its view with Cursor.Marker or PlaceCursor. The marker is a
+// zero-width escape sequence that survives being joined, padded and
+// framed by the parents, and the engine looks for it in the final view to
+// position the real cursor after compositing.
+type CursorProvider interface {
+	TerminalCursor() *Cursor
+}
+
+// cursorMarkerPrefix and cursorMarkerEnd enclose the APC sequence that
+// marks the cursor cell. Its payload is "<shape>;<blink>".
+const (
+	cursorMarkerPrefix = "\x1b_taproot-cursor;"
+	cursorMarkerEnd    = "\x1b\\"
+)
+
+// Marker returns the escape sequence that puts the cursor on the cell
+// following it. X and Y are ignored: the marker's place in the view is
+// the position.
+func (c Cursor) Marker() string {
+	blink := "0"
+	if c.Blink {
+		blink = "1"
+	}
+	return cursorMarkerPrefix + strconv.Itoa(int(c.Shape)) + ";" + blink + cursorMarkerEnd
+}
+
+// style returns the DECSCUSR parameter for the cursor.
+func (c Cursor) style() int {
+	style := 2*int(c.Shape) + 1
+	if !c.Blink {
+		style++
+	}
+	return style
+}
+
+// PlaceCursor returns view with the marker of c inserted at the cell
+// (c.X, c.Y). A line shorter than c.X is padded with spaces; a position
+// above or below the view is ignored. It returns view unchanged if c is
+// nil.
+func PlaceCursor(view string, c *Cursor) string {
+	if c == nil || c.X < 0 || c.Y < 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	if c.Y >= len(lines) {
+		return view
+	}
+
+	line := lines[c.Y]
+	offset, col := cellOffset(line, c.X)
+	if offset == len(line) && col < c.X {
+		line += strings.Repeat(" ", c.X-col)
+		offset = len(line)
+	}
+	lines[c.Y] = line[:offset] + c.Marker() + line[offset:]
+	return strings.Join(lines, "\n")
+}
+
+// cellOffset returns the byte offset in line of the cell at column x and
+// the column the offset is at. A wide character covering x starts the
+// cell. If line is narrower than x, it returns len(line) and its width.
+func cellOffset(line string, x int) (int, int) {
+	var state byte
+	col := 0
+	for i := 0; i < len(line); {
+		_, width, n, newState := ansi.DecodeSequence(line[i:], state, nil)
+		if width > 0 && col+width > x {
+			return i, col
+		}
+		col += width
+		i += n
+		state = newState
+	}
+	return len(line), col
+}
+
+// FindCursor removes the cursor markers from view and returns the view
+// and the cursor of the first marker, positioned where the marker was.
+// The cursor is nil if there was no marker.
+func FindCursor(view string) (string, *Cursor) {
+	if !strings.Contains(view, cursorMarkerPrefix) {
+		return view, nil
+	}
+
+	var cursor *Cursor
+	lines := strings.Split(view, "\n")
+	for y, line := range lines {
+		for {
+			start := strings.Index(line, cursorMarkerPrefix)
+			if start < 0 {
+				break
+			}
+			end := strings.Index(line[start:], cursorMarkerEnd)
+			if end < 0 {
+				break
+			}
+			end += start
+			if cursor == nil {
+				cursor = parseMarker(line[start+len(cursorMarkerPrefix) : end])
+				cursor.X, cursor.Y = ansi.StringWidth(line[:start]), y
+			}
+			line = line[:start] + line[end+len(cursorMarkerEnd):]
+		}
+		lines[y] = line
+	}
+	return strings.Join(lines, "\n"), cursor
+}
+
+// DrawCursor replaces the cursor marker in view with a styled cell and
+// removes any others. Hosts that cannot move the terminal cursor, such as a
+// plain Bubbletea program, use it on the final view so components that
+// embed a marker still show a cursor.
+func DrawCursor(view string) string {
+	return drawCursor(FindCursor(view))
+}
+
+// StripCursor removes the cursor markers from view.
+func StripCursor(view string) string {
+	view, _ = FindCursor(view)
+	return view
+}
+
+// parseMarker decodes the "<shape>;<blink>" payload of a cursor marker.
+func parseMarker(payload string) *Cursor {
+	shape, blink, _ := strings.Cut(payload, ";")
+	n, _ := strconv.Atoi(shape)
+	return &Cursor{Shape: CursorShape(n), Blink: blink == "1"}
+}
+
+// drawCursor renders c into view as a styled cell, for engines that
+// cannot move the terminal cursor: a reverse video cell for the block and
+// bar shapes and an underlined one for the underline shape.
+func drawCursor(view string, c *Cursor) string {
+	if c == nil {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	if c.Y < 0 || c.Y >= len(lines) {
+		return view
+	}
+
+	on, off := "7", "27"
+	if c.Shape == CursorUnderline {
+		on, off = "4", "24"
+	}
+	if c.Blink {
+		on, off = on+";5", off+";25"
+	}
+	on, off = "\x1b["+on+"m", "\x1b["+off+"m"
+
+	line := lines[c.Y]
+	start, col := cellOffset(line, c.X)
+	if start == len(line) {
+		lines[c.Y] = line + strings.Repeat(" ", max(c.X-col, 0)) + on + " " + off
+	} else {
+		_, _, n, _ := ansi.DecodeSequence(line[start:], ansi.NormalState, nil)
+		lines[c.Y] = line[:start] + on + line[start:start+n] + off + line[start+n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,19 +1,32 @@
 package render
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 // DirectEngine is a simple rendering engine that writes directly to output.
 // It's primarily useful for testing and simple applications.
+//
+// Messages are applied synchronously by Send. Commands run in the background
+// on the shared command runtime and feed their results back through Send;
+// use Wait to block until they have finished.
 type DirectEngine struct {
 	config    *EngineConfig
 	model     Model
+	cmds      *Runtime
 	running   bool
 	mu        sync.RWMutex
 	output    *stringWriter
+	cursor    *Cursor
+	printed   []string
+	caps      termcap.Capabilities
 	initFunc  func() error
 	cleanupFn func() error
 }
@@ -67,16 +80,6 @@ func (e *DirectEngine) Start(model Model) error {
 
 	e.model = model
 
-	// Initialize the model
-	cmd := model.Init()
-	if cmd != nil {
-		if c, ok := cmd.(Command); ok {
-			if err := c.Execute(); err != nil {
-				return fmt.Errorf("model init failed: %w", err)
-			}
-		}
-	}
-
 	// Call custom init function if provided
 	if e.initFunc != nil {
 		if err := e.initFunc(); err != nil {
@@ -85,6 +88,12 @@ func (e *DirectEngine) Start(model Model) error {
 	}
 
 	e.running = true
+	e.cmds = NewRuntime(context.Background(), func(msg Msg) {
+		_ = e.Send(msg)
+	})
+
+	// Initialize the model
+	e.cmds.Run(model.Init())
 
 	// Initial render
 	e.render()
@@ -97,6 +106,11 @@ func (e *DirectEngine) Stop() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	return e.stop()
+}
+
+// stop shuts down the engine. The caller must hold e.mu.
+func (e *DirectEngine) stop() error {
 	if !e.running {
 		return nil
 	}
@@ -108,6 +122,7 @@ func (e *DirectEngine) Stop() error {
 		}
 	}
 
+	e.cmds.Stop()
 	e.running = false
 	e.model = nil
 
@@ -116,6 +131,25 @@ func (e *DirectEngine) Stop() error {
 
 // Send sends a message to the model.
 func (e *DirectEngine) Send(msg Msg) error {
+	// There is no terminal to release: commands run on the configured
+	// streams, stdio by default, and suspending resumes at once. Both are
+	// handled without holding the lock, since their replies go through Send.
+	switch msg := msg.(type) {
+	case ExecMsg:
+		if !e.Running() {
+			return fmt.Errorf("engine not running")
+		}
+		in, out := e.config.streams()
+		if reply := msg.Run(in, out, os.Stderr); reply != nil {
+			return e.Send(reply)
+		}
+		return nil
+	case SuspendMsg:
+		return e.Send(ResumeMsg{})
+	case ProbeMsg:
+		return e.Send(CapabilitiesMsg{Capabilities: e.probe(msg.Timeout)})
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -123,27 +157,64 @@ func (e *DirectEngine) Send(msg Msg) error {
 		return fmt.Errorf("engine not running")
 	}
 
+	if _, ok := msg.(QuitMsg); ok {
+		return e.stop()
+	}
+
+	if line, ok := msg.(PrintLineMsg); ok {
+		if !e.config.EnableAltScreen {
+			e.printed = append(e.printed, line.Text)
+		}
+		return nil
+	}
+
 	// Update the model with the message
 	newModel, cmd := e.model.Update(msg)
 	if newModel != nil {
 		e.model = newModel
 	}
 
-	// Execute command if returned
-	if cmd != nil {
-		if c, ok := cmd.(Command); ok {
-			if err := c.Execute(); err != nil {
-				return err
-			}
-		}
+	if IsQuit(cmd) {
+		return e.stop()
 	}
 
+	// Run the returned command in the background
+	e.cmds.Run(cmd)
+
 	// Re-render after update
 	e.render()
 
 	return nil
 }
 
+// probe queries the terminal behind the configured streams, if both are
+// set, and returns the answer to the last probe that got one.
+func (e *DirectEngine) probe(timeout time.Duration) termcap.Capabilities {
+	if e.config.Input != nil && e.config.Output != nil {
+		if caps, _ := termcap.Probe(e.config.Input, e.config.Output, timeout); caps.Probed {
+			e.mu.Lock()
+			e.caps = caps
+			e.mu.Unlock()
+		}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.caps
+}
+
+// Wait blocks until all commands started by the model have finished and
+// their messages have been applied. A model that keeps scheduling ticks
+// prevents Wait from returning until the engine is stopped.
+func (e *DirectEngine) Wait() {
+	e.mu.RLock()
+	cmds := e.cmds
+	e.mu.RUnlock()
+	if cmds != nil {
+		cmds.Wait()
+	}
+}
+
 // Resize notifies the engine of a size change.
 func (e *DirectEngine) Resize(width, height int) error {
 	return e.Send(WindowSizeMsg{Width: width, Height: height})
@@ -162,9 +233,10 @@ func (e *DirectEngine) render() {
 		return
 	}
 
-	view := e.model.View()
+	view, cursor := FindCursor(e.config.view(e.model.View()))
 	e.output.Clear()
 	e.output.Write(view)
+	e.cursor = cursor
 }
 
 // Output returns the rendered output.
@@ -174,6 +246,22 @@ func (e *DirectEngine) Output() string {
 	return e.output.String()
 }
 
+// Cursor returns where the terminal cursor would be shown after the last
+// render, or nil if no component placed it.
+func (e *DirectEngine) Cursor() *Cursor {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cursor
+}
+
+// Printed returns the lines printed with Println while rendering inline,
+// which a terminal would have committed to its scrollback.
+func (e *DirectEngine) Printed() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]string(nil), e.printed...)
+}
+
 // SetInitFunc sets a custom initialization function.
 func (e *DirectEngine) SetInitFunc(fn func() error) {
 	e.mu.Lock()
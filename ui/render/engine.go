@@ -1,5 +1,13 @@
 package render
 
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
 // EngineType identifies the rendering engine.
 type EngineType int
 
@@ -10,6 +18,9 @@ const (
 	EngineUltraviolet
 	// EngineDirect uses direct terminal output (for testing/simple cases).
 	EngineDirect
+	// EngineReplay replays a recorded session. It is registered by
+	// importing package asciicast.
+	EngineReplay
 )
 
 // String returns the engine type name.
@@ -21,6 +32,8 @@ func (e EngineType) String() string {
 		return "ultraviolet"
 	case EngineDirect:
 		return "direct"
+	case EngineReplay:
+		return "replay"
 	default:
 		return "unknown"
 	}
@@ -46,14 +59,46 @@ type Engine interface {
 type EngineConfig struct {
 	// EnableMouse enables mouse event support.
 	EnableMouse bool
-	// EnableAltScreen enables alternate screen mode.
+	// EnableAltScreen enables alternate screen mode. Without it the engine
+	// renders inline: the view occupies as many lines as it has at the
+	// bottom of the normal screen, and Println commits lines to the
+	// scrollback above it.
 	EnableAltScreen bool
+	// InlineHeight limits the view of an inline engine to its last
+	// InlineHeight lines. Zero uses the full height of the view, up to the
+	// terminal height.
+	InlineHeight int
 	// EnableCursor enables cursor visibility.
 	EnableCursor bool
-	// Input is the input reader (nil uses stdin).
-	Input any
-	// Output is the output writer (nil uses stdout).
-	Output any
+	// EnableKeyboardEnhancements requests the kitty progressive keyboard
+	// protocol so modifiers and ambiguous keys are reported precisely.
+	// Terminals without support ignore the request.
+	EnableKeyboardEnhancements bool
+	// EnableKeyReleases additionally requests key release and repeat
+	// events. Models must check KeyMsg.Type when this is enabled.
+	EnableKeyReleases bool
+	// EnableFocusReporting asks the terminal to report when its window
+	// gains or loses focus, delivered as FocusMsg. It is off by default;
+	// terminals without support ignore the request.
+	EnableFocusReporting bool
+	// Input is the stream keys and other terminal input are read from
+	// (nil uses stdin). Together with Output it can be any connection to a
+	// terminal: a PTY, a Unix socket or an SSH channel, so one process can
+	// serve several sessions, each with its own engine and model.
+	Input io.Reader
+	// Output is the stream the view is written to (nil uses stdout).
+	Output io.Writer
+	// Width and Height are the initial terminal size in cells. They are
+	// used when the size can't be read from Output because it is not a
+	// terminal; later changes are reported with Engine.Resize.
+	Width, Height int
+	// Term is the terminal type, as in the TERM environment variable, of
+	// the terminal at the other end of the streams. Empty uses $TERM.
+	Term string
+	// MaxFPS caps how often the view is redrawn; updates in between are
+	// coalesced into the next frame. Zero uses DefaultMaxFPS and a
+	// negative value removes the cap.
+	MaxFPS int
 }
 
 // DefaultConfig returns the default engine configuration.
@@ -62,11 +107,65 @@ func DefaultConfig() *EngineConfig {
 		EnableMouse:     false,
 		EnableAltScreen: true,
 		EnableCursor:    false,
-		Input:           nil,
-		Output:          nil,
 	}
 }
 
+// InlineConfig returns a configuration for inline rendering, with the live
+// view limited to height lines (0 for no limit).
+func InlineConfig(height int) *EngineConfig {
+	config := DefaultConfig()
+	config.EnableAltScreen = false
+	config.InlineHeight = height
+	return config
+}
+
+// streams returns the configured input and output, defaulting to stdio.
+func (c *EngineConfig) streams() (io.Reader, io.Writer) {
+	var in io.Reader = os.Stdin
+	var out io.Writer = os.Stdout
+	if c.Input != nil {
+		in = c.Input
+	}
+	if c.Output != nil {
+		out = c.Output
+	}
+	return in, out
+}
+
+// environ returns the environment of the session: the process
+// environment with TERM replaced by Term, if set.
+func (c *EngineConfig) environ() []string {
+	env := os.Environ()
+	if c.Term == "" {
+		return env
+	}
+	environ := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "TERM=") {
+			environ = append(environ, kv)
+		}
+	}
+	return append(environ, "TERM="+c.Term)
+}
+
+// isTerminal reports whether s is a terminal device.
+func isTerminal(s any) bool {
+	f, ok := s.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// view returns the part of a model's view the engine draws.
+func (c *EngineConfig) view(view string) string {
+	if c.EnableAltScreen || c.InlineHeight <= 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	if len(lines) <= c.InlineHeight {
+		return view
+	}
+	return strings.Join(lines[len(lines)-c.InlineHeight:], "\n")
+}
+
 // EngineFactory creates a new engine instance.
 type EngineFactory func(config *EngineConfig) Engine
 
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"io"
+	"os/exec"
+)
+
+// ExecCommand is an interactive program that takes over the terminal while
+// it runs, such as $EDITOR or a shell. Use ExecProcess for an *exec.Cmd.
+type ExecCommand interface {
+	Run() error
+	SetStdin(io.Reader)
+	SetStdout(io.Writer)
+	SetStderr(io.Writer)
+}
+
+// Exec returns a command that hands the terminal to c: the engine leaves
+// raw mode and the alternate screen, runs c connected to the terminal,
+// then restores the terminal and redraws. fn is called with the error
+// returned by c, and the message it returns is sent to the model. fn may
+// be nil.
+//
+// Messages arriving while c runs are queued until it has finished.
+func Exec(c ExecCommand, fn func(error) Msg) Cmd {
+	return func() Msg {
+		return ExecMsg{Command: c, Callback: fn}
+	}
+}
+
+// ExecProcess is Exec for an *exec.Cmd. Streams left unset on c are
+// connected to the terminal.
+//
+//	cmd := render.ExecProcess(exec.Command("vim", path), func(err error) render.Msg {
+//		return editorClosedMsg{err: err}
+//	})
+func ExecProcess(c *exec.Cmd, fn func(error) Msg) Cmd {
+	return Exec(&osExecCommand{Cmd: c}, fn)
+}
+
+// Suspend returns a command that suspends the program, as a shell would
+// on ctrl+z. Raw mode is turned off and the process stops itself with
+// SIGTSTP; once it is resumed (e.g. with fg) the terminal is restored and
+// the model receives a ResumeMsg. Terminals in raw mode don't send SIGTSTP
+// themselves, so models that want ctrl+z to suspend must map the key to
+// this command.
+func Suspend() Cmd {
+	return func() Msg {
+		return SuspendMsg{}
+	}
+}
+
+// ExecMsg is sent by Exec. Engines handle it by running Command with the
+// terminal released; it is not passed to the model.
+type ExecMsg struct {
+	Command  ExecCommand
+	Callback func(error) Msg
+}
+
+// Run connects the command's streams that are not nil and runs it,
+// returning the message produced by the callback, if any.
+func (m ExecMsg) Run(stdin io.Reader, stdout, stderr io.Writer) Msg {
+	if stdin != nil {
+		m.Command.SetStdin(stdin)
+	}
+	if stdout != nil {
+		m.Command.SetStdout(stdout)
+	}
+	if stderr != nil {
+		m.Command.SetStderr(stderr)
+	}
+	return m.Done(m.Command.Run())
+}
+
+// Done returns the message the callback produces for err, if any.
+func (m ExecMsg) Done(err error) Msg {
+	if m.Callback == nil {
+		return nil
+	}
+	return m.Callback(err)
+}
+
+// SuspendMsg is sent by Suspend. Engines handle it by suspending the
+// process; it is not passed to the model.
+type SuspendMsg struct{}
+
+// ResumeMsg is sent to the model when the program resumes after Suspend.
+type ResumeMsg struct{}
+
+// osExecCommand adapts an *exec.Cmd to ExecCommand. Streams the caller
+// has already set are kept.
+type osExecCommand struct{ *exec.Cmd }
+
+func (c *osExecCommand) SetStdin(r io.Reader) {
+	if c.Stdin == nil {
+		c.Stdin = r
+	}
+}
+
+func (c *osExecCommand) SetStdout(w io.Writer) {
+	if c.Stdout == nil {
+		c.Stdout = w
+	}
+}
+
+func (c *osExecCommand) SetStderr(w io.Writer) {
+	if c.Stderr == nil {
+		c.Stderr = w
+	}
+}
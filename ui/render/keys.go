@@ -0,0 +1,202 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keyExtended marks the start of the named key code range, above any valid
+// Unicode code point so named keys never collide with printable runes.
+const keyExtended = unicode.MaxRune + 1
+
+// Named key codes used in KeyMsg.Code.
+// Printable keys use their rune value instead (space is ' ').
+const (
+	KeyUp rune = keyExtended + iota + 1
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyBegin
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDown
+	KeyInsert
+	KeyDelete
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyF13
+	KeyF14
+	KeyF15
+	KeyF16
+	KeyF17
+	KeyF18
+	KeyF19
+	KeyF20
+)
+
+// keyNames maps named key codes to the names used in KeyMsg.Key.
+var keyNames = map[rune]string{
+	KeyUp:        "up",
+	KeyDown:      "down",
+	KeyRight:     "right",
+	KeyLeft:      "left",
+	KeyBegin:     "begin",
+	KeyHome:      "home",
+	KeyEnd:       "end",
+	KeyPgUp:      "pgup",
+	KeyPgDown:    "pgdown",
+	KeyInsert:    "insert",
+	KeyDelete:    "delete",
+	KeyEnter:     "enter",
+	KeyTab:       "tab",
+	KeyBackspace: "backspace",
+	KeyEscape:    "esc",
+	KeyF1:        "f1",
+	KeyF2:        "f2",
+	KeyF3:        "f3",
+	KeyF4:        "f4",
+	KeyF5:        "f5",
+	KeyF6:        "f6",
+	KeyF7:        "f7",
+	KeyF8:        "f8",
+	KeyF9:        "f9",
+	KeyF10:       "f10",
+	KeyF11:       "f11",
+	KeyF12:       "f12",
+	KeyF13:       "f13",
+	KeyF14:       "f14",
+	KeyF15:       "f15",
+	KeyF16:       "f16",
+	KeyF17:       "f17",
+	KeyF18:       "f18",
+	KeyF19:       "f19",
+	KeyF20:       "f20",
+}
+
+// keyCodes is the reverse of keyNames.
+var keyCodes = func() map[string]rune {
+	m := make(map[string]rune, len(keyNames))
+	for code, name := range keyNames {
+		m[name] = code
+	}
+	return m
+}()
+
+// KeyName returns the name of a key code, e.g. "enter" for KeyEnter or "a"
+// for 'a'. Space is returned as " " to match existing key maps.
+func KeyName(code rune) string {
+	if name, ok := keyNames[code]; ok {
+		return name
+	}
+	if code <= 0 || code > unicode.MaxRune {
+		return ""
+	}
+	return string(code)
+}
+
+// KeyCode returns the key code for a key name, or 0 if the name is unknown.
+// It is the inverse of KeyName.
+func KeyCode(name string) rune {
+	if name == "space" {
+		return ' '
+	}
+	if code, ok := keyCodes[name]; ok {
+		return code
+	}
+	if r, size := utf8.DecodeRuneInString(name); r != utf8.RuneError && size == len(name) {
+		return r
+	}
+	return 0
+}
+
+// ParseKey parses a key string such as "ctrl+shift+up" or "alt+a" into a
+// KeyMsg. It accepts modifiers in any order. Single printable characters
+// without modifiers also populate Text.
+func ParseKey(s string) KeyMsg {
+	var k KeyMsg
+
+	for {
+		i := strings.IndexByte(s, '+')
+		// A trailing or lone "+" is the plus key itself, not a separator.
+		if i <= 0 || i == len(s)-1 {
+			break
+		}
+		switch s[:i] {
+		case "alt":
+			k.Alt = true
+		case "ctrl":
+			k.Ctrl = true
+		case "shift":
+			k.Shift = true
+		case "meta":
+			k.Meta = true
+		case "hyper":
+			k.Hyper = true
+		case "super":
+			k.Super = true
+		default:
+			i = -1
+		}
+		if i < 0 {
+			break
+		}
+		s = s[i+1:]
+	}
+
+	if s == "space" {
+		s = " "
+	}
+	k.Key = s
+	k.Code = KeyCode(s)
+
+	if k.Code > 0 && k.Code <= unicode.MaxRune && !k.Alt && !k.Ctrl && !k.Shift && unicode.IsPrint(k.Code) {
+		k.Text = s
+	}
+
+	return k
+}
+
+// KeyboardEnhancementsMsg reports which kitty keyboard protocol features the
+// terminal has enabled. It is sent by engines that request keyboard
+// enhancements (see EngineConfig.EnableKeyboardEnhancements).
+type KeyboardEnhancementsMsg struct {
+	// Flags is the kitty progressive enhancement bitmask.
+	Flags int
+}
+
+// Kitty progressive enhancement flags.
+const (
+	KittyDisambiguateEscapeCodes = 1 << iota
+	KittyReportEventTypes
+	KittyReportAlternateKeys
+	KittyReportAllKeysAsEscapeCodes
+	KittyReportAssociatedText
+)
+
+// SupportsKeyDisambiguation reports whether keys like ctrl+i and tab are
+// reported distinctly.
+func (m KeyboardEnhancementsMsg) SupportsKeyDisambiguation() bool {
+	return m.Flags&KittyDisambiguateEscapeCodes != 0
+}
+
+// SupportsKeyReleases reports whether key release and repeat events are
+// reported.
+func (m KeyboardEnhancementsMsg) SupportsKeyReleases() bool {
+	return m.Flags&KittyReportEventTypes != 0
+}
@@ -0,0 +1,273 @@
+package render
+
+import "sync"
+
+// MouseAction represents the kind of mouse event.
+type MouseAction int
+
+const (
+	// MousePress is a mouse button press.
+	MousePress MouseAction = iota
+	// MouseRelease is a mouse button release.
+	MouseRelease
+	// MouseMotion is a mouse movement, with or without a button held.
+	MouseMotion
+	// MouseWheel is a scroll wheel movement.
+	MouseWheel
+)
+
+// String returns the action name.
+func (a MouseAction) String() string {
+	switch a {
+	case MousePress:
+		return "press"
+	case MouseRelease:
+		return "release"
+	case MouseMotion:
+		return "motion"
+	case MouseWheel:
+		return "wheel"
+	default:
+		return "unknown"
+	}
+}
+
+// MouseButton identifies the mouse button involved in an event.
+type MouseButton int
+
+const (
+	// MouseButtonNone is used for motion events without a button held.
+	MouseButtonNone MouseButton = iota
+	// MouseButtonLeft is the left (primary) button.
+	MouseButtonLeft
+	// MouseButtonMiddle is the middle button (pressing the wheel).
+	MouseButtonMiddle
+	// MouseButtonRight is the right (secondary) button.
+	MouseButtonRight
+	// MouseButtonWheelUp is the wheel scrolled up.
+	MouseButtonWheelUp
+	// MouseButtonWheelDown is the wheel scrolled down.
+	MouseButtonWheelDown
+	// MouseButtonWheelLeft is the wheel pushed left.
+	MouseButtonWheelLeft
+	// MouseButtonWheelRight is the wheel pushed right.
+	MouseButtonWheelRight
+	// MouseButtonBackward is the browser "back" button.
+	MouseButtonBackward
+	// MouseButtonForward is the browser "forward" button.
+	MouseButtonForward
+)
+
+// String returns the button name.
+func (b MouseButton) String() string {
+	switch b {
+	case MouseButtonNone:
+		return "none"
+	case MouseButtonLeft:
+		return "left"
+	case MouseButtonMiddle:
+		return "middle"
+	case MouseButtonRight:
+		return "right"
+	case MouseButtonWheelUp:
+		return "wheelup"
+	case MouseButtonWheelDown:
+		return "wheeldown"
+	case MouseButtonWheelLeft:
+		return "wheelleft"
+	case MouseButtonWheelRight:
+		return "wheelright"
+	case MouseButtonBackward:
+		return "backward"
+	case MouseButtonForward:
+		return "forward"
+	default:
+		return "unknown"
+	}
+}
+
+// MouseMsg represents a mouse input message.
+// X and Y are zero-based cell coordinates, with (0,0) being the upper left
+// corner of the screen.
+type MouseMsg struct {
+	X      int
+	Y      int
+	Button MouseButton
+	Action MouseAction
+	Alt    bool
+	Ctrl   bool
+	Shift  bool
+}
+
+// String returns the mouse event including modifiers, e.g. "ctrl+left press".
+func (m MouseMsg) String() string {
+	prefix := ""
+	if m.Alt {
+		prefix += "alt+"
+	}
+	if m.Ctrl {
+		prefix += "ctrl+"
+	}
+	if m.Shift {
+		prefix += "shift+"
+	}
+	if m.Action == MouseWheel {
+		return prefix + m.Button.String()
+	}
+	if m.Button == MouseButtonNone {
+		return prefix + m.Action.String()
+	}
+	return prefix + m.Button.String() + " " + m.Action.String()
+}
+
+// IsWheel returns true if this is a scroll wheel event.
+func (m MouseMsg) IsWheel() bool {
+	return m.Action == MouseWheel
+}
+
+// IsClick returns true if this is a left button press.
+func (m MouseMsg) IsClick() bool {
+	return m.Action == MousePress && m.Button == MouseButtonLeft
+}
+
+// ClickHandler is implemented by components that react to clicks.
+// line and col are relative to the top-left corner of the component's region.
+// It matches the ClickHandler interface in ui/components/messages.
+type ClickHandler interface {
+	HandleClick(line, col int) Cmd
+}
+
+// MouseHandler is implemented by components that want every mouse event
+// inside their region, not just clicks. The message coordinates are
+// translated to be relative to the region.
+type MouseHandler interface {
+	HandleMouse(msg MouseMsg) Cmd
+}
+
+// Region is a rectangular area of the screen in cell coordinates.
+type Region struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Contains reports whether the cell (x, y) lies inside the region.
+func (r Region) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// HitRegion associates a screen region with a handler.
+type HitRegion struct {
+	// ID identifies the region, e.g. a message ID.
+	ID string
+	// Region is the screen area covered by the handler.
+	Region Region
+	// Z orders overlapping regions; higher values are on top.
+	Z int
+	// Handler receives events inside the region. It should implement
+	// ClickHandler, MouseHandler or both.
+	Handler any
+}
+
+// HitMap routes mouse events to the components registered under them.
+// Components are typically registered during View and the map is reset at
+// the start of each frame with Clear.
+type HitMap struct {
+	mu      sync.RWMutex
+	regions []HitRegion
+}
+
+// NewHitMap creates an empty hit map.
+func NewHitMap() *HitMap {
+	return &HitMap{}
+}
+
+// Register adds a handler for the given region.
+// Regions registered later win over earlier ones with the same Z.
+func (h *HitMap) Register(id string, region Region, handler any) {
+	h.RegisterZ(id, region, 0, handler)
+}
+
+// RegisterZ adds a handler for the given region with an explicit Z order.
+func (h *HitMap) RegisterZ(id string, region Region, z int, handler any) {
+	if region.Width <= 0 || region.Height <= 0 || handler == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.regions = append(h.regions, HitRegion{ID: id, Region: region, Z: z, Handler: handler})
+}
+
+// Remove removes all regions registered under id.
+func (h *HitMap) Remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kept := h.regions[:0]
+	for _, r := range h.regions {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	h.regions = kept
+}
+
+// Clear removes all registered regions.
+func (h *HitMap) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.regions = h.regions[:0]
+}
+
+// Len returns the number of registered regions.
+func (h *HitMap) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.regions)
+}
+
+// HitTest returns the topmost region containing the cell (x, y).
+func (h *HitMap) HitTest(x, y int) (HitRegion, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	found := -1
+	for i, r := range h.regions {
+		if !r.Region.Contains(x, y) {
+			continue
+		}
+		if found == -1 || r.Z >= h.regions[found].Z {
+			found = i
+		}
+	}
+	if found == -1 {
+		return HitRegion{}, false
+	}
+	return h.regions[found], true
+}
+
+// Dispatch routes a mouse message to the topmost handler under the pointer.
+// MouseHandlers receive every event with region-relative coordinates;
+// ClickHandlers receive left button presses. It returns false if no handler
+// accepted the event.
+func (h *HitMap) Dispatch(msg MouseMsg) (Cmd, bool) {
+	region, ok := h.HitTest(msg.X, msg.Y)
+	if !ok {
+		return nil, false
+	}
+
+	if mh, ok := region.Handler.(MouseHandler); ok {
+		local := msg
+		local.X -= region.Region.X
+		local.Y -= region.Region.Y
+		return mh.HandleMouse(local), true
+	}
+
+	if ch, ok := region.Handler.(ClickHandler); ok && msg.IsClick() {
+		line := msg.Y - region.Region.Y
+		col := msg.X - region.Region.X
+		return ch.HandleClick(line, col), true
+	}
+
+	return nil, false
+}
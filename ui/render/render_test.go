@@ -1,10 +1,26 @@
 package render
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
+var errTest = errors.New("test error")
+
 func TestEngineRegistry(t *testing.T) {
 	// Test unregistered engine first
 	t.Run("UnregisteredEngine", func(t *testing.T) {
@@ -66,6 +82,22 @@ func TestDefaultConfig(t *testing.T) {
 	}
 }
 
+func TestInlineConfig(t *testing.T) {
+	config := InlineConfig(2)
+	if config.EnableAltScreen || config.InlineHeight != 2 {
+		t.Fatalf("unexpected inline config: %+v", config)
+	}
+	if got := config.view("a\nb\nc"); got != "b\nc" {
+		t.Errorf("expected the last 2 lines, got %q", got)
+	}
+	if got := config.view("a\nb"); got != "a\nb" {
+		t.Errorf("expected short views unchanged, got %q", got)
+	}
+	if got := DefaultConfig().view("a\nb\nc"); got != "a\nb\nc" {
+		t.Errorf("expected alt screen views unchanged, got %q", got)
+	}
+}
+
 func TestDirectEngine(t *testing.T) {
 	t.Run("NewDirectEngine", func(t *testing.T) {
 		engine := NewDirectEngine(nil)
@@ -152,6 +184,121 @@ func TestDirectEngine(t *testing.T) {
 		}
 	})
 
+	t.Run("Cursor", func(t *testing.T) {
+		engine := NewDirectEngine(nil).(*DirectEngine)
+		model := &cursorModel{text: "abc", cursor: &Cursor{X: 4, Shape: CursorBar}}
+		if err := engine.Start(model); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer engine.Stop()
+
+		if got := engine.Output(); got != "> abc" {
+			t.Errorf("expected the marker to be removed, got %q", got)
+		}
+		if got := engine.Cursor(); got == nil || *got != (Cursor{X: 4, Shape: CursorBar}) {
+			t.Errorf("expected the bar cursor at (4, 0), got %+v", got)
+		}
+	})
+
+	t.Run("Inline", func(t *testing.T) {
+		model := &cmdModel{init: Sequence(Println("first"), Printf("%d done", 2))}
+		engine := NewDirectEngine(InlineConfig(0)).(*DirectEngine)
+		if err := engine.Start(model); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer engine.Stop()
+		engine.Wait()
+
+		if got := engine.Printed(); !slices.Equal(got, []string{"first", "2 done"}) {
+			t.Errorf("expected printed lines, got %q", got)
+		}
+		for _, msg := range model.received() {
+			if _, ok := msg.(PrintLineMsg); ok {
+				t.Errorf("expected PrintLineMsg to be handled by the engine")
+			}
+		}
+
+		// The alternate screen has no scrollback
+		alt := NewDirectEngine(nil).(*DirectEngine)
+		if err := alt.Start(&cmdModel{init: Println("lost")}); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer alt.Stop()
+		alt.Wait()
+		if got := alt.Printed(); len(got) != 0 {
+			t.Errorf("expected no printed lines in alt screen, got %q", got)
+		}
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		var out strings.Builder
+		config := DefaultConfig()
+		config.Output = &out
+		c := exec.Command("echo", "from child")
+		model := &cmdModel{init: Sequence(
+			ExecProcess(c, func(err error) Msg { return ErrorMsg{Error: err} }),
+			Suspend(),
+		)}
+		engine := NewDirectEngine(config).(*DirectEngine)
+		if err := engine.Start(model); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer engine.Stop()
+		engine.Wait()
+
+		if out.String() != "from child\n" {
+			t.Errorf("expected command output on the configured writer, got %q", out.String())
+		}
+		got := model.received()
+		if len(got) != 2 {
+			t.Fatalf("expected exec result and resume, got %#v", got)
+		}
+		if msg, ok := got[0].(ErrorMsg); !ok || msg.Error != nil {
+			t.Errorf("expected successful exec callback, got %#v", got[0])
+		}
+		if _, ok := got[1].(ResumeMsg); !ok {
+			t.Errorf("expected ResumeMsg, got %#v", got[1])
+		}
+	})
+
+	t.Run("Probe", func(t *testing.T) {
+		var out strings.Builder
+		config := DefaultConfig()
+		config.Input = strings.NewReader("\x1b[?62;4c")
+		config.Output = &out
+		model := &cmdModel{init: ProbeCapabilities(time.Second)}
+		engine := NewDirectEngine(config).(*DirectEngine)
+		if err := engine.Start(model); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		defer engine.Stop()
+		engine.Wait()
+
+		if out.String() != termcap.Query {
+			t.Errorf("expected the probe query on the configured writer, got %q", out.String())
+		}
+		got := model.received()
+		if len(got) != 1 {
+			t.Fatalf("expected one CapabilitiesMsg, got %#v", got)
+		}
+		msg, ok := got[0].(CapabilitiesMsg)
+		if !ok || !msg.Capabilities.Probed || !msg.Capabilities.Sixel() {
+			t.Errorf("expected probed sixel support, got %#v", got[0])
+		}
+		if termcap.Current().Sixel() {
+			t.Error("expected the probe to leave the process-wide capabilities alone")
+		}
+
+		// A probe nobody answers reports the earlier answer again
+		if err := engine.Send(ProbeMsg{Timeout: 50 * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+		got = model.received()
+		if msg, ok := got[len(got)-1].(CapabilitiesMsg); !ok || !msg.Capabilities.Sixel() {
+			t.Errorf("expected the kept capabilities, got %#v", got[len(got)-1])
+		}
+	})
+
 	t.Run("DoubleStart", func(t *testing.T) {
 		engine := NewDirectEngine(nil).(*DirectEngine)
 		model := NewTestModel("hello")
@@ -187,6 +334,27 @@ func TestKeyMsg(t *testing.T) {
 		}
 	})
 
+	t.Run("TextOmitsShift", func(t *testing.T) {
+		msg := KeyMsg{Key: "A", Text: "A", Shift: true, Code: 'a'}
+		if got := msg.String(); got != "A" {
+			t.Errorf("expected %q, got %q", "A", got)
+		}
+	})
+
+	t.Run("ExtendedModifiers", func(t *testing.T) {
+		msg := KeyMsg{Key: "up", Ctrl: true, Shift: true, Super: true}
+		if got := msg.String(); got != "ctrl+shift+super+up" {
+			t.Errorf("expected %q, got %q", "ctrl+shift+super+up", got)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		msg := KeyMsg{Key: "a", Type: KeyRelease}
+		if !msg.IsRelease() {
+			t.Error("expected IsRelease to return true")
+		}
+	})
+
 	t.Run("IsMouse", func(t *testing.T) {
 		msg := KeyMsg{Key: "a"}
 		if msg.IsMouse() {
@@ -334,3 +502,1014 @@ func TestCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestMouseMsg(t *testing.T) {
+	tests := []struct {
+		msg      MouseMsg
+		expected string
+	}{
+		{MouseMsg{Button: MouseButtonLeft, Action: MousePress}, "left press"},
+		{MouseMsg{Button: MouseButtonRight, Action: MouseRelease, Ctrl: true}, "ctrl+right release"},
+		{MouseMsg{Button: MouseButtonWheelUp, Action: MouseWheel}, "wheelup"},
+		{MouseMsg{Action: MouseMotion, Shift: true}, "shift+motion"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.msg.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+
+	if !(MouseMsg{Button: MouseButtonLeft, Action: MousePress}).IsClick() {
+		t.Error("expected left press to be a click")
+	}
+	if (MouseMsg{Button: MouseButtonRight, Action: MousePress}).IsClick() {
+		t.Error("expected right press not to be a click")
+	}
+}
+
+type testClickHandler struct {
+	line, col int
+	clicks    int
+}
+
+func (h *testClickHandler) HandleClick(line, col int) Cmd {
+	h.line, h.col = line, col
+	h.clicks++
+	return None()
+}
+
+type testMouseHandler struct {
+	last MouseMsg
+}
+
+func (h *testMouseHandler) HandleMouse(msg MouseMsg) Cmd {
+	h.last = msg
+	return nil
+}
+
+func TestHitMap(t *testing.T) {
+	t.Run("ClickRouting", func(t *testing.T) {
+		hm := NewHitMap()
+		first := &testClickHandler{}
+		second := &testClickHandler{}
+		hm.Register("first", Region{X: 0, Y: 0, Width: 10, Height: 3}, first)
+		hm.Register("second", Region{X: 0, Y: 3, Width: 10, Height: 5}, second)
+
+		_, ok := hm.Dispatch(MouseMsg{X: 4, Y: 5, Button: MouseButtonLeft, Action: MousePress})
+		if !ok {
+			t.Fatal("expected click to be dispatched")
+		}
+		if second.clicks != 1 || first.clicks != 0 {
+			t.Errorf("expected click on second only, got first=%d second=%d", first.clicks, second.clicks)
+		}
+		if second.line != 2 || second.col != 4 {
+			t.Errorf("expected local (2,4), got (%d,%d)", second.line, second.col)
+		}
+	})
+
+	t.Run("IgnoresNonClicks", func(t *testing.T) {
+		hm := NewHitMap()
+		h := &testClickHandler{}
+		hm.Register("item", Region{X: 0, Y: 0, Width: 5, Height: 5}, h)
+
+		if _, ok := hm.Dispatch(MouseMsg{X: 1, Y: 1, Action: MouseMotion}); ok {
+			t.Error("expected motion not to reach a ClickHandler")
+		}
+		if _, ok := hm.Dispatch(MouseMsg{X: 9, Y: 9, Button: MouseButtonLeft, Action: MousePress}); ok {
+			t.Error("expected click outside all regions to miss")
+		}
+	})
+
+	t.Run("ZOrder", func(t *testing.T) {
+		hm := NewHitMap()
+		below := &testClickHandler{}
+		above := &testClickHandler{}
+		hm.RegisterZ("above", Region{X: 2, Y: 2, Width: 4, Height: 4}, 1, above)
+		hm.Register("below", Region{X: 0, Y: 0, Width: 10, Height: 10}, below)
+
+		region, ok := hm.HitTest(3, 3)
+		if !ok || region.ID != "above" {
+			t.Errorf("expected topmost region 'above', got %q", region.ID)
+		}
+	})
+
+	t.Run("MouseHandler", func(t *testing.T) {
+		hm := NewHitMap()
+		h := &testMouseHandler{}
+		hm.Register("pane", Region{X: 10, Y: 5, Width: 20, Height: 10}, h)
+
+		hm.Dispatch(MouseMsg{X: 12, Y: 8, Button: MouseButtonWheelDown, Action: MouseWheel})
+		if h.last.X != 2 || h.last.Y != 3 || !h.last.IsWheel() {
+			t.Errorf("unexpected translated event: %+v", h.last)
+		}
+	})
+
+	t.Run("RemoveAndClear", func(t *testing.T) {
+		hm := NewHitMap()
+		hm.Register("a", Region{Width: 1, Height: 1}, &testClickHandler{})
+		hm.Register("b", Region{Width: 1, Height: 1}, &testClickHandler{})
+		hm.Register("empty", Region{}, &testClickHandler{})
+		if hm.Len() != 2 {
+			t.Errorf("expected 2 regions, got %d", hm.Len())
+		}
+		hm.Remove("a")
+		if hm.Len() != 1 {
+			t.Errorf("expected 1 region after remove, got %d", hm.Len())
+		}
+		hm.Clear()
+		if hm.Len() != 0 {
+			t.Errorf("expected 0 regions after clear, got %d", hm.Len())
+		}
+	})
+}
+
+func TestPasteMsg(t *testing.T) {
+	paste := PasteMsg{Text: "a\tb\r\nc\x1b\rd\n"}
+	if got := paste.Lines(); !slices.Equal(got, []string{"a    b", "c", "d", ""}) {
+		t.Errorf("unexpected lines %q", got)
+	}
+	if got := paste.SingleLine(); got != "a    b c d" {
+		t.Errorf("unexpected single line %q", got)
+	}
+
+	msgs := ParseInput([]byte("x\x1b[200~one\rtwo\x1b[201~y"))
+	if len(msgs) != 3 {
+		t.Fatalf("expected key, paste and key, got %#v", msgs)
+	}
+	if msg, ok := msgs[1].(PasteMsg); !ok || msg.Text != "one\rtwo" {
+		t.Errorf("expected paste message, got %#v", msgs[1])
+	}
+	if msg, ok := msgs[2].(KeyMsg); !ok || msg.Key != "y" {
+		t.Errorf("expected key after the paste, got %#v", msgs[2])
+	}
+
+	if msg, ok := translateUVEvent(uv.PasteEvent{Content: "hi"}).(PasteMsg); !ok || msg.Text != "hi" {
+		t.Errorf("expected paste message, got %#v", msg)
+	}
+
+	model := &cmdModel{}
+	adapter := &teaAdapter{internal: model, ctx: context.Background(), config: DefaultConfig()}
+	adapter.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a\nb"), Paste: true})
+	if got := model.received(); len(got) != 1 || got[0] != (PasteMsg{Text: "a\nb"}) {
+		t.Errorf("expected Bubbletea paste as PasteMsg, got %#v", got)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want KeyMsg
+	}{
+		{"a", KeyMsg{Key: "a", Code: 'a', Text: "a"}},
+		{"ctrl+shift+up", KeyMsg{Key: "up", Code: KeyUp, Ctrl: true, Shift: true}},
+		{"shift+ctrl+up", KeyMsg{Key: "up", Code: KeyUp, Ctrl: true, Shift: true}},
+		{"alt+enter", KeyMsg{Key: "enter", Code: KeyEnter, Alt: true}},
+		{"space", KeyMsg{Key: " ", Code: ' ', Text: " "}},
+		{"+", KeyMsg{Key: "+", Code: '+', Text: "+"}},
+		{"ctrl++", KeyMsg{Key: "+", Code: '+', Ctrl: true}},
+		{"f12", KeyMsg{Key: "f12", Code: KeyF12}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseKey(tt.in); got != tt.want {
+			t.Errorf("ParseKey(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	// Canonical strings round-trip.
+	for _, s := range []string{"alt+ctrl+a", "ctrl+shift+up", "shift+tab", "esc", " ", "G"} {
+		if got := ParseKey(s).String(); got != s {
+			t.Errorf("ParseKey(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func TestFromTeaKey(t *testing.T) {
+	tests := []struct {
+		key  tea.KeyMsg
+		want string
+		code rune
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}, "q", 'q'},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")}, "G", 'G'},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true}, "alt+x", 'x'},
+		{tea.KeyMsg{Type: tea.KeyCtrlC}, "ctrl+c", 'c'},
+		{tea.KeyMsg{Type: tea.KeyCtrlShiftUp}, "ctrl+shift+up", KeyUp},
+		{tea.KeyMsg{Type: tea.KeyShiftTab}, "shift+tab", KeyTab},
+		{tea.KeyMsg{Type: tea.KeyEnter, Alt: true}, "alt+enter", KeyEnter},
+		{tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, " ", ' '},
+	}
+
+	for _, tt := range tests {
+		got := fromTeaKey(tt.key)
+		if got.String() != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got.String())
+		}
+		if got.String() != tt.key.String() {
+			t.Errorf("expected string compatible with bubbletea %q, got %q", tt.key.String(), got.String())
+		}
+		if got.Code != tt.code {
+			t.Errorf("%q: expected code %d, got %d", tt.want, tt.code, got.Code)
+		}
+	}
+}
+
+func TestTeaAdapter(t *testing.T) {
+	model := &cmdModel{}
+	adapter := &teaAdapter{internal: model, ctx: context.Background(), config: DefaultConfig()}
+
+	for _, msg := range []tea.Msg{
+		PrintLineMsg{Text: "line"},
+		ExecMsg{Command: &osExecCommand{Cmd: exec.Command("true")}},
+		SuspendMsg{},
+	} {
+		if _, cmd := adapter.Update(msg); cmd == nil {
+			t.Errorf("expected %T to become a Bubbletea command", msg)
+		}
+	}
+	adapter.Update(tea.ResumeMsg{})
+	adapter.Update(tea.BlurMsg{})
+
+	got := model.received()
+	if len(got) != 2 {
+		t.Fatalf("expected only the resume and the blur to reach the model, got %#v", got)
+	}
+	if _, ok := got[0].(ResumeMsg); !ok {
+		t.Errorf("expected ResumeMsg, got %#v", got[0])
+	}
+	if got[1] != (FocusMsg{Focused: false}) {
+		t.Errorf("expected an unfocused FocusMsg, got %#v", got[1])
+	}
+}
+
+func TestFromUVKey(t *testing.T) {
+	t.Run("ShiftedText", func(t *testing.T) {
+		got := fromUVKey(uv.Key{Code: 'a', ShiftedCode: 'A', Text: "A", Mod: uv.ModShift}, KeyPress)
+		if got.String() != "A" || got.Code != 'a' || got.ShiftedCode != 'A' || !got.Shift {
+			t.Errorf("unexpected key: %+v", got)
+		}
+	})
+
+	t.Run("Modifiers", func(t *testing.T) {
+		got := fromUVKey(uv.Key{Code: uv.KeyUp, Mod: uv.ModCtrl | uv.ModShift}, KeyPress)
+		if got.String() != "ctrl+shift+up" || got.Code != KeyUp {
+			t.Errorf("unexpected key: %+v (%s)", got, got.String())
+		}
+	})
+
+	t.Run("ReleaseAndRepeat", func(t *testing.T) {
+		got := fromUVKey(uv.Key{Code: 'x', Text: "x", IsRepeat: true}, KeyRelease)
+		if !got.IsRelease() || !got.IsRepeat() || got.String() != "x" {
+			t.Errorf("unexpected key: %+v", got)
+		}
+	})
+
+	t.Run("Space", func(t *testing.T) {
+		got := fromUVKey(uv.Key{Code: uv.KeySpace, Text: " "}, KeyPress)
+		if got.String() != " " || got.Code != ' ' {
+			t.Errorf("unexpected key: %+v", got)
+		}
+	})
+
+	t.Run("CtrlLetter", func(t *testing.T) {
+		got := fromUVKey(uv.Key{Code: 'c', Mod: uv.ModCtrl}, KeyPress)
+		if got.String() != "ctrl+c" || got.Text != "" {
+			t.Errorf("unexpected key: %+v", got)
+		}
+	})
+}
+
+func TestUltravioletEngine(t *testing.T) {
+	t.Run("NotRunning", func(t *testing.T) {
+		engine := NewUltravioletEngine(nil)
+		if engine.Running() {
+			t.Error("expected engine not to be running")
+		}
+		if err := engine.Send(KeyMsg{Key: "a"}); err == nil {
+			t.Error("expected error sending to a stopped engine")
+		}
+		if err := engine.Stop(); err != nil {
+			t.Errorf("expected Stop on idle engine to succeed, got %v", err)
+		}
+	})
+
+	t.Run("TranslateEvents", func(t *testing.T) {
+		if msg, ok := translateUVEvent(uv.WindowSizeEvent{Width: 80, Height: 24}).(WindowSizeMsg); !ok || msg.Width != 80 || msg.Height != 24 {
+			t.Errorf("unexpected size message: %#v", msg)
+		}
+		if msg, ok := translateUVEvent(uv.MouseClickEvent{X: 3, Y: 4, Button: uv.MouseLeft}).(MouseMsg); !ok || !msg.IsClick() || msg.X != 3 {
+			t.Errorf("unexpected mouse message: %#v", msg)
+		}
+		if msg, ok := translateUVEvent(uv.BlurEvent{}).(FocusMsg); !ok || msg.Focused {
+			t.Errorf("unexpected blur message: %#v", msg)
+		}
+		if msgs := ParseInput([]byte("\x1b[I")); len(msgs) != 1 || msgs[0] != (FocusMsg{Focused: true}) {
+			t.Errorf("expected a focus message, got %#v", msgs)
+		}
+		if msg := translateUVEvent(uv.UnknownEvent("x")); msg != nil {
+			t.Errorf("expected unknown events to be dropped, got %#v", msg)
+		}
+	})
+
+	t.Run("CommandsQueueResults", func(t *testing.T) {
+		e := NewUltravioletEngine(nil).(*UltravioletEngine)
+		e.notify = make(chan struct{}, 1)
+		e.cmds = NewRuntime(context.Background(), e.enqueue)
+		defer e.cmds.Stop()
+
+		e.cmds.Run(Batch(
+			func() Msg { return CustomMsg{Type: "done"} },
+			Command(func() error { return errTest }),
+			Quit(),
+		))
+
+		var msgs []Msg
+		for len(msgs) < 3 {
+			<-e.notify
+			msgs = append(msgs, e.drain()...)
+		}
+
+		var custom, failed, quit bool
+		for _, msg := range msgs {
+			switch m := msg.(type) {
+			case CustomMsg:
+				custom = m.Type == "done"
+			case ErrorMsg:
+				failed = m.Error == errTest
+			case QuitMsg:
+				quit = true
+			}
+		}
+		if !custom || !failed || !quit {
+			t.Errorf("expected custom, error and quit messages, got %#v", msgs)
+		}
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		type session struct {
+			in     *io.PipeWriter
+			out    *syncBuffer
+			model  *echoModel
+			engine Engine
+			done   chan error
+		}
+		// Color profiles come from each session's TERM alone
+		t.Setenv("COLORTERM", "")
+		t.Setenv("NO_COLOR", "")
+		t.Setenv("CLICOLOR_FORCE", "")
+		start := func(name, term string, width, height int) *session {
+			r, w := io.Pipe()
+			s := &session{in: w, out: &syncBuffer{}, model: &echoModel{name: name}, done: make(chan error, 1)}
+			config := DefaultConfig()
+			config.Input = r
+			config.Output = s.out
+			config.Width, config.Height = width, height
+			config.Term = term
+			s.engine = NewUltravioletEngine(config)
+			go func() { s.done <- s.engine.Start(s.model) }()
+			return s
+		}
+		waitFor := func(s *session, want string) {
+			t.Helper()
+			deadline := time.Now().Add(5 * time.Second)
+			for !strings.Contains(s.out.String(), want) {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %q in output, got %q", want, s.out.String())
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		waitDone := func(s *session) {
+			t.Helper()
+			select {
+			case err := <-s.done:
+				if err != nil {
+					t.Errorf("%s: Start returned %v", s.model.name, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: engine did not stop", s.model.name)
+			}
+		}
+
+		one := start("one", "xterm-256color", 40, 5)
+		two := start("two", "linux", 60, 8)
+		waitFor(one, "one 40x5")
+		waitFor(two, "two 60x8")
+
+		// Input and resizes only reach their own session
+		io.WriteString(one.in, "ab")
+		io.WriteString(two.in, "xyz")
+		waitFor(one, "one 40x5 ab")
+		waitFor(two, "two 60x8 xyz")
+		two.engine.Resize(70, 9)
+		waitFor(two, "two 70x9 xyz")
+		if strings.Contains(one.out.String(), "xyz") || strings.Contains(two.out.String(), "ab") {
+			t.Error("expected sessions not to see each other's input")
+		}
+
+		// Quitting one session leaves the other running
+		io.WriteString(one.in, "q")
+		waitDone(one)
+		if one.model.profile != colorprofile.ANSI256 {
+			t.Errorf("expected the first session to get a 256 color profile, got %s", one.model.profile)
+		}
+		if !two.engine.Running() {
+			t.Error("expected the second session to keep running")
+		}
+		if !strings.HasSuffix(one.out.String(), ansi.ResetModeAltScreenSaveCursor+ansi.SetModeTextCursorEnable) {
+			t.Errorf("expected the alternate screen to be restored, got %q", one.out.String())
+		}
+
+		// A client disconnecting ends its session
+		two.in.Close()
+		waitDone(two)
+		if two.model.profile != colorprofile.ANSI {
+			t.Errorf("expected the second session to get a 16 color profile, got %s", two.model.profile)
+		}
+	})
+
+	t.Run("FocusReporting", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+		out := &syncBuffer{}
+		config := DefaultConfig()
+		config.Input, config.Output = r, out
+		config.Width, config.Height = 40, 5
+		config.EnableFocusReporting = true
+		engine := NewUltravioletEngine(config)
+		model := &cmdModel{}
+		done := make(chan error, 1)
+		go func() { done <- engine.Start(model) }()
+
+		go w.Write([]byte("\x1b[O\x1b[I"))
+		deadline := time.Now().Add(5 * time.Second)
+		var focus []Msg
+		for len(focus) < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a blur and a focus message, got %#v", model.received())
+			}
+			time.Sleep(5 * time.Millisecond)
+			focus = slices.DeleteFunc(model.received(), func(msg Msg) bool {
+				_, ok := msg.(FocusMsg)
+				return !ok
+			})
+		}
+		if focus[0] != (FocusMsg{Focused: false}) || focus[1] != (FocusMsg{Focused: true}) {
+			t.Errorf("expected blur then focus, got %#v", focus)
+		}
+
+		engine.Stop()
+		<-done
+		if !strings.Contains(out.String(), ansi.SetModeFocusEvent) || !strings.Contains(out.String(), ansi.ResetModeFocusEvent) {
+			t.Errorf("expected focus reporting to be enabled and reset, got %q", out.String())
+		}
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+		out := &syncBuffer{}
+		config := DefaultConfig()
+		config.Input, config.Output = r, out
+		config.Width, config.Height = 40, 5
+		engine := NewUltravioletEngine(config)
+		model := &cursorModel{text: "abc", cursor: &Cursor{X: 3, Shape: CursorBar}}
+		done := make(chan error, 1)
+		go func() { done <- engine.Start(model) }()
+
+		waitFor := func(what, seq string) {
+			t.Helper()
+			deadline := time.Now().Add(5 * time.Second)
+			for !strings.Contains(out.String(), seq) {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %s in output %q", what, out.String())
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		waitFor("a steady bar cursor", ansi.SetCursorStyle(6))
+		waitFor("the cursor to be shown", ansi.SetModeTextCursorEnable)
+		if strings.Contains(out.String(), "taproot-cursor") {
+			t.Errorf("expected the marker to be removed, got %q", out.String())
+		}
+
+		engine.Stop()
+		<-done
+		if !strings.Contains(out.String(), ansi.SetCursorStyle(0)) {
+			t.Errorf("expected the cursor style to be reset, got %q", out.String())
+		}
+	})
+
+	t.Run("FrameStats", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+		config := DefaultConfig()
+		config.Input, config.Output = r, &syncBuffer{}
+		config.Width, config.Height = 40, 5
+		config.MaxFPS = 10
+		engine := NewUltravioletEngine(config).(*UltravioletEngine)
+		model := &viewRecorder{Model: &echoModel{name: "fps"}}
+		done := make(chan error, 1)
+		go func() { done <- engine.Start(model) }()
+
+		waitFor := func(what string, ok func(FrameStats) bool) {
+			t.Helper()
+			deadline := time.Now().Add(5 * time.Second)
+			for !ok(engine.FrameStats()) {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %s, got %+v and view %q", what, engine.FrameStats(), model.last())
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+		waitFor("the first frame", func(FrameStats) bool { return model.last() == "fps 40x5 " })
+		before := engine.FrameStats()
+
+		// A burst of updates is drawn in a frame or two, the last one once
+		// the frame interval has passed
+		for range 20 {
+			engine.Send(KeyMsg{Key: "a", Code: 'a', Text: "a"})
+		}
+		want := "fps 40x5 " + strings.Repeat("a", 20)
+		waitFor("the burst to be drawn", func(FrameStats) bool { return model.last() == want })
+		stats := engine.FrameStats()
+		if drawn := stats.Rendered - before.Rendered; drawn > 3 {
+			t.Errorf("expected the burst to be coalesced, drew %d frames: %+v", drawn, stats)
+		}
+		if stats.Coalesced == 0 {
+			t.Errorf("expected coalesced requests, got %+v", stats)
+		}
+
+		// Messages that leave the view unchanged are not drawn
+		engine.Send(CustomMsg{Type: "noop"})
+		waitFor("a skipped frame", func(s FrameStats) bool { return s.Skipped > stats.Skipped })
+		if got := engine.FrameStats().Rendered; got != stats.Rendered {
+			t.Errorf("expected no new frame, rendered %d then %d", stats.Rendered, got)
+		}
+
+		engine.Stop()
+		<-done
+	})
+}
+
+// viewRecorder remembers the last view of the model it wraps, for tests
+// to read while an engine runs it.
+type viewRecorder struct {
+	Model
+	mu   sync.Mutex
+	view string
+}
+
+func (m *viewRecorder) Update(msg any) (Model, Cmd) {
+	_, cmd := m.Model.Update(msg)
+	return m, cmd
+}
+
+func (m *viewRecorder) View() string {
+	view := m.Model.View()
+	m.mu.Lock()
+	m.view = view
+	m.mu.Unlock()
+	return view
+}
+
+func (m *viewRecorder) last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.view
+}
+
+// echoModel shows its session name, size and the text typed so far, and
+// quits on "q".
+type echoModel struct {
+	name          string
+	width, height int
+	text          string
+	profile       colorprofile.Profile
+}
+
+func (m *echoModel) Init() Cmd { return nil }
+
+func (m *echoModel) Update(msg any) (Model, Cmd) {
+	switch msg := msg.(type) {
+	case WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case ColorProfileMsg:
+		m.profile = msg.Profile
+	case KeyMsg:
+		if msg.String() == "q" {
+			return m, Quit()
+		}
+		m.text += msg.Text
+	}
+	return m, nil
+}
+
+func (m *echoModel) View() string {
+	return fmt.Sprintf("%s %dx%d %s", m.name, m.width, m.height, m.text)
+}
+
+// cursorModel shows a prompt with the terminal cursor placed on it.
+type cursorModel struct {
+	text   string
+	cursor *Cursor
+}
+
+func (m *cursorModel) Init() Cmd                   { return nil }
+func (m *cursorModel) Update(msg any) (Model, Cmd) { return m, nil }
+func (m *cursorModel) View() string {
+	return PlaceCursor("> "+m.text, m.cursor)
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("PlaceAndFind", func(t *testing.T) {
+		want := Cursor{X: 3, Y: 1, Shape: CursorUnderline, Blink: true}
+		view := PlaceCursor("first\n\x1b[1mab\x1b[0mcd", &want)
+		if ansi.StringWidth(strings.Split(view, "\n")[1]) != 4 {
+			t.Errorf("expected the marker to have no width, got %q", view)
+		}
+		stripped, got := FindCursor(view)
+		if stripped != "first\n\x1b[1mab\x1b[0mcd" {
+			t.Errorf("expected the marker to be removed, got %q", stripped)
+		}
+		if got == nil || *got != want {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("WideCharacters", func(t *testing.T) {
+		// The cursor lands on the cell after the wide character
+		_, got := FindCursor(PlaceCursor("日本", &Cursor{X: 2}))
+		if got == nil || got.X != 2 {
+			t.Errorf("expected the cursor at column 2, got %+v", got)
+		}
+		// A column inside a wide character moves to its start
+		_, got = FindCursor(PlaceCursor("日本", &Cursor{X: 3}))
+		if got == nil || got.X != 2 {
+			t.Errorf("expected the cursor at column 2, got %+v", got)
+		}
+	})
+
+	t.Run("PastTheEnd", func(t *testing.T) {
+		view, got := FindCursor(PlaceCursor("ab", &Cursor{X: 4}))
+		if view != "ab  " || got == nil || got.X != 4 {
+			t.Errorf("expected the line padded to the cursor, got %q and %+v", view, got)
+		}
+		if view := PlaceCursor("ab", &Cursor{Y: 1}); view != "ab" {
+			t.Errorf("expected a cursor below the view to be ignored, got %q", view)
+		}
+		if view := PlaceCursor("ab", nil); view != "ab" {
+			t.Errorf("expected a nil cursor to be ignored, got %q", view)
+		}
+	})
+
+	t.Run("Composited", func(t *testing.T) {
+		// A child's marker keeps its cell when the parent frames it
+		child := "ab" + Cursor{Shape: CursorBar}.Marker() + "c"
+		parent := "title\n| " + child + " |"
+		_, got := FindCursor(parent)
+		if got == nil || got.X != 4 || got.Y != 1 || got.Shape != CursorBar {
+			t.Errorf("expected a bar cursor at (4, 1), got %+v", got)
+		}
+	})
+
+	t.Run("FirstMarkerWins", func(t *testing.T) {
+		view := Cursor{}.Marker() + "a" + Cursor{Shape: CursorBar}.Marker() + "b"
+		stripped, got := FindCursor(view)
+		if stripped != "ab" || got == nil || got.X != 0 || got.Shape != CursorBlock {
+			t.Errorf("expected the first cursor, got %q and %+v", stripped, got)
+		}
+	})
+
+	t.Run("Style", func(t *testing.T) {
+		tests := []struct {
+			cursor Cursor
+			want   int
+		}{
+			{Cursor{Shape: CursorBlock, Blink: true}, 1},
+			{Cursor{Shape: CursorBlock}, 2},
+			{Cursor{Shape: CursorUnderline, Blink: true}, 3},
+			{Cursor{Shape: CursorUnderline}, 4},
+			{Cursor{Shape: CursorBar, Blink: true}, 5},
+			{Cursor{Shape: CursorBar}, 6},
+		}
+		for _, tt := range tests {
+			if got := tt.cursor.style(); got != tt.want {
+				t.Errorf("%s blink=%v: expected style %d, got %d", tt.cursor.Shape, tt.cursor.Blink, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("DrawCursor", func(t *testing.T) {
+		if got := drawCursor("abc", &Cursor{X: 1}); got != "a\x1b[7mb\x1b[27mc" {
+			t.Errorf("expected a reverse video cell, got %q", got)
+		}
+		if got := drawCursor("ab", &Cursor{X: 2, Shape: CursorUnderline, Blink: true}); got != "ab\x1b[4;5m \x1b[24;25m" {
+			t.Errorf("expected a blinking underlined cell after the text, got %q", got)
+		}
+
+		// The exported helpers work on views with markers
+		view := "a" + Cursor{}.Marker() + "bc"
+		if got := DrawCursor(view); got != "a\x1b[7mb\x1b[27mc" {
+			t.Errorf("expected the marker drawn as a cell, got %q", got)
+		}
+		if got := StripCursor(view); got != "abc" {
+			t.Errorf("expected the marker removed, got %q", got)
+		}
+	})
+}
+
+// syncBuffer is an output stream that can be read while an engine writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// cmdModel records the messages it receives and returns scripted commands.
+type cmdModel struct {
+	mu   sync.Mutex
+	init Cmd
+	msgs []Msg
+}
+
+func (m *cmdModel) Init() Cmd { return m.init }
+
+func (m *cmdModel) Update(msg any) (Model, Cmd) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msgs = append(m.msgs, msg)
+	return m, nil
+}
+
+func (m *cmdModel) View() string { return "" }
+
+func (m *cmdModel) received() []Msg {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.msgs)
+}
+
+// stepClock is a Clock that only moves when told to. AfterFunc records
+// the callback for the test to fire.
+type stepClock struct {
+	now   time.Time
+	after []func(time.Time)
+}
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+func (c *stepClock) AfterFunc(d time.Duration, f func(time.Time)) func() bool {
+	c.after = append(c.after, f)
+	return func() bool { return true }
+}
+
+// dirtyModel is a model that tracks its changes with Dirty.
+type dirtyModel struct {
+	Dirty
+	text  string
+	views int
+}
+
+func (m *dirtyModel) Init() Cmd                   { return nil }
+func (m *dirtyModel) Update(msg any) (Model, Cmd) { return m, nil }
+func (m *dirtyModel) View() string {
+	m.views++
+	return m.text
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("FrameRateCap", func(t *testing.T) {
+		clock := &stepClock{now: time.Unix(0, 0)}
+		woken := 0
+		s := NewScheduler(10, clock, func() { woken++ })
+		model := &echoModel{name: "m"}
+		var frames []string
+		draw := func(view string) { frames = append(frames, view) }
+
+		s.Request()
+		if !s.Due() || !s.Draw(model, draw) {
+			t.Fatal("expected the first frame to be drawn at once")
+		}
+
+		// Within the frame interval, requests wait for a wake-up
+		clock.now = clock.now.Add(30 * time.Millisecond)
+		model.text = "a"
+		s.Request()
+		model.text = "ab"
+		s.Request()
+		if s.Due() || len(clock.after) != 1 {
+			t.Fatalf("expected the frame to be held back, timers = %d", len(clock.after))
+		}
+		s.Due()
+		if len(clock.after) != 1 {
+			t.Error("expected a single wake-up per pending frame")
+		}
+
+		clock.now = clock.now.Add(70 * time.Millisecond)
+		clock.after[0](clock.now)
+		if woken != 1 || !s.Due() || !s.Draw(model, draw) {
+			t.Fatalf("expected the frame to be due after the wake-up, woken = %d", woken)
+		}
+		if len(frames) != 2 || frames[1] != "m 0x0 ab" {
+			t.Errorf("expected the requests to be coalesced, got %q", frames)
+		}
+		if s.Due() {
+			t.Error("expected no frame due without a request")
+		}
+
+		stats := s.Stats()
+		if stats.Rendered != 2 || stats.Coalesced != 1 || stats.Skipped != 0 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("SkipsUnchanged", func(t *testing.T) {
+		s := NewScheduler(-1, &stepClock{}, nil)
+		model := &echoModel{name: "m"}
+		draws := 0
+		draw := func(string) { draws++ }
+
+		s.Request()
+		s.Draw(model, draw)
+		s.Request()
+		if !s.Due() || s.Draw(model, draw) {
+			t.Error("expected an unchanged view to be skipped")
+		}
+		s.Invalidate()
+		if !s.Draw(model, draw) {
+			t.Error("expected an invalidated frame to be drawn")
+		}
+		if stats := s.Stats(); draws != 2 || stats.Rendered != 2 || stats.Skipped != 1 {
+			t.Errorf("draws = %d, stats = %+v", draws, stats)
+		}
+	})
+
+	t.Run("DirtyTracker", func(t *testing.T) {
+		s := NewScheduler(-1, &stepClock{}, nil)
+		model := &dirtyModel{text: "one"}
+		draw := func(string) {}
+
+		if !s.Draw(model, draw) || model.views != 1 {
+			t.Fatal("expected a new model to be drawn")
+		}
+		if s.Draw(model, draw) || model.views != 1 {
+			t.Errorf("expected a clean model to be skipped without View, views = %d", model.views)
+		}
+		model.text = "two"
+		model.MarkDirty()
+		if !s.Draw(model, draw) || model.views != 2 {
+			t.Errorf("expected a dirty model to be drawn, views = %d", model.views)
+		}
+	})
+
+	t.Run("NeedsRender", func(t *testing.T) {
+		var a, b Dirty
+		a.NeedsRender()
+		b.NeedsRender()
+		if NeedsRender(&a, &b) {
+			t.Error("expected clean components not to need a render")
+		}
+		b.MarkDirty()
+		if !NeedsRender(&a, &b) || b.NeedsRender() {
+			t.Error("expected NeedsRender to report and clear the mark")
+		}
+		if !NeedsRender(&a, &echoModel{}) {
+			t.Error("expected components without tracking to always need a render")
+		}
+	})
+}
+
+func TestRuntime(t *testing.T) {
+	collect := func() (*Runtime, func() []Msg) {
+		var mu sync.Mutex
+		var msgs []Msg
+		r := NewRuntime(context.Background(), func(msg Msg) {
+			mu.Lock()
+			defer mu.Unlock()
+			msgs = append(msgs, msg)
+		})
+		return r, func() []Msg {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(msgs)
+		}
+	}
+
+	t.Run("Tick", func(t *testing.T) {
+		r, msgs := collect()
+		r.Run(Tick(time.Millisecond, func(time.Time) Msg { return CustomMsg{Type: "tick"} }))
+		r.Wait()
+		if got := msgs(); len(got) != 1 || got[0].(CustomMsg).Type != "tick" {
+			t.Errorf("expected one tick, got %#v", got)
+		}
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		r, msgs := collect()
+		r.Run(Sequence(
+			Tick(5*time.Millisecond, func(time.Time) Msg { return CustomMsg{Type: "1"} }),
+			Func(func() Msg { return CustomMsg{Type: "2"} }),
+			Quit(),
+		))
+		r.Wait()
+
+		got := msgs()
+		if len(got) != 3 {
+			t.Fatalf("expected 3 messages, got %#v", got)
+		}
+		if got[0].(CustomMsg).Type != "1" || got[1].(CustomMsg).Type != "2" {
+			t.Errorf("expected messages in order, got %#v", got)
+		}
+		if _, ok := got[2].(QuitMsg); !ok {
+			t.Errorf("expected QuitMsg last, got %#v", got[2])
+		}
+	})
+
+	t.Run("StopCancelsTicks", func(t *testing.T) {
+		r, msgs := collect()
+		r.Run(Tick(time.Hour, func(time.Time) Msg { return CustomMsg{} }))
+		r.Stop()
+		r.Wait()
+		if got := msgs(); len(got) != 0 {
+			t.Errorf("expected no messages after stop, got %#v", got)
+		}
+		if r.Context().Err() == nil {
+			t.Error("expected context to be cancelled")
+		}
+	})
+
+	t.Run("Every", func(t *testing.T) {
+		r, msgs := collect()
+		r.Run(Every(10*time.Millisecond, func(tm time.Time) Msg { return TickMsg{Time: tm} }))
+		r.Wait()
+		got := msgs()
+		if len(got) != 1 {
+			t.Fatalf("expected one tick, got %#v", got)
+		}
+		if got[0].(TickMsg).Time.IsZero() {
+			t.Error("expected tick time to be set")
+		}
+	})
+
+	t.Run("DirectEngine", func(t *testing.T) {
+		model := &cmdModel{init: Batch(
+			Tick(time.Millisecond, func(time.Time) Msg { return CustomMsg{Type: "tick"} }),
+			Command(func() error { return errTest }),
+		)}
+		engine := NewDirectEngine(nil).(*DirectEngine)
+		if err := engine.Start(model); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		engine.Wait()
+
+		var tick, failed bool
+		for _, msg := range model.received() {
+			switch m := msg.(type) {
+			case CustomMsg:
+				tick = m.Type == "tick"
+			case ErrorMsg:
+				failed = m.Error == errTest
+			}
+		}
+		if !tick || !failed {
+			t.Errorf("expected tick and error messages, got %#v", model.received())
+		}
+
+		if err := engine.Send(QuitMsg{}); err != nil {
+			t.Fatalf("quit failed: %v", err)
+		}
+		if engine.Running() {
+			t.Error("expected QuitMsg to stop the engine")
+		}
+	})
+
+	t.Run("TeaCmd", func(t *testing.T) {
+		if TeaCmd(nil) != nil || TeaCmd(None()) != nil {
+			t.Error("expected nil tea.Cmd for empty commands")
+		}
+		cmd := TeaCmd(Tick(time.Millisecond, func(time.Time) Msg { return CustomMsg{Type: "tick"} }))
+		if msg, ok := cmd().(CustomMsg); !ok || msg.Type != "tick" {
+			t.Errorf("expected tick message, got %#v", msg)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cmd = adaptCmd(ctx, Tick(time.Hour, func(time.Time) Msg { return CustomMsg{} }))
+		if msg := cmd(); msg != nil {
+			t.Errorf("expected cancelled tick to return nil, got %#v", msg)
+		}
+	})
+}
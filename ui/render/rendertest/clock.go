@@ -0,0 +1,127 @@
+// Package rendertest drives render.Model implementations headlessly for
+// tests: scripted input, deterministic commands on a fake clock and golden
+// snapshot files.
+package rendertest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render"
+)
+
+var _ render.Clock = (*Clock)(nil)
+
+// Clock is a fake render.Clock. Time only moves when Advance is called, and
+// timers fire on the goroutine calling Advance, in deadline order.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64
+}
+
+type fakeTimer struct {
+	when time.Time
+	seq  uint64
+	fn   func(time.Time)
+}
+
+// NewClock creates a fake clock starting at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the fake current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to run once the clock has advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, f func(time.Time)) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{when: c.now.Add(d), seq: c.seq, fn: f}
+	c.seq++
+	c.timers = append(c.timers, t)
+
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, pending := range c.timers {
+			if pending == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due
+// on the way. Timers scheduled by those callbacks fire too if they are due
+// before the new time.
+func (c *Clock) Advance(d time.Duration) {
+	c.advance(d, func() {})
+}
+
+// advance is Advance with a hook that runs after each timer fires, so the
+// driver can apply the resulting messages before time moves on.
+func (c *Clock) advance(d time.Duration, after func()) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		t := c.next(target)
+		if t == nil {
+			break
+		}
+		t.fn(t.when)
+		after()
+	}
+
+	c.mu.Lock()
+	if target.After(c.now) {
+		c.now = target
+	}
+	c.mu.Unlock()
+}
+
+// Pending returns the number of timers that have not fired yet.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// next removes and returns the earliest timer due at or before target and
+// moves the clock to its deadline.
+func (c *Clock) next(target time.Time) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.timers) == 0 {
+		return nil
+	}
+	sort.Slice(c.timers, func(i, j int) bool {
+		if c.timers[i].when.Equal(c.timers[j].when) {
+			return c.timers[i].seq < c.timers[j].seq
+		}
+		return c.timers[i].when.Before(c.timers[j].when)
+	})
+
+	t := c.timers[0]
+	if t.when.After(target) {
+		return nil
+	}
+	c.timers = c.timers[1:]
+	if t.when.After(c.now) {
+		c.now = t.when
+	}
+	return t
+}
@@ -0,0 +1,288 @@
+package rendertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
+)
+
+// Epoch is the time the fake clock of a new Driver starts at.
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// maxSteps bounds the number of messages processed by a single call, to
+// turn models that never stop producing messages into an error.
+const maxSteps = 10000
+
+// Driver runs a render.Model without a terminal.
+//
+// Every input method applies the message, then runs the resulting commands
+// on the calling goroutine and applies their messages until nothing is left
+// to do. Ticks wait for the fake clock, so time only passes on Advance.
+// If the model never stops producing messages, the driver stops and
+// reports it through Err.
+type Driver struct {
+	model   render.Model
+	clock   *Clock
+	cmds    *render.Runtime
+	width   int
+	height  int
+	quit    bool
+	printed []string
+	caps    termcap.Capabilities
+	err     error
+
+	mu    sync.Mutex
+	queue []render.Msg
+}
+
+// New starts model with a screen of the given size. It runs Init, sends a
+// WindowSizeMsg and processes everything they produce.
+func New(model render.Model, width, height int) *Driver {
+	return NewWithClock(model, width, height, NewClock(Epoch))
+}
+
+// NewWithClock is like New, with ticks running on clock instead of a fake
+// clock starting at Epoch.
+func NewWithClock(model render.Model, width, height int, clock *Clock) *Driver {
+	d := &Driver{
+		model:  model,
+		clock:  clock,
+		width:  width,
+		height: height,
+	}
+	d.cmds = render.NewRuntime(context.Background(), d.enqueue)
+	d.cmds.SetClock(d.clock)
+	d.cmds.SetSynchronous(true)
+
+	d.cmds.Run(model.Init())
+	d.enqueue(render.WindowSizeMsg{Width: width, Height: height})
+	d.settle()
+	return d
+}
+
+// Send applies msgs to the model in order.
+func (d *Driver) Send(msgs ...render.Msg) {
+	for _, msg := range msgs {
+		d.enqueue(msg)
+	}
+	d.settle()
+}
+
+// Type sends one key message per character of text.
+func (d *Driver) Type(text string) {
+	for _, r := range text {
+		s := string(r)
+		d.enqueue(render.KeyMsg{Key: s, Code: r, Text: s})
+	}
+	d.settle()
+}
+
+// Press sends key messages parsed from names like "enter", "ctrl+c" or
+// "shift+tab".
+func (d *Driver) Press(keys ...string) {
+	for _, key := range keys {
+		d.enqueue(render.ParseKey(key))
+	}
+	d.settle()
+}
+
+// Paste sends text as a single PasteMsg.
+func (d *Driver) Paste(text string) {
+	d.Send(render.PasteMsg{Text: text})
+}
+
+// Focus reports that the terminal window gained focus.
+func (d *Driver) Focus() {
+	d.Send(render.FocusMsg{Focused: true})
+}
+
+// Blur reports that the terminal window lost focus.
+func (d *Driver) Blur() {
+	d.Send(render.FocusMsg{Focused: false})
+}
+
+// Resize changes the screen size and sends a WindowSizeMsg.
+func (d *Driver) Resize(width, height int) {
+	d.width = width
+	d.height = height
+	d.Send(render.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Click sends a left button press and release at (x, y).
+func (d *Driver) Click(x, y int) {
+	d.Send(
+		render.MouseMsg{X: x, Y: y, Button: render.MouseButtonLeft, Action: render.MousePress},
+		render.MouseMsg{X: x, Y: y, Button: render.MouseButtonLeft, Action: render.MouseRelease},
+	)
+}
+
+// Scroll sends a wheel event at (x, y). Negative amounts scroll up.
+func (d *Driver) Scroll(x, y, amount int) {
+	button := render.MouseButtonWheelDown
+	if amount < 0 {
+		button = render.MouseButtonWheelUp
+		amount = -amount
+	}
+	for i := 0; i < amount; i++ {
+		d.enqueue(render.MouseMsg{X: x, Y: y, Button: button, Action: render.MouseWheel})
+	}
+	d.settle()
+}
+
+// SetCapabilities sets what the driver answers to
+// render.ProbeCapabilities. Until it is called the answer is not Probed.
+func (d *Driver) SetCapabilities(caps termcap.Capabilities) {
+	d.caps = caps
+}
+
+// Advance moves the fake clock forward, firing due ticks and applying the
+// messages they produce. Each tick's message is applied before the clock
+// moves on, so ticks scheduled in response fire within the same call.
+func (d *Driver) Advance(dt time.Duration) {
+	d.clock.advance(dt, d.settle)
+	d.settle()
+}
+
+// Clock returns the driver's fake clock.
+func (d *Driver) Clock() *Clock {
+	return d.clock
+}
+
+// Model returns the current model.
+func (d *Driver) Model() render.Model {
+	return d.model
+}
+
+// Size returns the current screen size.
+func (d *Driver) Size() (int, int) {
+	return d.width, d.height
+}
+
+// Quit reports whether the model has quit. Messages sent afterwards are
+// dropped.
+func (d *Driver) Quit() bool {
+	return d.quit
+}
+
+// Err returns the error that stopped the driver, if any. Messages sent
+// afterwards are dropped.
+func (d *Driver) Err() error {
+	return d.err
+}
+
+// Printed returns the lines the model has printed with render.Println, in
+// order.
+func (d *Driver) Printed() []string {
+	return d.printed
+}
+
+// View returns the model's view including ANSI escape sequences, without
+// the cursor marker.
+func (d *Driver) View() string {
+	view, _ := render.FindCursor(d.model.View())
+	return view
+}
+
+// Cursor returns where an engine would show the terminal cursor, or nil
+// if no component placed it. See render.CursorProvider.
+func (d *Driver) Cursor() *render.Cursor {
+	_, cursor := render.FindCursor(d.model.View())
+	return cursor
+}
+
+// Plain returns the model's view with ANSI escape sequences removed and
+// trailing spaces trimmed from each line.
+func (d *Driver) Plain() string {
+	lines := strings.Split(ansi.Strip(d.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Close stops the command runtime, cancelling pending ticks.
+func (d *Driver) Close() {
+	d.cmds.Stop()
+}
+
+func (d *Driver) enqueue(msg render.Msg) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue = append(d.queue, msg)
+}
+
+func (d *Driver) dequeue() (render.Msg, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queue) == 0 {
+		return nil, false
+	}
+	msg := d.queue[0]
+	d.queue = d.queue[1:]
+	return msg, true
+}
+
+// settle applies queued messages until the queue is empty.
+func (d *Driver) settle() {
+	for steps := 0; d.err == nil; steps++ {
+		if steps == maxSteps {
+			d.err = fmt.Errorf("rendertest: model did not settle after %d messages", maxSteps)
+			d.cmds.Stop()
+			return
+		}
+		msg, ok := d.dequeue()
+		if !ok {
+			return
+		}
+		d.update(msg)
+	}
+}
+
+func (d *Driver) update(msg render.Msg) {
+	if d.quit || d.err != nil {
+		return
+	}
+	if _, ok := msg.(render.QuitMsg); ok {
+		d.quit = true
+		d.cmds.Stop()
+		return
+	}
+	switch msg := msg.(type) {
+	case render.PrintLineMsg:
+		d.printed = append(d.printed, msg.Text)
+		return
+	case render.ExecMsg:
+		// External programs are not run; they are assumed to have
+		// succeeded
+		if reply := msg.Done(nil); reply != nil {
+			d.enqueue(reply)
+		}
+		return
+	case render.SuspendMsg:
+		d.enqueue(render.ResumeMsg{})
+		return
+	case render.ProbeMsg:
+		// There is no terminal to ask; tests set the answer with
+		// SetCapabilities
+		d.enqueue(render.CapabilitiesMsg{Capabilities: d.caps})
+		return
+	}
+
+	newModel, cmd := d.model.Update(msg)
+	if newModel != nil {
+		d.model = newModel
+	}
+
+	if render.IsQuit(cmd) {
+		d.quit = true
+		d.cmds.Stop()
+		return
+	}
+	d.cmds.Run(cmd)
+}
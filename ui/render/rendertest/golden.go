@@ -0,0 +1,84 @@
+package rendertest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// update is set by running the tests with -update, which rewrites golden
+// files instead of comparing against them.
+var update = flag.Bool("update", false, "update golden files")
+
+// Golden compares got with testdata/<name>.golden. When the tests run with
+// -update the file is written instead.
+func Golden(t testing.TB, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if diff := diffLines(string(want), got); diff != "" {
+		t.Errorf("output does not match %s (run with -update to accept):\n%s", path, diff)
+	}
+}
+
+// AssertGolden compares the plain text view with testdata/<name>.golden.
+func (d *Driver) AssertGolden(t testing.TB, name string) {
+	t.Helper()
+	Golden(t, name, d.Plain())
+}
+
+// AssertGoldenANSI compares the view, escape sequences included, with
+// testdata/<name>.ansi.golden.
+func (d *Driver) AssertGoldenANSI(t testing.TB, name string) {
+	t.Helper()
+	Golden(t, name+".ansi", d.View())
+}
+
+// diffLines reports the lines that differ between want and got, quoted so
+// escape sequences and trailing spaces are visible. It returns "" if they
+// are equal.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var sb strings.Builder
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		fmt.Fprintf(&sb, "line %d:\n  want %s\n  got  %s\n", i+1, strconv.Quote(w), strconv.Quote(g))
+	}
+	if len(wantLines) != len(gotLines) {
+		fmt.Fprintf(&sb, "want %d lines, got %d\n", len(wantLines), len(gotLines))
+	}
+	return sb.String()
+}
@@ -0,0 +1,253 @@
+package rendertest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wwsheng009/taproot/ui/render"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
+)
+
+type tickMsg struct{ n int }
+
+// counterModel records input and ticks once per second while running.
+type counterModel struct {
+	width, height int
+	ticks         int
+	running       bool
+	log           []string
+}
+
+func (m *counterModel) Init() render.Cmd {
+	return nil
+}
+
+func (m *counterModel) Update(msg any) (render.Model, render.Cmd) {
+	switch msg := msg.(type) {
+	case render.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case render.KeyMsg:
+		switch msg.String() {
+		case "s":
+			m.running = true
+			return m, m.tick()
+		case "p":
+			return m, render.Printf("printed %d", len(m.log))
+		case "e":
+			return m, render.Exec(&fakeExec{}, func(err error) render.Msg {
+				return render.PasteMsg{Text: fmt.Sprint("exec ", err)}
+			})
+		case "ctrl+z":
+			return m, render.Suspend()
+		case "?":
+			return m, render.ProbeCapabilities(0)
+		case "ctrl+c":
+			return m, render.Quit()
+		}
+		m.log = append(m.log, "key "+msg.String())
+	case render.PasteMsg:
+		m.log = append(m.log, "paste "+msg.Text)
+	case render.ResumeMsg:
+		m.log = append(m.log, "resumed")
+	case render.CapabilitiesMsg:
+		m.log = append(m.log, fmt.Sprintf("caps %v %s", msg.Capabilities.Probed, msg.Capabilities.Version))
+	case render.FocusMsg:
+		m.log = append(m.log, fmt.Sprint("focused ", msg.Focused))
+	case render.MouseMsg:
+		m.log = append(m.log, fmt.Sprintf("mouse %s %d,%d", msg, msg.X, msg.Y))
+	case tickMsg:
+		m.ticks = msg.n
+		if m.ticks < 3 {
+			return m, m.tick()
+		}
+	}
+	return m, nil
+}
+
+// fakeExec is an external command that fails if it is run.
+type fakeExec struct{}
+
+func (fakeExec) Run() error          { return errors.New("ran") }
+func (fakeExec) SetStdin(io.Reader)  {}
+func (fakeExec) SetStdout(io.Writer) {}
+func (fakeExec) SetStderr(io.Writer) {}
+
+func (m *counterModel) tick() render.Cmd {
+	n := m.ticks + 1
+	return render.Tick(time.Second, func(time.Time) render.Msg { return tickMsg{n: n} })
+}
+
+func (m *counterModel) View() string {
+	return fmt.Sprintf("\x1b[1m%dx%d\x1b[0m ticks=%d   \n%s", m.width, m.height, m.ticks, strings.Join(m.log, "\n"))
+}
+
+type loopMsg struct{}
+
+// loopModel answers every message with another one.
+type loopModel struct{}
+
+func (loopModel) Init() render.Cmd { return nil }
+
+func (m loopModel) Update(msg any) (render.Model, render.Cmd) {
+	return m, func() render.Msg { return loopMsg{} }
+}
+
+func (loopModel) View() string { return "" }
+
+func TestDriver(t *testing.T) {
+	t.Run("Input", func(t *testing.T) {
+		d := New(&counterModel{}, 40, 10)
+		defer d.Close()
+
+		d.Type("hi")
+		d.Press("ctrl+x")
+		d.Paste("a\nb")
+		d.Click(2, 3)
+		d.Resize(20, 5)
+
+		d.AssertGolden(t, "driver_input")
+		d.AssertGoldenANSI(t, "driver_input")
+	})
+
+	t.Run("Focus", func(t *testing.T) {
+		m := &counterModel{}
+		d := New(m, 40, 10)
+		defer d.Close()
+
+		d.Blur()
+		d.Focus()
+		if got := strings.Join(m.log, ", "); got != "focused false, focused true" {
+			t.Errorf("expected a blur and a focus, got %q", got)
+		}
+	})
+
+	t.Run("Capabilities", func(t *testing.T) {
+		m := &counterModel{}
+		d := New(m, 40, 10)
+		defer d.Close()
+
+		d.Type("?")
+		d.SetCapabilities(termcap.Capabilities{Probed: true, Version: "kitty"})
+		d.Type("?")
+		if got := strings.Join(m.log, ", "); got != "caps false , caps true kitty" {
+			t.Errorf("expected an unprobed then the set answer, got %q", got)
+		}
+	})
+
+	t.Run("FakeClock", func(t *testing.T) {
+		d := New(&counterModel{}, 40, 10)
+		defer d.Close()
+
+		d.Press("s")
+		m := d.Model().(*counterModel)
+		if m.ticks != 0 {
+			t.Fatalf("expected no ticks before advancing, got %d", m.ticks)
+		}
+
+		d.Advance(999 * time.Millisecond)
+		if m.ticks != 0 {
+			t.Errorf("expected no ticks after 999ms, got %d", m.ticks)
+		}
+		d.Advance(time.Millisecond)
+		if m.ticks != 1 {
+			t.Errorf("expected 1 tick after 1s, got %d", m.ticks)
+		}
+		d.Advance(10 * time.Second)
+		if m.ticks != 3 {
+			t.Errorf("expected ticks to stop at 3, got %d", m.ticks)
+		}
+		if d.Clock().Pending() != 0 {
+			t.Errorf("expected no pending timers, got %d", d.Clock().Pending())
+		}
+		if got := d.Clock().Now().Sub(Epoch); got != 11*time.Second {
+			t.Errorf("expected clock at 11s, got %v", got)
+		}
+	})
+
+	t.Run("Printed", func(t *testing.T) {
+		d := New(&counterModel{}, 40, 10)
+		defer d.Close()
+
+		d.Press("p", "x", "p")
+		if got := strings.Join(d.Printed(), ","); got != "printed 0,printed 1" {
+			t.Errorf("expected printed lines, got %q", got)
+		}
+		if m := d.Model().(*counterModel); len(m.log) != 1 {
+			t.Errorf("expected printed lines to bypass the model, got log %v", m.log)
+		}
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		d := New(&counterModel{}, 40, 10)
+		defer d.Close()
+
+		d.Press("e", "ctrl+z")
+		m := d.Model().(*counterModel)
+		if got := strings.Join(m.log, ","); got != "paste exec <nil>,resumed" {
+			t.Errorf("expected exec to succeed without running and resume, got %q", got)
+		}
+	})
+
+	t.Run("Settle", func(t *testing.T) {
+		d := New(loopModel{}, 40, 10)
+		defer d.Close()
+
+		if d.Err() == nil {
+			t.Fatal("expected an error for a model that never settles")
+		}
+		d.Type("x")
+		if d.Err() == nil {
+			t.Error("expected the error to stick")
+		}
+	})
+
+	t.Run("Quit", func(t *testing.T) {
+		d := New(&counterModel{}, 40, 10)
+		d.Press("s", "ctrl+c")
+		if !d.Quit() {
+			t.Fatal("expected driver to report quit")
+		}
+		d.Type("x")
+		d.Advance(time.Minute)
+		m := d.Model().(*counterModel)
+		if len(m.log) != 0 || m.ticks != 0 {
+			t.Errorf("expected no updates after quit, got log %v and %d ticks", m.log, m.ticks)
+		}
+	})
+}
+
+func TestClock(t *testing.T) {
+	c := NewClock(Epoch)
+	var fired []string
+	c.AfterFunc(2*time.Second, func(time.Time) { fired = append(fired, "b") })
+	c.AfterFunc(time.Second, func(time.Time) {
+		fired = append(fired, "a")
+		c.AfterFunc(500*time.Millisecond, func(time.Time) { fired = append(fired, "a2") })
+	})
+	stop := c.AfterFunc(time.Second, func(time.Time) { fired = append(fired, "stopped") })
+	if !stop() {
+		t.Error("expected stop to cancel a pending timer")
+	}
+
+	c.Advance(3 * time.Second)
+	if got := strings.Join(fired, ","); got != "a,a2,b" {
+		t.Errorf("expected timers in deadline order, got %s", got)
+	}
+	if stop() {
+		t.Error("expected stop to report false for a removed timer")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	if diff := diffLines("a\nb", "a\nb"); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+	diff := diffLines("a\nb", "a\nc\x1b[0m")
+	if !strings.Contains(diff, "line 2") || !strings.Contains(diff, `\x1b[0m`) {
+		t.Errorf("expected quoted diff of line 2, got %q", diff)
+	}
+}
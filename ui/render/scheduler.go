@@ -0,0 +1,187 @@
+package render
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxFPS is the frame rate cap of engines configured without one.
+const DefaultMaxFPS = 60
+
+// frameMsg wakes up an event loop when a frame held back by its
+// Scheduler is due.
+type frameMsg struct{}
+
+// FrameStats counts what a Scheduler did with the frames it was asked for.
+type FrameStats struct {
+	// Rendered is the number of frames written to the terminal.
+	Rendered uint64
+	// Skipped is the number of frames dropped because the model reported
+	// no changes or its view was the same as the last frame's.
+	Skipped uint64
+	// Coalesced is the number of requests merged into a frame that was
+	// already pending, e.g. a burst of messages within one frame interval.
+	Coalesced uint64
+}
+
+// DirtyTracker is implemented by models that know whether their view has
+// changed. Before each frame the scheduler asks NeedsRender and, if it
+// returns false, neither calls View nor redraws. NeedsRender reports the
+// changes since its previous call.
+//
+// Models that don't implement it have View called for every frame, and
+// the frame is skipped if the view is unchanged.
+type DirtyTracker interface {
+	NeedsRender() bool
+}
+
+// Dirty records whether a component needs to be redrawn. Components embed
+// it, call MarkDirty whenever their state changes and get NeedsRender for
+// free. The zero value is dirty, so the first frame is always drawn.
+type Dirty struct {
+	clean atomic.Bool
+}
+
+// MarkDirty schedules a redraw. It is safe to call from any goroutine.
+func (d *Dirty) MarkDirty() {
+	d.clean.Store(false)
+}
+
+// NeedsRender reports whether MarkDirty was called since the last call,
+// and clears the mark.
+func (d *Dirty) NeedsRender() bool {
+	return !d.clean.Swap(true)
+}
+
+// NeedsRender reports whether any of the components needs to be redrawn,
+// for containers implementing DirtyTracker over their children. Every
+// component is asked, so all marks are cleared; components that don't
+// implement DirtyTracker always need to be redrawn.
+func NeedsRender(components ...any) bool {
+	dirty := false
+	for _, c := range components {
+		t, ok := c.(DirtyTracker)
+		if !ok || t.NeedsRender() {
+			dirty = true
+		}
+	}
+	return dirty
+}
+
+// Scheduler decides when an event loop draws a frame. Updates request a
+// frame; frames are drawn at most maxFPS times a second, so a burst of
+// messages, such as a fast spinner or a streamed response, is coalesced
+// into one frame, and a frame whose view hasn't changed is not drawn.
+//
+// A Scheduler belongs to one event loop: only Stats may be called from
+// other goroutines.
+type Scheduler struct {
+	interval time.Duration
+	clock    Clock
+	wake     func()
+
+	pending bool        // a frame was requested and not drawn yet
+	timer   func() bool // stops the pending wake-up, nil if none
+	last    time.Time   // when the last frame was drawn
+	view    string      // the view of the last frame
+	valid   bool        // view is on screen and may be compared with
+
+	rendered, skipped, coalesced atomic.Uint64
+}
+
+// NewScheduler returns a scheduler that draws at most maxFPS frames a
+// second (no limit if maxFPS is negative, DefaultMaxFPS if zero). When a
+// requested frame is held back by the limit, wake is called from another
+// goroutine once it is due; the loop should then check Due again.
+func NewScheduler(maxFPS int, clock Clock, wake func()) *Scheduler {
+	if maxFPS == 0 {
+		maxFPS = DefaultMaxFPS
+	}
+	if clock == nil {
+		clock = SystemClock()
+	}
+	s := &Scheduler{clock: clock, wake: wake}
+	if maxFPS > 0 {
+		s.interval = time.Second / time.Duration(maxFPS)
+	}
+	return s
+}
+
+// Request asks for a frame because the model may have changed.
+func (s *Scheduler) Request() {
+	if s.pending {
+		s.coalesced.Add(1)
+	}
+	s.pending = true
+}
+
+// Invalidate asks for a frame that is drawn even if the view is
+// unchanged: after the screen was cleared or resized, or when output other
+// than the view is waiting to be flushed.
+func (s *Scheduler) Invalidate() {
+	s.valid = false
+	s.Request()
+}
+
+// Due reports whether a requested frame may be drawn now. If the frame
+// rate limit holds it back, wake is called once it is due.
+func (s *Scheduler) Due() bool {
+	if !s.pending {
+		return false
+	}
+	wait := s.interval - s.clock.Now().Sub(s.last)
+	if wait <= 0 {
+		return true
+	}
+	if s.timer == nil {
+		s.timer = s.clock.AfterFunc(wait, func(time.Time) { s.wake() })
+	}
+	return false
+}
+
+// Draw draws a frame of model with draw, regardless of the frame rate
+// limit, unless the model reports no changes or its view is the same as
+// the last frame's. It reports whether the frame was drawn.
+func (s *Scheduler) Draw(model Model, draw func(view string)) bool {
+	s.pending = false
+	s.stop()
+
+	if t, ok := model.(DirtyTracker); ok && !t.NeedsRender() && s.valid {
+		s.skipped.Add(1)
+		return false
+	}
+	view := model.View()
+	if s.valid && view == s.view {
+		s.skipped.Add(1)
+		return false
+	}
+
+	draw(view)
+	s.view, s.valid = view, true
+	s.last = s.clock.Now()
+	s.rendered.Add(1)
+	return true
+}
+
+// Stop cancels a pending wake-up.
+func (s *Scheduler) Stop() {
+	s.pending = false
+	s.stop()
+}
+
+func (s *Scheduler) stop() {
+	if s.timer != nil {
+		s.timer()
+		s.timer = nil
+	}
+}
+
+// Stats returns the frame counts so far. It is safe to call from any
+// goroutine.
+func (s *Scheduler) Stats() FrameStats {
+	return FrameStats{
+		Rendered:  s.rendered.Load(),
+		Skipped:   s.skipped.Load(),
+		Coalesced: s.coalesced.Load(),
+	}
+}
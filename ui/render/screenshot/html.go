@@ -0,0 +1,124 @@
+package screenshot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+)
+
+// WriteHTML writes buf to w as a standalone HTML page: a preformatted
+// block with a span for every run of equally styled cells.
+func WriteHTML(w io.Writer, buf *buffer.Buffer, opts Options) error {
+	opts = opts.withDefaults()
+	t := opts.Theme
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 0; background: %s; }
+pre { display: inline-block; margin: 0; padding: %dpx; color: %s; background: %s; font-family: %s; font-size: %dpx; line-height: 1.2; }
+a { color: inherit; }
+</style>
+</head>
+<body><pre>`,
+		html.EscapeString(opts.Title), hex(t.Background), opts.Padding,
+		hex(t.Foreground), hex(t.Background), opts.FontFamily, opts.FontSize)
+
+	for y := 0; y < buf.Height(); y++ {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		var run strings.Builder
+		var style buffer.Style
+		for x := 0; x < buf.Width(); x++ {
+			c := buf.CellAt(buffer.Point{X: x, Y: y})
+			if c.IsContinuation {
+				continue
+			}
+			if c.Style != style && run.Len() > 0 {
+				writeSpan(&b, t, style, run.String())
+				run.Reset()
+			}
+			style = c.Style
+			run.WriteString(cellText(c))
+		}
+		writeSpan(&b, t, style, run.String())
+	}
+
+	b.WriteString("</pre></body>\n</html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSpan writes text styled with s.
+func writeSpan(b *strings.Builder, t Theme, s buffer.Style, text string) {
+	text = html.EscapeString(text)
+	css := spanCSS(t, s)
+	if css == "" && s.Hyperlink == "" {
+		b.WriteString(text)
+		return
+	}
+
+	if s.Hyperlink != "" {
+		fmt.Fprintf(b, `<a href="%s">`, html.EscapeString(s.Hyperlink))
+	}
+	if css != "" {
+		fmt.Fprintf(b, `<span style="%s">%s</span>`, css, text)
+	} else {
+		b.WriteString(text)
+	}
+	if s.Hyperlink != "" {
+		b.WriteString("</a>")
+	}
+}
+
+// spanCSS returns the inline CSS for cells styled with s, "" for the
+// default style.
+func spanCSS(t Theme, s buffer.Style) string {
+	fg, bg := t.colors(s)
+	var css []string
+	if fg != t.Foreground {
+		css = append(css, "color:"+hex(fg))
+	}
+	if bg != t.Background {
+		css = append(css, "background:"+hex(bg))
+	}
+	if s.Bold {
+		css = append(css, "font-weight:bold")
+	}
+	if s.Italic {
+		css = append(css, "font-style:italic")
+	}
+
+	var lines []string
+	if s.Underline {
+		lines = append(lines, "underline")
+		switch s.UnderlineStyle {
+		case buffer.UnderlineDouble:
+			lines = append(lines, "double")
+		case buffer.UnderlineCurly:
+			lines = append(lines, "wavy")
+		case buffer.UnderlineDotted:
+			lines = append(lines, "dotted")
+		case buffer.UnderlineDashed:
+			lines = append(lines, "dashed")
+		}
+		if s.UnderlineColor != "" {
+			lines = append(lines, hex(t.underline(s, fg)))
+		}
+	}
+	if s.Strikethrough {
+		lines = append([]string{"line-through"}, lines...)
+	}
+	if len(lines) > 0 {
+		css = append(css, "text-decoration:"+strings.Join(lines, " "))
+	}
+	return strings.Join(css, ";")
+}
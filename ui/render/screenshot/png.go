@@ -0,0 +1,357 @@
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+)
+
+// WritePNG writes buf to w as a PNG image drawn with the embedded font.
+func WritePNG(w io.Writer, buf *buffer.Buffer, opts Options) error {
+	return png.Encode(w, Image(buf, opts))
+}
+
+// Image draws buf with the embedded 7x13 font, enlarged by opts.Scale.
+// Box drawing characters and block elements are drawn geometrically so
+// borders join up; characters the font has no glyph or look-alike for are
+// drawn as U+FFFD.
+func Image(buf *buffer.Buffer, opts Options) *image.RGBA {
+	opts = opts.withDefaults()
+	t := opts.Theme
+	width := buf.Width()*cellWidth + 2*opts.Padding
+	height := buf.Height()*cellHeight + 2*opts.Padding
+
+	cv := canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, width*opts.Scale, height*opts.Scale)),
+		scale: opts.Scale,
+	}
+	cv.fill(0, 0, width, height, t.Background)
+
+	for y := 0; y < buf.Height(); y++ {
+		for x := 0; x < buf.Width(); x++ {
+			c := buf.CellAt(buffer.Point{X: x, Y: y})
+			if c.IsContinuation {
+				continue
+			}
+			cv.drawCell(opts.Padding+x*cellWidth, opts.Padding+y*cellHeight, c, t)
+		}
+	}
+	return cv.img
+}
+
+// canvas draws on an image in unscaled pixels.
+type canvas struct {
+	img   *image.RGBA
+	scale int
+}
+
+// set colors the pixel at x, y.
+func (cv canvas) set(x, y int, c color.RGBA) {
+	cv.fill(x, y, x+1, y+1, c)
+}
+
+// fill colors the rectangle from x0, y0 up to x1, y1.
+func (cv canvas) fill(x0, y0, x1, y1 int, c color.RGBA) {
+	s := cv.scale
+	r := image.Rect(x0*s, y0*s, x1*s, y1*s).Intersect(cv.img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			cv.img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawCell draws c with its top left corner at x, y.
+func (cv canvas) drawCell(x, y int, c buffer.Cell, t Theme) {
+	fg, bg := t.colors(c.Style)
+	w := cellWidth * max(c.Width, 1)
+	if bg != t.Background {
+		cv.fill(x, y, x+w, y+cellHeight, bg)
+	}
+
+	switch r := c.Char; {
+	case r == 0 || r == ' ':
+	case r >= 0x2500 && r <= 0x257f:
+		cv.drawBox(x, y, r, fg)
+	case r >= 0x2580 && r <= 0x259f:
+		cv.drawBlock(x, y, r, fg, bg)
+	default:
+		cv.drawGlyph(x, y, glyph(r), fg, c.Style.Bold)
+	}
+
+	if c.Style.Underline {
+		ul := t.underline(c.Style, fg)
+		cv.fill(x, y+glyphAscent+1, x+w, y+glyphAscent+2, ul)
+		if c.Style.UnderlineStyle == buffer.UnderlineDouble {
+			cv.fill(x, y+glyphAscent-1, x+w, y+glyphAscent, ul)
+		}
+	}
+	if c.Style.Strikethrough {
+		cv.fill(x, y+cellHeight/2, x+w, y+cellHeight/2+1, fg)
+	}
+}
+
+// drawGlyph draws a font glyph. Bold text is drawn a second time one
+// pixel to the right.
+func (cv canvas) drawGlyph(x, y int, g *[glyphHeight]uint8, fg color.RGBA, bold bool) {
+	for row, bits := range g {
+		if bold {
+			bits |= bits >> 1
+		}
+		for col := 0; col < cellWidth; col++ {
+			if bits&(0x80>>col) != 0 {
+				cv.set(x+col, y+row, fg)
+			}
+		}
+	}
+}
+
+// Line weights of box drawing characters.
+const (
+	lineNone = iota
+	lineLight
+	lineHeavy
+	lineDouble
+)
+
+// drawBox draws a box drawing character as lines from the cell center to
+// the middle of its edges. Dashed lines are drawn solid and arcs as
+// corners.
+func (cv canvas) drawBox(x, y int, r rune, fg color.RGBA) {
+	const cx, cy = cellWidth / 2, cellHeight / 2
+
+	switch r {
+	case '╱', '╲', '╳':
+		for row := 0; row < cellHeight; row++ {
+			col := row * (cellWidth - 1) / (cellHeight - 1)
+			if r != '╲' {
+				cv.set(x+cellWidth-1-col, y+row, fg)
+			}
+			if r != '╱' {
+				cv.set(x+col, y+row, fg)
+			}
+		}
+		return
+	}
+
+	lines := boxLines[r-0x2500]
+	up, right, down, left := lines[0], lines[1], lines[2], lines[3]
+	// Lines reach past the center by half the width of the lines they
+	// meet, so corners and junctions close
+	reachV := max(halfWidth(left), halfWidth(right))
+	reachH := max(halfWidth(up), halfWidth(down))
+	for _, off := range lineOffsets(up) {
+		cv.fill(x+cx+off, y, x+cx+off+1, y+cy+reachV+1, fg)
+	}
+	for _, off := range lineOffsets(down) {
+		cv.fill(x+cx+off, y+cy-reachV, x+cx+off+1, y+cellHeight, fg)
+	}
+	for _, off := range lineOffsets(left) {
+		cv.fill(x, y+cy+off, x+cx+reachH+1, y+cy+off+1, fg)
+	}
+	for _, off := range lineOffsets(right) {
+		cv.fill(x+cx-reachH, y+cy+off, x+cellWidth, y+cy+off+1, fg)
+	}
+}
+
+// lineOffsets returns the offsets from the center of the pixel lines
+// that make up a line of weight w.
+func lineOffsets(w uint8) []int {
+	switch w {
+	case lineLight:
+		return []int{0}
+	case lineHeavy:
+		return []int{-1, 0, 1}
+	case lineDouble:
+		return []int{-1, 1}
+	}
+	return nil
+}
+
+// halfWidth returns how far a line of weight w extends from the center.
+func halfWidth(w uint8) int {
+	if w == lineHeavy || w == lineDouble {
+		return 1
+	}
+	return 0
+}
+
+// quadrants are the filled quarters of U+2596 to U+259F: 1 upper left,
+// 2 upper right, 4 lower left and 8 lower right.
+var quadrants = [10]uint8{4, 8, 1, 1 | 4 | 8, 1 | 8, 1 | 2 | 4, 1 | 2 | 8, 2, 2 | 4, 2 | 4 | 8}
+
+// drawBlock draws a block element.
+func (cv canvas) drawBlock(x, y int, r rune, fg, bg color.RGBA) {
+	const w, h = cellWidth, cellHeight
+
+	switch {
+	case r == '▀':
+		cv.fill(x, y, x+w, y+h/2, fg)
+	case r >= '▁' && r <= '█':
+		n := int(r - 0x2580)
+		cv.fill(x, y+h-h*n/8, x+w, y+h, fg)
+	case r >= '▉' && r <= '▏':
+		n := int(0x2590 - r)
+		cv.fill(x, y, x+w*n/8, y+h, fg)
+	case r == '▐':
+		cv.fill(x+w/2, y, x+w, y+h, fg)
+	case r >= '░' && r <= '▓':
+		cv.fill(x, y, x+w, y+h, blend(bg, fg, 0x40*int(r-0x2590)))
+	case r == '▔':
+		cv.fill(x, y, x+w, y+h/8, fg)
+	case r == '▕':
+		cv.fill(x+w-w/8, y, x+w, y+h, fg)
+	default:
+		q := quadrants[r-0x2596]
+		for i := range 4 {
+			if q&(1<<i) == 0 {
+				continue
+			}
+			x0, x1 := x, x+w/2
+			if i%2 == 1 {
+				x0, x1 = x+w/2, x+w
+			}
+			y0, y1 := y, y+h/2
+			if i >= 2 {
+				y0, y1 = y+h/2, y+h
+			}
+			cv.fill(x0, y0, x1, y1, fg)
+		}
+	}
+}
+
+// boxLines holds the weights of the lines of U+2500 to U+257F going up,
+// right, down and left from the center. Diagonals are drawn separately.
+var boxLines = [0x80][4]uint8{
+	{0, 1, 0, 1}, // ─
+	{0, 2, 0, 2}, // ━
+	{1, 0, 1, 0}, // │
+	{2, 0, 2, 0}, // ┃
+	{0, 1, 0, 1}, // ┄
+	{0, 2, 0, 2}, // ┅
+	{1, 0, 1, 0}, // ┆
+	{2, 0, 2, 0}, // ┇
+	{0, 1, 0, 1}, // ┈
+	{0, 2, 0, 2}, // ┉
+	{1, 0, 1, 0}, // ┊
+	{2, 0, 2, 0}, // ┋
+	{0, 1, 1, 0}, // ┌
+	{0, 2, 1, 0}, // ┍
+	{0, 1, 2, 0}, // ┎
+	{0, 2, 2, 0}, // ┏
+	{0, 0, 1, 1}, // ┐
+	{0, 0, 1, 2}, // ┑
+	{0, 0, 2, 1}, // ┒
+	{0, 0, 2, 2}, // ┓
+	{1, 1, 0, 0}, // └
+	{1, 2, 0, 0}, // ┕
+	{2, 1, 0, 0}, // ┖
+	{2, 2, 0, 0}, // ┗
+	{1, 0, 0, 1}, // ┘
+	{1, 0, 0, 2}, // ┙
+	{2, 0, 0, 1}, // ┚
+	{2, 0, 0, 2}, // ┛
+	{1, 1, 1, 0}, // ├
+	{1, 2, 1, 0}, // ┝
+	{2, 1, 1, 0}, // ┞
+	{1, 1, 2, 0}, // ┟
+	{2, 1, 2, 0}, // ┠
+	{2, 2, 1, 0}, // ┡
+	{1, 2, 2, 0}, // ┢
+	{2, 2, 2, 0}, // ┣
+	{1, 0, 1, 1}, // ┤
+	{1, 0, 1, 2}, // ┥
+	{2, 0, 1, 1}, // ┦
+	{1, 0, 2, 1}, // ┧
+	{2, 0, 2, 1}, // ┨
+	{2, 0, 1, 2}, // ┩
+	{1, 0, 2, 2}, // ┪
+	{2, 0, 2, 2}, // ┫
+	{0, 1, 1, 1}, // ┬
+	{0, 1, 1, 2}, // ┭
+	{0, 2, 1, 1}, // ┮
+	{0, 2, 1, 2}, // ┯
+	{0, 1, 2, 1}, // ┰
+	{0, 1, 2, 2}, // ┱
+	{0, 2, 2, 1}, // ┲
+	{0, 2, 2, 2}, // ┳
+	{1, 1, 0, 1}, // ┴
+	{1, 1, 0, 2}, // ┵
+	{1, 2, 0, 1}, // ┶
+	{1, 2, 0, 2}, // ┷
+	{2, 1, 0, 1}, // ┸
+	{2, 1, 0, 2}, // ┹
+	{2, 2, 0, 1}, // ┺
+	{2, 2, 0, 2}, // ┻
+	{1, 1, 1, 1}, // ┼
+	{1, 1, 1, 2}, // ┽
+	{1, 2, 1, 1}, // ┾
+	{1, 2, 1, 2}, // ┿
+	{2, 1, 1, 1}, // ╀
+	{1, 1, 2, 1}, // ╁
+	{2, 1, 2, 1}, // ╂
+	{2, 1, 1, 2}, // ╃
+	{2, 2, 1, 1}, // ╄
+	{1, 1, 2, 2}, // ╅
+	{1, 2, 2, 1}, // ╆
+	{2, 2, 1, 2}, // ╇
+	{1, 2, 2, 2}, // ╈
+	{2, 1, 2, 2}, // ╉
+	{2, 2, 2, 1}, // ╊
+	{2, 2, 2, 2}, // ╋
+	{0, 1, 0, 1}, // ╌
+	{0, 2, 0, 2}, // ╍
+	{1, 0, 1, 0}, // ╎
+	{2, 0, 2, 0}, // ╏
+	{0, 3, 0, 3}, // ═
+	{3, 0, 3, 0}, // ║
+	{0, 3, 1, 0}, // ╒
+	{0, 1, 3, 0}, // ╓
+	{0, 3, 3, 0}, // ╔
+	{0, 0, 1, 3}, // ╕
+	{0, 0, 3, 1}, // ╖
+	{0, 0, 3, 3}, // ╗
+	{1, 3, 0, 0}, // ╘
+	{3, 1, 0, 0}, // ╙
+	{3, 3, 0, 0}, // ╚
+	{1, 0, 0, 3}, // ╛
+	{3, 0, 0, 1}, // ╜
+	{3, 0, 0, 3}, // ╝
+	{1, 3, 1, 0}, // ╞
+	{3, 1, 3, 0}, // ╟
+	{3, 3, 3, 0}, // ╠
+	{1, 0, 1, 3}, // ╡
+	{3, 0, 3, 1}, // ╢
+	{3, 0, 3, 3}, // ╣
+	{0, 3, 1, 3}, // ╤
+	{0, 1, 3, 1}, // ╥
+	{0, 3, 3, 3}, // ╦
+	{1, 3, 0, 3}, // ╧
+	{3, 1, 0, 1}, // ╨
+	{3, 3, 0, 3}, // ╩
+	{1, 3, 1, 3}, // ╪
+	{3, 1, 3, 1}, // ╫
+	{3, 3, 3, 3}, // ╬
+	{0, 1, 1, 0}, // ╭
+	{0, 0, 1, 1}, // ╮
+	{1, 0, 0, 1}, // ╯
+	{1, 1, 0, 0}, // ╰
+	{},           // ╱
+	{},           // ╲
+	{},           // ╳
+	{0, 0, 0, 1}, // ╴
+	{1, 0, 0, 0}, // ╵
+	{0, 1, 0, 0}, // ╶
+	{0, 0, 1, 0}, // ╷
+	{0, 0, 0, 2}, // ╸
+	{2, 0, 0, 0}, // ╹
+	{0, 2, 0, 0}, // ╺
+	{0, 0, 2, 0}, // ╻
+	{0, 2, 0, 1}, // ╼
+	{1, 0, 2, 0}, // ╽
+	{0, 1, 0, 2}, // ╾
+	{2, 0, 1, 0}, // ╿
+}
@@ -0,0 +1,201 @@
+// Package screenshot turns rendered frames into images for documentation
+// and bug reports, without a terminal.
+//
+// A frame is a buffer.Buffer, or any View output converted with FromView.
+// WriteHTML produces a standalone page, WriteSVG a vector image with every
+// character placed on the cell grid, and WritePNG a bitmap drawn with an
+// embedded monospace font, so PNG output is the same on every machine.
+//
+// lipgloss styles views for the color profile of stdout, which has no
+// colors when it is not a terminal; call
+// lipgloss.SetColorProfile(termenv.TrueColor) before rendering views
+// headless.
+//
+//	buf := screenshot.FromView(model.View())
+//	err := screenshot.Save("docs/list.png", buf, screenshot.DefaultOptions())
+package screenshot
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+	"github.com/wwsheng009/taproot/ui/render/colorprofile"
+)
+
+// Theme holds the colors a screenshot is drawn with.
+type Theme struct {
+	// Foreground and Background are the terminal default colors.
+	Foreground color.RGBA
+	Background color.RGBA
+	// Palette holds the 16 basic and bright colors. Indexes 16-255 use the
+	// xterm color cube and grayscale ramp.
+	Palette [16]color.RGBA
+}
+
+// DefaultTheme is a dark theme with the xterm palette.
+var DefaultTheme = Theme{
+	Foreground: color.RGBA{R: 0xd0, G: 0xd0, B: 0xd0, A: 0xff},
+	Background: color.RGBA{R: 0x1c, G: 0x1c, B: 0x1c, A: 0xff},
+	Palette:    xtermPalette(),
+}
+
+// xtermPalette returns the default xterm colors of the basic and bright
+// colors.
+func xtermPalette() [16]color.RGBA {
+	var p [16]color.RGBA
+	for i := range p {
+		r, g, b, _ := colorprofile.RGB(strconv.Itoa(i))
+		p[i] = color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+	}
+	return p
+}
+
+// Options control how a screenshot is drawn. The zero value is valid:
+// zero fields other than Padding take their DefaultOptions values.
+type Options struct {
+	Theme Theme
+	// Title is the title of the HTML page.
+	Title string
+	// FontFamily and FontSize, in pixels, set the font of HTML and SVG
+	// output. PNG output always uses the embedded font.
+	FontFamily string
+	FontSize   int
+	// Padding is the margin around the screen in pixels.
+	Padding int
+	// Scale enlarges PNG output by a whole factor; the embedded font is
+	// 7x13 pixels per cell.
+	Scale int
+}
+
+// DefaultOptions returns the options used for documentation screenshots.
+func DefaultOptions() Options {
+	return Options{
+		Theme:      DefaultTheme,
+		Title:      "taproot",
+		FontFamily: `"DejaVu Sans Mono", Menlo, Consolas, monospace`,
+		FontSize:   14,
+		Padding:    8,
+		Scale:      2,
+	}
+}
+
+// withDefaults fills in zero fields.
+func (o Options) withDefaults() Options {
+	def := DefaultOptions()
+	if o.Theme == (Theme{}) {
+		o.Theme = def.Theme
+	}
+	if o.Title == "" {
+		o.Title = def.Title
+	}
+	if o.FontFamily == "" {
+		o.FontFamily = def.FontFamily
+	}
+	if o.FontSize <= 0 {
+		o.FontSize = def.FontSize
+	}
+	if o.Scale <= 0 {
+		o.Scale = def.Scale
+	}
+	return o
+}
+
+// FromView returns a buffer holding view, a string with ANSI escape
+// sequences such as a model's View output. The buffer is as wide as the
+// widest line and has one row per line.
+func FromView(view string) *buffer.Buffer {
+	view = strings.TrimSuffix(view, "\n")
+	lines := strings.Split(view, "\n")
+	width := 1
+	for _, line := range lines {
+		width = max(width, ansi.StringWidth(line))
+	}
+
+	buf := buffer.NewBuffer(width, len(lines))
+	buf.WriteANSI(buffer.Point{}, view)
+	return buf
+}
+
+// Save writes a screenshot of buf to path in the format given by its
+// extension: .html (or .htm), .svg or .png.
+func Save(path string, buf *buffer.Buffer, opts Options) error {
+	var write func(f *os.File) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".html", ".htm":
+		write = func(f *os.File) error { return WriteHTML(f, buf, opts) }
+	case ".svg":
+		write = func(f *os.File) error { return WriteSVG(f, buf, opts) }
+	case ".png":
+		write = func(f *os.File) error { return WritePNG(f, buf, opts) }
+	default:
+		return fmt.Errorf("screenshot: unsupported format %q", ext)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// color resolves c, in lipgloss notation, to a color of the theme. The
+// default color and raw SGR parameters resolve to def.
+func (t Theme) color(c string, def color.RGBA) color.RGBA {
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n < len(t.Palette) {
+		return t.Palette[n]
+	}
+	if r, g, b, ok := colorprofile.RGB(c); ok {
+		return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+	}
+	return def
+}
+
+// colors returns the foreground and background a cell with style s is
+// drawn with, after reverse video and faint are applied.
+func (t Theme) colors(s buffer.Style) (fg, bg color.RGBA) {
+	fg = t.color(s.Foreground, t.Foreground)
+	bg = t.color(s.Background, t.Background)
+	if s.Reverse {
+		fg, bg = bg, fg
+	}
+	if s.Faint {
+		fg = blend(bg, fg, 0x80)
+	}
+	return fg, bg
+}
+
+// underline returns the color of the underline of a cell with style s.
+func (t Theme) underline(s buffer.Style, fg color.RGBA) color.RGBA {
+	return t.color(s.UnderlineColor, fg)
+}
+
+// blend mixes fg over bg with the given opacity out of 0xff.
+func blend(bg, fg color.RGBA, alpha int) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*(0xff-alpha) + int(b)*alpha) / 0xff)
+	}
+	return color.RGBA{R: mix(bg.R, fg.R), G: mix(bg.G, fg.G), B: mix(bg.B, fg.B), A: 0xff}
+}
+
+// hex formats c as a CSS color.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// cellText returns the text displayed by c, a space for empty cells.
+func cellText(c buffer.Cell) string {
+	if s := c.Content(); s != "" {
+		return s
+	}
+	return " "
+}
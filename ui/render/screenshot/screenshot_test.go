@@ -0,0 +1,144 @@
+package screenshot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+)
+
+// view is a small frame with colors, a wide character and a border.
+const view = "\x1b[1;31mError\x1b[0m <a&b>\n\x1b[44m  \x1b[0m日本\n┌─┐█▀"
+
+func TestFromView(t *testing.T) {
+	buf := FromView(view + "\n")
+	if buf.Width() != 11 || buf.Height() != 3 {
+		t.Fatalf("size = %dx%d, want 11x3", buf.Width(), buf.Height())
+	}
+	c := buf.CellAt(buffer.Point{X: 0, Y: 0})
+	if c.Char != 'E' || c.Style.Foreground != "1" || !c.Style.Bold {
+		t.Errorf("cell 0,0 = %+v", c)
+	}
+	if c := buf.CellAt(buffer.Point{X: 2, Y: 1}); c.Char != '日' || c.Width != 2 {
+		t.Errorf("cell 2,1 = %+v", c)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteHTML(&out, FromView(view), Options{Title: "demo"}); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+	for _, want := range []string{
+		"<title>demo</title>",
+		`<span style="color:#800000;font-weight:bold">Error</span> &lt;a&amp;b&gt;`,
+		`<span style="background:#000080">  </span>日本`,
+		"┌─┐█▀",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML missing %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var out bytes.Buffer
+	opts := Options{FontSize: 10, Padding: 0}
+	if err := WriteSVG(&out, FromView(view), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// The output must be well-formed XML
+	dec := xml.NewDecoder(bytes.NewReader(out.Bytes()))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err.Error() != "EOF" {
+				t.Fatalf("invalid SVG: %v\n%s", err, out.String())
+			}
+			break
+		}
+	}
+
+	svg := out.String()
+	for _, want := range []string{
+		`width="66" height="36"`,
+		`<rect x="0" y="12" width="12" height="12" fill="#000080"/>`,
+		`<text x="0 6 12 18 24" y="9.5" fill="#800000" font-weight="bold">Error</text>`,
+		// The wide characters take two columns each
+		`<text x="12 24" y="21.5" fill="#d0d0d0">日本</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	theme := DefaultTheme
+	img := Image(FromView(view), Options{Scale: 1, Padding: 0})
+	if got := img.Bounds().Size(); got.X != 11*cellWidth || got.Y != 3*cellHeight {
+		t.Fatalf("size = %v", got)
+	}
+
+	at := func(col, row, dx, dy int) color.RGBA {
+		return img.RGBAAt(col*cellWidth+dx, row*cellHeight+dy)
+	}
+	if got := at(0, 0, 0, 0); got != theme.Background {
+		t.Errorf("background = %v", got)
+	}
+	// The left stroke of the bold red E
+	if got := at(0, 0, 1, 6); got != theme.Palette[1] {
+		t.Errorf("E stroke = %v", got)
+	}
+	if got := at(0, 1, 3, 3); got != theme.Palette[4] {
+		t.Errorf("blue background = %v", got)
+	}
+	// ┌ joins ─ at the middle of the cell edge
+	if got := at(0, 2, cellWidth-1, cellHeight/2); got != theme.Foreground {
+		t.Errorf("box line = %v", got)
+	}
+	if got := at(0, 2, 0, 0); got != theme.Background {
+		t.Errorf("box corner = %v", got)
+	}
+	if got := at(3, 2, 0, cellHeight-1); got != theme.Foreground {
+		t.Errorf("full block = %v", got)
+	}
+	if got := at(4, 2, 0, cellHeight-1); got != theme.Background {
+		t.Errorf("upper half block = %v", got)
+	}
+
+	scaled := Image(FromView(view), Options{Scale: 3, Padding: 2})
+	if got := scaled.Bounds().Size(); got.X != (11*cellWidth+4)*3 || got.Y != (3*cellHeight+4)*3 {
+		t.Errorf("scaled size = %v", got)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	buf := FromView(view)
+	for _, name := range []string{"shot.html", "shot.svg", "shot.png"} {
+		if err := Save(filepath.Join(dir, name), buf, DefaultOptions()); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "shot.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("decoding PNG: %v", err)
+	}
+
+	if err := Save(filepath.Join(dir, "shot.gif"), buf, DefaultOptions()); err == nil {
+		t.Error("Save() accepted an unsupported format")
+	}
+}
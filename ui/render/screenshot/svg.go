@@ -0,0 +1,143 @@
+package screenshot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wwsheng009/taproot/ui/render/buffer"
+)
+
+// Cell size of SVG output relative to the font size, matching the advance
+// and line height of common monospace fonts.
+const (
+	svgCellWidth  = 0.6
+	svgCellHeight = 1.2
+)
+
+// WriteSVG writes buf to w as an SVG image. Backgrounds are drawn as
+// rectangles on the cell grid and every character is placed at its own
+// column, so the layout holds even where the viewer's font lacks a glyph
+// or has a different advance.
+func WriteSVG(w io.Writer, buf *buffer.Buffer, opts Options) error {
+	opts = opts.withDefaults()
+	t := opts.Theme
+	cw := float64(opts.FontSize) * svgCellWidth
+	ch := float64(opts.FontSize) * svgCellHeight
+	pad := float64(opts.Padding)
+	width := float64(buf.Width())*cw + 2*pad
+	height := float64(buf.Height())*ch + 2*pad
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s" xml:space="preserve">
+<style>text { font-family: %s; font-size: %dpx; white-space: pre; }</style>
+<rect width="100%%" height="100%%" fill="%s"/>
+`, num(width), num(height), html.EscapeString(opts.FontFamily), opts.FontSize, hex(t.Background))
+
+	// Backgrounds, merged into runs of equal color
+	b.WriteString(`<g shape-rendering="crispEdges">` + "\n")
+	for y := 0; y < buf.Height(); y++ {
+		start := 0
+		for x := 0; x <= buf.Width(); x++ {
+			if x < buf.Width() && sameBackground(t, buf, x, y, start) {
+				continue
+			}
+			if _, bg := t.colors(buf.CellAt(buffer.Point{X: start, Y: y}).Style); bg != t.Background {
+				fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+					num(pad+float64(start)*cw), num(pad+float64(y)*ch), num(float64(x-start)*cw), num(ch), hex(bg))
+			}
+			start = x
+		}
+	}
+	b.WriteString("</g>\n")
+
+	// Text, in runs of equal style. A cluster of several runes gets an
+	// element of its own, since x positions apply to single characters.
+	for y := 0; y < buf.Height(); y++ {
+		baseline := pad + float64(y)*ch + (ch+float64(opts.FontSize)*0.7)/2
+		var run svgRun
+		flush := func() {
+			run.write(&b, t, baseline)
+			run = svgRun{}
+		}
+		for x := 0; x < buf.Width(); x++ {
+			c := buf.CellAt(buffer.Point{X: x, Y: y})
+			if c.IsContinuation {
+				continue
+			}
+			text := cellText(c)
+			single := utf8.RuneCountInString(text) == 1
+			if len(run.xs) > 0 && (c.Style != run.style || !single || !run.single) {
+				flush()
+			}
+			run.style = c.Style
+			run.single = single
+			run.xs = append(run.xs, num(pad+float64(x)*cw))
+			run.text.WriteString(text)
+		}
+		flush()
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sameBackground reports whether cells x and start of row y have the same
+// background color.
+func sameBackground(t Theme, buf *buffer.Buffer, x, y, start int) bool {
+	_, a := t.colors(buf.CellAt(buffer.Point{X: x, Y: y}).Style)
+	_, b := t.colors(buf.CellAt(buffer.Point{X: start, Y: y}).Style)
+	return a == b
+}
+
+// svgRun collects equally styled characters of a row.
+type svgRun struct {
+	style  buffer.Style
+	single bool
+	xs     []string
+	text   strings.Builder
+}
+
+// write writes the run as a text element, unless it is only blanks.
+func (r *svgRun) write(b *strings.Builder, t Theme, baseline float64) {
+	text, xs := r.text.String(), r.xs
+	if !r.style.Underline && !r.style.Strikethrough {
+		// Trailing blanks show nothing, the background is already drawn
+		trimmed := strings.TrimRight(text, " ")
+		xs = xs[:len(xs)-(len(text)-len(trimmed))]
+		text = trimmed
+	}
+	if len(xs) == 0 {
+		return
+	}
+
+	fg, _ := t.colors(r.style)
+	fmt.Fprintf(b, `<text x="%s" y="%s" fill="%s"`, strings.Join(xs, " "), num(baseline), hex(fg))
+	if r.style.Bold {
+		b.WriteString(` font-weight="bold"`)
+	}
+	if r.style.Italic {
+		b.WriteString(` font-style="italic"`)
+	}
+	var lines []string
+	if r.style.Underline {
+		lines = append(lines, "underline")
+	}
+	if r.style.Strikethrough {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		fmt.Fprintf(b, ` text-decoration="%s"`, strings.Join(lines, " "))
+	}
+	fmt.Fprintf(b, ">%s</text>\n", html.EscapeString(text))
+}
+
+// num formats a coordinate with at most two decimals.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,275 @@
+package render
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	uvprofile "github.com/charmbracelet/colorprofile"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// screen is the part of uv.Terminal the UltravioletEngine draws with, so
+// the engine can also serve streams that are not terminal devices.
+type screen interface {
+	Start() error
+	Teardown() error
+	Pause() error
+	Resume() error
+	Events() <-chan uv.Event
+
+	EnterAltScreen()
+	ExitAltScreen()
+	ShowCursor()
+	HideCursor()
+	MoveTo(x, y int)
+	Erase()
+	Resize(width, height int) error
+	Draw(d uv.Drawable)
+	Display() error
+	PrependString(str string)
+	WriteString(s string) (int, error)
+}
+
+var _ screen = (*uv.Terminal)(nil)
+
+// defaultStreamSize is the size of a stream session that was not given one.
+var defaultStreamSize = uv.Size{Width: 80, Height: 24}
+
+// streamScreen is a screen over a connection to a terminal at the other
+// end of a socket, SSH channel or pipe. Unlike uv.Terminal it puts no
+// device into raw mode and doesn't watch for SIGWINCH: the client owns the
+// terminal and reports size changes through Engine.Resize.
+//
+// The event channel is closed when the input stream ends, i.e. when the
+// client disconnects.
+type streamScreen struct {
+	in      io.Reader
+	out     io.Writer
+	environ []string
+	size    uv.Size
+
+	scr     *uv.TerminalRenderer
+	buf     *uv.RenderBuffer
+	state   streamState
+	last    *streamState // state at the last Display, nil to apply it all
+	prepend []string
+
+	events chan uv.Event
+	cancel context.CancelFunc
+	paused atomic.Bool
+}
+
+type streamState struct {
+	altscreen bool
+	curHidden bool
+	cur       uv.Position // cursor position after Display, (-1, -1) for none
+}
+
+// newStreamScreen returns a screen that reads input from in and draws to
+// out, for a terminal of the given size described by environ.
+func newStreamScreen(in io.Reader, out io.Writer, environ []string, width, height int) *streamScreen {
+	size := uv.Size{Width: width, Height: height}
+	if width <= 0 || height <= 0 {
+		size = defaultStreamSize
+	}
+	return &streamScreen{
+		in:      in,
+		out:     out,
+		environ: environ,
+		size:    size,
+		buf:     uv.NewRenderBuffer(0, 0),
+		state:   streamState{altscreen: true, curHidden: true, cur: uv.Pos(-1, -1)},
+	}
+}
+
+// Start begins reading input and reports the initial size as the first
+// event.
+func (s *streamScreen) Start() error {
+	s.scr = uv.NewTerminalRenderer(s.out, s.environ)
+	// The output is not a terminal, so the profile comes from TERM alone
+	s.scr.SetColorProfile(uvprofile.Env(s.environ))
+	s.buf.Resize(s.size.Width, s.size.Height)
+	s.scr.Resize(s.size.Width, s.size.Height)
+	s.scr.Erase()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.events = make(chan uv.Event)
+	go s.read(ctx)
+	return nil
+}
+
+// read forwards input events until the input ends or ctx is done.
+func (s *streamScreen) read(ctx context.Context) {
+	defer close(s.events)
+
+	raw := make(chan uv.Event)
+	go func() {
+		defer close(raw)
+		raw <- uv.WindowSizeEvent(s.size)
+		rd := uv.NewTerminalReader(s.in, uv.Environ(s.environ).Getenv("TERM"))
+		_ = rd.StreamEvents(ctx, raw)
+	}()
+
+	for event := range raw {
+		// Input that arrives while paused belongs to whatever runs in
+		// the meantime
+		if s.paused.Load() {
+			continue
+		}
+		select {
+		case s.events <- event:
+		case <-ctx.Done():
+			// Unblock the reader so it can see ctx
+			go func() {
+				for range raw {
+				}
+			}()
+			return
+		}
+	}
+}
+
+// Events returns the input events. The channel is closed when the input
+// stream ends.
+func (s *streamScreen) Events() <-chan uv.Event {
+	return s.events
+}
+
+// Teardown restores the client's screen and stops reading input. A read
+// that is blocked on the stream returns when the stream is closed.
+func (s *streamScreen) Teardown() error {
+	err := s.restore()
+	s.cancel()
+	return err
+}
+
+// Pause restores the client's screen and holds back input until Resume.
+func (s *streamScreen) Pause() error {
+	s.paused.Store(true)
+	return s.restore()
+}
+
+// Resume takes the screen back after Pause; the next Display applies the
+// current state again.
+func (s *streamScreen) Resume() error {
+	s.paused.Store(false)
+	s.last = nil
+	return nil
+}
+
+// restore leaves the alternate screen, or moves below the inline view,
+// and shows the cursor again.
+func (s *streamScreen) restore() error {
+	if last := s.last; last != nil {
+		if last.altscreen {
+			s.scr.ExitAltScreen()
+		} else {
+			s.scr.MoveTo(0, s.buf.Height()-1)
+			_, _ = s.scr.WriteString("\r" + ansi.EraseScreenBelow)
+		}
+		if last.curHidden {
+			_, _ = s.scr.WriteString(ansi.SetModeTextCursorEnable)
+		}
+	}
+	s.last = nil
+	err := s.scr.Flush()
+	s.scr.SetPosition(-1, -1)
+	return err
+}
+
+func (s *streamScreen) EnterAltScreen() { s.state.altscreen = true }
+func (s *streamScreen) ExitAltScreen()  { s.state.altscreen = false }
+func (s *streamScreen) ShowCursor()     { s.state.curHidden = false }
+func (s *streamScreen) HideCursor()     { s.state.curHidden = true }
+
+// MoveTo moves the cursor to (x, y) after the next Display. A position of
+// (-1, -1) leaves it where rendering ends.
+func (s *streamScreen) MoveTo(x, y int) { s.state.cur = uv.Pos(x, y) }
+
+// Erase clears the screen on the next Display.
+func (s *streamScreen) Erase() {
+	s.buf.Touched = nil
+	s.scr.Erase()
+	s.buf.Clear()
+}
+
+// Resize sets the size of the client's terminal.
+func (s *streamScreen) Resize(width, height int) error {
+	s.size = uv.Size{Width: width, Height: height}
+	s.buf.Touched = nil
+	s.buf.Resize(width, height)
+	s.scr.Resize(width, height)
+	return nil
+}
+
+// Draw draws d into the screen buffer the way uv.Terminal does: the
+// buffer is as tall as the drawable, up to the terminal height, keeping
+// the bottom lines of taller ones.
+func (s *streamScreen) Draw(d uv.Drawable) {
+	height := s.size.Height
+	switch layer := d.(type) {
+	case *uv.StyledString:
+		height = layer.Height()
+	case interface{ Height() int }:
+		height = layer.Height()
+	}
+
+	s.buf.Resize(s.size.Width, height)
+	s.buf.Clear()
+	d.Draw(uv.ScreenBuffer{RenderBuffer: s.buf, Method: ansi.WcWidth}, s.buf.Bounds())
+	if height > s.size.Height {
+		s.buf.Lines = s.buf.Lines[height-s.size.Height:]
+	}
+}
+
+// Display writes the changes since the last Display to the stream.
+func (s *streamScreen) Display() error {
+	state := s.state
+	altChanged := s.last == nil || s.last.altscreen != state.altscreen
+	if altChanged {
+		if state.altscreen {
+			s.scr.EnterAltScreen()
+		} else {
+			s.scr.ExitAltScreen()
+		}
+	}
+	if altChanged || s.last.curHidden != state.curHidden {
+		if state.curHidden {
+			_, _ = s.scr.WriteString(ansi.ResetModeTextCursorEnable)
+		} else {
+			_, _ = s.scr.WriteString(ansi.SetModeTextCursorEnable)
+		}
+	}
+
+	s.scr.Render(s.buf)
+	for _, str := range s.prepend {
+		lines := strings.Split(str, "\n")
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, s.size.Width, "")
+		}
+		s.scr.PrependString(s.buf, strings.Join(lines, "\n"))
+	}
+	s.prepend = s.prepend[:0]
+
+	// The cursor is moved last, since rendering moves it around
+	if state.cur != uv.Pos(-1, -1) {
+		s.scr.MoveTo(state.cur.X, state.cur.Y)
+	}
+
+	s.last = &state
+	return s.scr.Flush()
+}
+
+// PrependString queues lines to print above an inline view.
+func (s *streamScreen) PrependString(str string) {
+	s.prepend = append(s.prepend, str)
+}
+
+// WriteString queues raw output, such as mode changes, for the next flush.
+func (s *streamScreen) WriteString(str string) (int, error) {
+	return s.scr.WriteString(str)
+}
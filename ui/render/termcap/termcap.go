@@ -0,0 +1,339 @@
+// Package termcap asks the terminal what it supports instead of guessing
+// from environment variables.
+//
+// A probe writes Query to the terminal and reads the replies: device
+// attributes (DA1 and DA2), the XTVERSION name, whether the kitty graphics
+// protocol is available, the background color (OSC 11) and the window and
+// cell sizes in pixels (CSI 14t and 16t). Engines run the probe over their
+// own input stream when a model returns render.ProbeCapabilities; Probe
+// runs it over any reader and writer.
+//
+// Engines keep the result of their own probe and report it to the model,
+// since one process may serve several terminals. Code far from the engine
+// (image renderers, the clipboard, styles) consults the process-wide
+// capabilities with Current instead, which a program with a single
+// terminal sets with Set. Callers fall back to their environment
+// heuristics while Probed is false.
+package termcap
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DefaultTimeout is how long a probe waits for replies when no timeout is
+// given. Terminals answer in a few milliseconds; the margin is for slow
+// links such as ssh.
+const DefaultTimeout = 500 * time.Millisecond
+
+// kittyQueryID identifies the reply to the kitty graphics query.
+const kittyQueryID = 31
+
+// Query is the sequence written to the terminal to probe it. The primary
+// device attributes request comes last: every terminal answers it and
+// replies arrive in order, so its reply marks the end of the probe.
+const Query = ansi.RequestNameVersion +
+	"\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\" +
+	ansi.RequestBackgroundColor +
+	"\x1b[14t" +
+	"\x1b[16t" +
+	ansi.RequestSecondaryDeviceAttributes +
+	ansi.RequestPrimaryDeviceAttributes
+
+// Primary device attributes of interest.
+const (
+	AttrSixel     = 4
+	AttrClipboard = 52
+)
+
+// ErrTimeout is returned by Probe when the terminal does not finish
+// answering in time.
+var ErrTimeout = errors.New("termcap: probe timed out")
+
+// Capabilities is what a terminal reported about itself. Fields the
+// terminal did not answer are left zero.
+type Capabilities struct {
+	// Probed reports whether the terminal answered a probe.
+	Probed bool
+
+	// Attributes are the primary device attributes (DA1), e.g. 4 for
+	// sixel graphics and 52 for clipboard access through OSC 52.
+	Attributes []int
+	// TerminalType and Firmware are the first two secondary device
+	// attributes (DA2).
+	TerminalType int
+	Firmware     int
+	// Version is the XTVERSION reply, e.g. "kitty(0.32.2)".
+	Version string
+
+	// KittyGraphics reports support for the kitty graphics protocol.
+	KittyGraphics bool
+
+	// Background is the default background color, nil if unknown.
+	Background color.Color
+
+	// WindowWidth and WindowHeight are the text area size in pixels.
+	WindowWidth, WindowHeight int
+	// CellWidth and CellHeight are the size of a cell in pixels.
+	CellWidth, CellHeight int
+}
+
+// HasAttribute reports whether the terminal listed attr in its primary
+// device attributes.
+func (c Capabilities) HasAttribute(attr int) bool {
+	return slices.Contains(c.Attributes, attr)
+}
+
+// Sixel reports whether the terminal advertises sixel graphics.
+func (c Capabilities) Sixel() bool {
+	return c.HasAttribute(AttrSixel)
+}
+
+// Clipboard reports whether the terminal advertises clipboard access
+// through OSC 52. Several terminals support OSC 52 without listing it, so
+// false only means it was not advertised.
+func (c Capabilities) Clipboard() bool {
+	return c.HasAttribute(AttrClipboard)
+}
+
+// DarkBackground reports whether the background color is dark. An
+// unknown background is assumed to be dark.
+func (c Capabilities) DarkBackground() bool {
+	if c.Background == nil {
+		return true
+	}
+	r, g, b, _ := c.Background.RGBA()
+	// Perceived lightness from the Rec. 601 luma coefficients
+	luma := (299*r + 587*g + 114*b) / 1000
+	return luma < 0x8000
+}
+
+// CellSize returns the cell size in pixels. When the terminal only
+// reported its window size, the cell size is derived from it and the
+// window size in cells; ok is false if neither is known.
+func (c Capabilities) CellSize(cols, rows int) (width, height int, ok bool) {
+	if c.CellWidth > 0 && c.CellHeight > 0 {
+		return c.CellWidth, c.CellHeight, true
+	}
+	if c.WindowWidth > 0 && c.WindowHeight > 0 && cols > 0 && rows > 0 {
+		return c.WindowWidth / cols, c.WindowHeight / rows, true
+	}
+	return 0, 0, false
+}
+
+// Collector builds Capabilities from the terminal's replies to Query.
+// The zero value is ready to use.
+type Collector struct {
+	caps Capabilities
+	done bool
+}
+
+// Handle records event if it is a reply to Query and reports whether it
+// was one. Other events, such as key presses typed during the probe, are
+// left to the caller.
+func (c *Collector) Handle(event uv.Event) bool {
+	switch evt := event.(type) {
+	case uv.MultiEvent:
+		handled := false
+		for _, e := range evt {
+			if c.Handle(e) {
+				handled = true
+			}
+		}
+		return handled
+	case uv.PrimaryDeviceAttributesEvent:
+		c.caps.Attributes = append([]int(nil), evt...)
+		c.done = true
+	case uv.SecondaryDeviceAttributesEvent:
+		if len(evt) > 0 {
+			c.caps.TerminalType = evt[0]
+		}
+		if len(evt) > 1 {
+			c.caps.Firmware = evt[1]
+		}
+	case uv.TerminalVersionEvent:
+		c.caps.Version = evt.Name
+	case uv.KittyGraphicsEvent:
+		if evt.Options.ID != kittyQueryID {
+			return false
+		}
+		c.caps.KittyGraphics = string(evt.Payload) == "OK"
+	case uv.BackgroundColorEvent:
+		c.caps.Background = evt.Color
+	case uv.PixelSizeEvent:
+		c.caps.WindowWidth, c.caps.WindowHeight = evt.Width, evt.Height
+	case uv.CellSizeEvent:
+		c.caps.CellWidth, c.caps.CellHeight = evt.Width, evt.Height
+	default:
+		return false
+	}
+	c.caps.Probed = true
+	return true
+}
+
+// Done reports whether the terminal has finished answering.
+func (c *Collector) Done() bool {
+	return c.done
+}
+
+// Capabilities returns what has been collected so far.
+func (c *Collector) Capabilities() Capabilities {
+	return c.caps
+}
+
+// IsReply reports whether event is one of the replies a probe collects.
+func IsReply(event uv.Event) bool {
+	var c Collector
+	return c.Handle(event)
+}
+
+// deadliner is implemented by streams whose reads can be interrupted,
+// such as *os.File pipes and terminals.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// Probe writes Query to w and collects the replies read from r for up to
+// timeout (DefaultTimeout if zero). The terminal behind r must be in raw
+// mode, or the replies wait for a newline. On timeout Probe returns what
+// it has collected along with ErrTimeout. Input that is not a reply is
+// discarded.
+//
+// If r supports read deadlines, a read blocked at the timeout is
+// interrupted and the deadline cleared again, so r stays usable;
+// otherwise the read finishes in the background and its data is dropped.
+// Either way nothing is read from r after Probe returns. Engines probe
+// through their own input stream instead, so no keystrokes are lost.
+func Probe(r io.Reader, w io.Writer, timeout time.Duration) (Capabilities, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if _, err := io.WriteString(w, Query); err != nil {
+		return Capabilities{}, err
+	}
+
+	var (
+		mu        sync.Mutex
+		collector Collector
+		finished  = make(chan error, 1)
+		stop      = make(chan struct{})
+		exited    = make(chan struct{})
+	)
+	go func() {
+		defer close(exited)
+		var decoder uv.EventDecoder
+		var pending []byte
+		buf := make([]byte, 256)
+		for {
+			n, err := r.Read(buf)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			pending = append(pending, buf[:n]...)
+			mu.Lock()
+			pending = decode(&decoder, &collector, pending)
+			done := collector.Done()
+			mu.Unlock()
+			if done {
+				finished <- nil
+				return
+			}
+			if err != nil {
+				finished <- err
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-finished:
+		mu.Lock()
+		defer mu.Unlock()
+		if err == io.EOF {
+			err = ErrTimeout
+		}
+		return collector.Capabilities(), err
+	case <-timer.C:
+		close(stop)
+		if d, ok := r.(deadliner); ok && d.SetReadDeadline(time.Now()) == nil {
+			<-exited
+			_ = d.SetReadDeadline(time.Time{})
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return collector.Capabilities(), ErrTimeout
+	}
+}
+
+// decode feeds the complete sequences in b to c and returns the rest. An
+// escape sequence cut off by the end of b is kept for the next read.
+func decode(decoder *uv.EventDecoder, c *Collector, b []byte) []byte {
+	for len(b) > 0 && !c.Done() && !incomplete(b) {
+		n, event := decoder.Decode(b)
+		if n == 0 {
+			break
+		}
+		c.Handle(event)
+		b = b[n:]
+	}
+	return b
+}
+
+// incomplete reports whether b starts with an escape sequence whose end
+// has not been read yet.
+func incomplete(b []byte) bool {
+	if b[0] != ansi.ESC {
+		return false
+	}
+	if len(b) == 1 {
+		return true
+	}
+	switch b[1] {
+	case '[':
+		// CSI ends with a final byte in 0x40-0x7e
+		for _, c := range b[2:] {
+			if c >= 0x40 && c <= 0x7e {
+				return false
+			}
+		}
+		return true
+	case ']', 'P', '_', '^', 'X':
+		// OSC, DCS, APC, PM and SOS end with BEL or ST
+		rest := b[2:]
+		return !bytes.Contains(rest, []byte{ansi.BEL}) && !bytes.Contains(rest, []byte("\x1b\\"))
+	}
+	return false
+}
+
+var (
+	mu      sync.RWMutex
+	current Capabilities
+)
+
+// Current returns the process-wide capabilities. Probed is false until
+// they are set.
+func Current() Capabilities {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set replaces the process-wide capabilities, e.g. with the
+// render.CapabilitiesMsg of a program's only terminal, or in tests.
+func Set(c Capabilities) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = c
+}
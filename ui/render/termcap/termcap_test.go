@@ -0,0 +1,157 @@
+package termcap
+
+import (
+	"errors"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// replies is what a kitty-like terminal answers to Query.
+const replies = "\x1bP>|kitty(0.32.2)\x1b\\" +
+	"\x1b_Gi=31;OK\x1b\\" +
+	"\x1b]11;rgb:ffff/ffff/eeee\x1b\\" +
+	"\x1b[4;480;800t" +
+	"\x1b[6;20;10t" +
+	"\x1b[>1;4000;29c" +
+	"\x1b[?62;4;22;52c"
+
+// fakeTerminal reads the query from a pipe and answers with reply, split
+// into chunks to exercise sequences spanning reads. Writes to keys are
+// typed into the terminal's input.
+func fakeTerminal(t *testing.T, reply string, chunk int) (in io.Reader, out, keys io.Writer) {
+	t.Helper()
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		inR.Close()
+		inW.Close()
+		outR.Close()
+		outW.Close()
+	})
+
+	go func() {
+		query := make([]byte, len(Query))
+		if _, err := io.ReadFull(outR, query); err != nil || string(query) != Query {
+			return
+		}
+		for len(reply) > 0 {
+			n := min(chunk, len(reply))
+			inW.Write([]byte(reply[:n]))
+			reply = reply[n:]
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	return inR, outW, inW
+}
+
+func TestProbe(t *testing.T) {
+	for _, chunk := range []int{len(replies), 7} {
+		in, out, _ := fakeTerminal(t, "x"+replies, chunk)
+		caps, err := Probe(in, out, 5*time.Second)
+		if err != nil {
+			t.Fatalf("chunk %d: %v", chunk, err)
+		}
+		if !caps.Probed || caps.Version != "kitty(0.32.2)" || !caps.KittyGraphics {
+			t.Errorf("chunk %d: caps = %+v", chunk, caps)
+		}
+		if caps.TerminalType != 1 || caps.Firmware != 4000 {
+			t.Errorf("chunk %d: DA2 = %d, %d", chunk, caps.TerminalType, caps.Firmware)
+		}
+		if !caps.Sixel() || !caps.Clipboard() || caps.HasAttribute(28) {
+			t.Errorf("chunk %d: attributes = %v", chunk, caps.Attributes)
+		}
+		if caps.DarkBackground() {
+			t.Errorf("chunk %d: background %v reported dark", chunk, caps.Background)
+		}
+		if caps.WindowWidth != 800 || caps.WindowHeight != 480 || caps.CellWidth != 10 || caps.CellHeight != 20 {
+			t.Errorf("chunk %d: sizes = %+v", chunk, caps)
+		}
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	// A terminal that only understands XTVERSION never finishes answering
+	in, out, keys := fakeTerminal(t, "\x1bP>|xterm(390)\x1b\\", 64)
+	start := time.Now()
+	caps, err := Probe(in, out, 50*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("err = %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("probe took %v", time.Since(start))
+	}
+	if !caps.Probed || caps.Version != "xterm(390)" || caps.KittyGraphics {
+		t.Errorf("caps = %+v", caps)
+	}
+
+	// The probe stops reading, so keys typed afterwards reach the caller
+	if _, err := io.WriteString(keys, "q"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 8)
+	if n, err := in.Read(buf); err != nil || string(buf[:n]) != "q" {
+		t.Errorf("read after timeout = %q, %v", buf[:n], err)
+	}
+}
+
+func TestProbeEOF(t *testing.T) {
+	var out strings.Builder
+	caps, err := Probe(strings.NewReader(""), &out, time.Second)
+	if !errors.Is(err, ErrTimeout) || caps.Probed {
+		t.Errorf("caps = %+v, err = %v", caps, err)
+	}
+	if out.String() != Query {
+		t.Errorf("query = %q", out.String())
+	}
+}
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	if c.Handle(uv.KeyPressEvent{Code: 'a', Text: "a"}) {
+		t.Error("key press handled as a reply")
+	}
+	if c.Handle(uv.KittyGraphicsEvent{Payload: []byte("OK")}) {
+		t.Error("reply to another kitty image handled")
+	}
+	if !c.Handle(uv.MultiEvent{uv.CellSizeEvent{Width: 9, Height: 18}}) || c.Done() {
+		t.Errorf("caps = %+v, done = %v", c.Capabilities(), c.Done())
+	}
+	if !c.Handle(uv.PrimaryDeviceAttributesEvent{62}) || !c.Done() {
+		t.Error("DA1 did not end the probe")
+	}
+	if w, h, ok := c.Capabilities().CellSize(80, 24); !ok || w != 9 || h != 18 {
+		t.Errorf("CellSize() = %d, %d, %v", w, h, ok)
+	}
+
+	derived := Capabilities{WindowWidth: 800, WindowHeight: 480}
+	if w, h, ok := derived.CellSize(80, 24); !ok || w != 10 || h != 20 {
+		t.Errorf("derived CellSize() = %d, %d, %v", w, h, ok)
+	}
+	if _, _, ok := derived.CellSize(0, 0); ok {
+		t.Error("CellSize() known without a window size in cells")
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	defer Set(Current())
+
+	if !(Capabilities{}).DarkBackground() {
+		t.Error("unknown background not assumed dark")
+	}
+	Set(Capabilities{Probed: true, Background: color.RGBA{R: 0x10, G: 0x10, B: 0x20, A: 0xff}})
+	if caps := Current(); !caps.Probed || !caps.DarkBackground() {
+		t.Errorf("Current() = %+v", caps)
+	}
+}
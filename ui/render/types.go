@@ -1,6 +1,7 @@
 package render
 
 import (
+	"strings"
 	"time"
 )
 
@@ -119,12 +120,36 @@ func IsQuit(cmd Cmd) bool {
 type Msg any
 
 // KeyMsg represents a keyboard input message.
+//
+// Key holds the key name without modifiers ("a", "A", "enter", "up", " ").
+// For printable keys Text holds the characters produced, which already
+// reflect shift, so String() omits "shift+" in that case. Code, Repeat and
+// release events (Type == KeyRelease) are only fully populated by engines
+// and terminals that support the kitty keyboard protocol.
 type KeyMsg struct {
-	Key    string
-	Alt    bool
-	Ctrl   bool
-	Shift  bool
-	Type   KeyType
+	Key   string
+	Alt   bool
+	Ctrl  bool
+	Shift bool
+	Type  KeyType
+
+	// Meta, Hyper and Super are extra modifiers reported by the kitty
+	// keyboard protocol.
+	Meta  bool
+	Hyper bool
+	Super bool
+
+	// Code is the key pressed: a printable rune such as 'a', or one of the
+	// named key codes such as KeyEnter. Zero if unknown.
+	Code rune
+	// ShiftedCode is the shifted key, e.g. 'A' for shift+a, when reported.
+	ShiftedCode rune
+	// BaseCode is the key in the standard PC-101 layout, when reported.
+	BaseCode rune
+	// Text is the printable text produced by the key, if any.
+	Text string
+	// Repeat is true if the key is held down and auto-repeating.
+	Repeat bool
 }
 
 // KeyType represents the type of key event.
@@ -138,6 +163,8 @@ const (
 )
 
 // String returns the key string including modifiers.
+// Modifiers are always written in the order alt, ctrl, shift, meta, hyper,
+// super, e.g. "alt+ctrl+shift+up", matching the strings used in key maps.
 func (k KeyMsg) String() string {
 	prefix := ""
 	if k.Alt {
@@ -146,17 +173,73 @@ func (k KeyMsg) String() string {
 	if k.Ctrl {
 		prefix += "ctrl+"
 	}
-	if k.Shift {
+	if k.Shift && k.Text == "" {
 		prefix += "shift+"
 	}
+	if k.Meta {
+		prefix += "meta+"
+	}
+	if k.Hyper {
+		prefix += "hyper+"
+	}
+	if k.Super {
+		prefix += "super+"
+	}
 	return prefix + k.Key
 }
 
+// IsRelease returns true if this is a key release event.
+func (k KeyMsg) IsRelease() bool {
+	return k.Type == KeyRelease
+}
+
+// IsRepeat returns true if this is an auto-repeated key press.
+func (k KeyMsg) IsRepeat() bool {
+	return k.Repeat
+}
+
 // IsMouse returns true if this is a mouse event.
+// It always returns false; mouse input is delivered as MouseMsg.
 func (k KeyMsg) IsMouse() bool {
 	return false
 }
 
+// PasteMsg carries text pasted into the terminal as a single message.
+// Engines enable bracketed paste so a paste never arrives as a flood of
+// key messages. Text is passed on unmodified and may contain line breaks
+// ("\r", "\n" or "\r\n") and other control characters.
+type PasteMsg struct {
+	Text string
+}
+
+// Lines returns the pasted text split at line breaks, with tabs expanded
+// to four spaces and other control characters removed.
+func (p PasteMsg) Lines() []string {
+	text := strings.ReplaceAll(p.Text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+				return -1
+			}
+			return r
+		}, strings.ReplaceAll(line, "\t", "    "))
+	}
+	return lines
+}
+
+// SingleLine returns the pasted text for a single-line input: lines are
+// joined with spaces, tabs expanded and control characters removed. A
+// trailing line break, as left by copying whole lines, is dropped.
+func (p PasteMsg) SingleLine() string {
+	lines := p.Lines()
+	for len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, " ")
+}
+
 // ResizeMsg represents a terminal resize event.
 type ResizeMsg struct {
 	Width  int
@@ -168,15 +251,19 @@ type TickMsg struct {
 	Time time.Time
 }
 
-// FocusMsg represents a focus change event.
+// FocusMsg is sent when the terminal window gains or loses focus, if
+// EngineConfig.EnableFocusReporting is set. Components can pause
+// animations while the window is unfocused and refresh external state,
+// such as the clipboard or a file listing, when it regains focus.
 type FocusMsg struct {
 	Focused bool
 }
 
-// BlurMsg is sent when a component loses focus.
+// BlurMsg is sent when a component loses focus within the application.
 type BlurMsg struct{}
 
-// FocusMsg is sent when a component receives focus.
+// FocusGainMsg is sent when a component receives focus within the
+// application.
 type FocusGainMsg struct{}
 
 // ErrorMsg represents an error event.
@@ -4,6 +4,7 @@ import (
 	"github.com/alecthomas/chroma/v2"
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 const (
@@ -452,6 +453,14 @@ func (s *Styles) ChromaTheme() chroma.StyleEntries {
 	}
 }
 
+// HasDarkBackground reports whether the terminal background is dark. The
+// background color in termcap.Current is used once it has been set; until
+// then it is assumed to be dark, which is what the default palette is
+// designed for.
+func HasDarkBackground() bool {
+	return termcap.Current().DarkBackground()
+}
+
 // DefaultStyles returns the default styles for the UI.
 func DefaultStyles() Styles {
 	var (
@@ -8,6 +8,8 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/wwsheng009/taproot/ui/render/termcap"
 )
 
 // OSC52Provider - OSC 52 clipboard provider
@@ -33,6 +35,12 @@ func DetectOSC52Support() bool {
 		return true
 	}
 
+	// A probed terminal that advertises clipboard access supports it;
+	// many that don't advertise it still do, so fall through otherwise.
+	if termcap.Current().Clipboard() {
+		return true
+	}
+
 	// On Windows, assume modern terminals support OSC 52
 	if runtime.GOOS == "windows" {
 		// Check for Windows Terminal, ConEmu, etc.